@@ -4,12 +4,26 @@ import (
 	flags "github.com/jessevdk/go-flags"
 	"log"
 	"os"
+	"time"
 )
 
 type Config struct {
 	Host      string `long:"host" env:"SDK_APP_HOST"`
 	Port      string `long:"port" env:"SDK_APP_PORT"`
 	JWTSecret string `long:"secret" env:"JWT_SECRET"`
+
+	// Administrator whose identity executes the due standing orders
+	StandingOrdersUser     string        `long:"standing-orders-user" env:"STANDING_ORDERS_USER" default:"s1"`
+	StandingOrdersInterval time.Duration `long:"standing-orders-interval" env:"STANDING_ORDERS_INTERVAL" default:"1m"`
+
+	// Administrator whose identity locks and claims the incoming side of
+	// cross-channel transfers, which are refunded when not claimed in time
+	CrossChannelUser    string        `long:"cross-channel-user" env:"CROSS_CHANNEL_USER" default:"s1"`
+	CrossChannelTimeout time.Duration `long:"cross-channel-timeout" env:"CROSS_CHANNEL_TIMEOUT" default:"5m"`
+
+	// User whose identity subscribes to the chaincode events of every channel
+	EventsUser          string        `long:"events-user" env:"EVENTS_USER" default:"s1"`
+	EventsRetryInterval time.Duration `long:"events-retry-interval" env:"EVENTS_RETRY_INTERVAL" default:"10s"`
 }
 
 func LoadConfig() (Config, error) {
@@ -0,0 +1,177 @@
+package coordinator
+
+import (
+	"app/model"
+	"app/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// Coordinator moves money between accounts on different channels with hash
+// time locks. The money leaving the source account is locked first, for twice
+// the timeout, then the money arriving to the destination account, which an
+// administrator locks from the clearing account of that channel. Every other
+// step runs with the identity of the user owning the source account. Claiming the
+// destination side reveals the secret on its ledger, which then claims the
+// source side. Locks that are not claimed in time are refunded, so the
+// transfer either completes on both channels or on neither.
+type Coordinator struct {
+	Admin      model.UserInfo
+	ChainCodes map[string]string
+	Timeout    time.Duration
+}
+
+type Transfer struct {
+	SrcChannel string
+	SrcAccount string
+	DstChannel string
+	DstAccount string
+	Amount     string
+}
+
+type Result struct {
+	HashLock string
+	Outgoing *model.Transaction
+	Incoming *model.Transaction
+}
+
+// Transfer makes the transfer on behalf of the user owning the source account.
+// When the destination side was claimed but the source side was not, the
+// partial result is returned with the error and Complete finishes it.
+func (c *Coordinator) Transfer(user model.UserInfo, transfer Transfer) (*Result, error) {
+	if _, ok := c.ChainCodes[transfer.SrcChannel]; !ok {
+		return nil, fmt.Errorf("unknown channel %s", transfer.SrcChannel)
+	}
+	if _, ok := c.ChainCodes[transfer.DstChannel]; !ok {
+		return nil, fmt.Errorf("unknown channel %s", transfer.DstChannel)
+	}
+	if transfer.SrcChannel == transfer.DstChannel {
+		return nil, fmt.Errorf("transfers within %s do not need to cross channels", transfer.SrcChannel)
+	}
+
+	secret := make([]byte, sha256.Size)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %v", err)
+	}
+	hash := sha256.Sum256(secret)
+	result := &Result{HashLock: hex.EncodeToString(hash[:])}
+
+	now := time.Now().UTC()
+	outgoingTimeout := now.Add(2 * c.Timeout).Format(time.RFC3339)
+	incomingTimeout := now.Add(c.Timeout).Format(time.RFC3339)
+
+	var outgoing model.HashTimeLock
+	err := c.submit(user, transfer.SrcChannel, &outgoing, "LockOutgoing", result.HashLock, transfer.SrcAccount, transfer.Amount, transfer.DstChannel, transfer.DstAccount, outgoingTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var incoming model.HashTimeLock
+	err = c.submit(c.Admin, transfer.DstChannel, &incoming, "LockIncoming", result.HashLock, transfer.DstAccount, transfer.Amount, string(outgoing.Currency), transfer.SrcChannel, transfer.SrcAccount, incomingTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock the money on %s, the money locked on %s is refunded after %s: %v", transfer.DstChannel, transfer.SrcChannel, outgoingTimeout, err)
+	}
+
+	result.Incoming = &model.Transaction{}
+	if err := c.submit(user, transfer.DstChannel, result.Incoming, "ClaimLock", result.HashLock, hex.EncodeToString(secret)); err != nil {
+		return nil, fmt.Errorf("failed to claim the money on %s, the locks are refunded after they time out: %v", transfer.DstChannel, err)
+	}
+
+	result.Outgoing = &model.Transaction{}
+	if err := c.submit(user, transfer.SrcChannel, result.Outgoing, "ClaimLock", result.HashLock, hex.EncodeToString(secret)); err != nil {
+		result.Outgoing = nil
+		return result, fmt.Errorf("failed to claim the money on %s, complete the transfer before %s: %v", transfer.SrcChannel, outgoingTimeout, err)
+	}
+
+	return result, nil
+}
+
+// Complete claims the source side of a transfer whose destination side was
+// claimed, with the secret revealed there. Only the user owning the source
+// account, or an administrator of its bank, completes it.
+func (c *Coordinator) Complete(user model.UserInfo, srcChannel, dstChannel, hashLock string) (*model.Transaction, error) {
+	var outgoing model.HashTimeLock
+	if err := c.evaluate(user, srcChannel, &outgoing, "ReadHashTimeLock", hashLock); err != nil {
+		return nil, err
+	}
+	if outgoing.Direction != model.LockOutgoing || outgoing.CounterpartyChannel != dstChannel {
+		return nil, fmt.Errorf("lock %s on %s is not a transfer to %s", hashLock, srcChannel, dstChannel)
+	}
+
+	// Strings are returned as they are, not as JSON
+	var secret []byte
+	err := c.withContract(user, dstChannel, func(contract *gateway.Contract) (err error) {
+		secret, err = contract.EvaluateTransaction("ReadLockSecret", hashLock)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var claimed model.Transaction
+	if err := c.submit(user, srcChannel, &claimed, "ClaimLock", hashLock, string(secret)); err != nil {
+		return nil, err
+	}
+	return &claimed, nil
+}
+
+// Currencies loads the currencies of the channel to format amounts with.
+func (c *Coordinator) Currencies(user model.UserInfo, channel string) (utils.Currencies, error) {
+	var currencies utils.Currencies
+	err := c.withContract(user, channel, func(contract *gateway.Contract) (err error) {
+		currencies, err = utils.LoadCurrencies(contract)
+		return err
+	})
+	return currencies, err
+}
+
+func (c *Coordinator) submit(user model.UserInfo, channel string, result interface{}, function string, args ...string) error {
+	return c.withContract(user, channel, func(contract *gateway.Contract) error {
+		response, err := contract.SubmitTransaction(function, args...)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(response, result)
+	})
+}
+
+func (c *Coordinator) evaluate(user model.UserInfo, channel string, result interface{}, function string, args ...string) error {
+	return c.withContract(user, channel, func(contract *gateway.Contract) error {
+		response, err := contract.EvaluateTransaction(function, args...)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(response, result)
+	})
+}
+
+func (c *Coordinator) withContract(user model.UserInfo, channel string, call func(contract *gateway.Contract) error) error {
+	chaincodeID, ok := c.ChainCodes[channel]
+	if !ok {
+		return fmt.Errorf("unknown channel %s", channel)
+	}
+
+	wallet, err := utils.CreateWallet(user.UserId, user.Organization, user.Admin)
+	if err != nil {
+		return fmt.Errorf("failed to create or populate wallet: %v", err)
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, user.Organization, user.UserId)
+	if err != nil {
+		return fmt.Errorf("failed to connect to gateway: %v", err)
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		return fmt.Errorf("failed to get network: %v", err)
+	}
+
+	return call(network.GetContract(chaincodeID))
+}
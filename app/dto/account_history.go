@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+	"time"
+)
+
+type AccountHistoryEntry struct {
+	TxId      string          `json:"txId"`
+	Timestamp time.Time       `json:"timestamp"`
+	Currency  string          `json:"currency"`
+	Balance   string          `json:"balance"`
+	Delta     string          `json:"delta"`
+	Operation model.Operation `json:"operation"`
+}
+
+func NewAccountHistory(history []model.AccountHistoryEntry, currencies utils.Currencies) []AccountHistoryEntry {
+	dtos := make([]AccountHistoryEntry, 0, len(history))
+	for _, entry := range history {
+		dtos = append(dtos, AccountHistoryEntry{
+			TxId:      entry.TxID,
+			Timestamp: entry.Timestamp,
+			Currency:  string(entry.Currency),
+			Balance:   currencies.FormatAmount(entry.Balance, entry.Currency),
+			Delta:     currencies.FormatAmount(entry.Delta, entry.Currency),
+			Operation: entry.Operation,
+		})
+	}
+	return dtos
+}
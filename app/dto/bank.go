@@ -0,0 +1,29 @@
+package dto
+
+import "app/model"
+
+type Bank struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	Headquarters string `json:"headquarters"`
+	Since        int    `json:"since"`
+	PIB          int    `json:"pib"`
+}
+
+func NewBank(bank model.Bank) Bank {
+	return Bank{
+		Id:           bank.ID,
+		Name:         bank.Name,
+		Headquarters: bank.Headquarters,
+		Since:        bank.Since,
+		PIB:          bank.PIB,
+	}
+}
+
+func NewBanks(banks []model.Bank) []Bank {
+	dtos := make([]Bank, 0, len(banks))
+	for _, bank := range banks {
+		dtos = append(dtos, NewBank(bank))
+	}
+	return dtos
+}
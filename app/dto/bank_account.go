@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+)
+
+type BankAccount struct {
+	Id       string `json:"id"`
+	Balance  string `json:"balance"`
+	Currency string `json:"currency"`
+	BankId   string `json:"bankId"`
+	Bank     Bank   `json:"bank"`
+	UserId   string `json:"userId"`
+	Status   string `json:"status"`
+	Product  string `json:"product"`
+
+	// The balance less the money on hold, the overdraft is on top of it
+	AvailableBalance string `json:"availableBalance"`
+	Held             string `json:"held"`
+
+	OverdraftLimit string `json:"overdraftLimit,omitempty"`
+	OverdraftRate  string `json:"overdraftRate,omitempty"`
+}
+
+func NewBankAccount(account model.BankAccountDetails, currencies utils.Currencies) BankAccount {
+	dto := BankAccount{
+		Id:       account.ID,
+		Balance:  currencies.FormatAmount(account.Balance, account.Currency),
+		Currency: string(account.Currency),
+		BankId:   account.BankID,
+		Bank:     NewBank(account.Bank),
+		UserId:   account.UserID,
+		Status:   string(account.Status),
+		Product:  string(account.Product),
+
+		AvailableBalance: currencies.FormatAmount(account.Balance-account.Held, account.Currency),
+		Held:             currencies.FormatAmount(account.Held, account.Currency),
+
+		OverdraftRate: account.OverdraftRate,
+	}
+	if account.OverdraftLimit > 0 {
+		dto.OverdraftLimit = currencies.FormatAmount(account.OverdraftLimit, account.Currency)
+	}
+	return dto
+}
+
+func NewBankAccounts(accounts []model.BankAccountDetails, currencies utils.Currencies) []BankAccount {
+	dtos := make([]BankAccount, 0, len(accounts))
+	for _, account := range accounts {
+		dtos = append(dtos, NewBankAccount(account, currencies))
+	}
+	return dtos
+}
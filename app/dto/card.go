@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"app/model"
+	"encoding/json"
+)
+
+type Card struct {
+	Id        string `json:"id"`
+	AccountId string `json:"accountId"`
+	MaskedPan string `json:"maskedPan"`
+	// The full number is only shown to the owner once, when the card is issued
+	Number     string `json:"number,omitempty"`
+	Network    string `json:"network"`
+	Expiry     string `json:"expiry"`
+	Status     string `json:"status"`
+	Currency   string `json:"currency"`
+	Replaces   string `json:"replaces,omitempty"`
+	ReplacedBy string `json:"replacedBy,omitempty"`
+}
+
+func NewCard(card model.Card) Card {
+	return Card{
+		Id:         card.ID,
+		AccountId:  card.AccountID,
+		MaskedPan:  card.MaskedPAN,
+		Network:    string(card.Network),
+		Expiry:     card.Expiry,
+		Status:     string(card.Status),
+		Currency:   string(card.Currency),
+		Replaces:   card.Replaces,
+		ReplacedBy: card.ReplacedBy,
+	}
+}
+
+func NewCards(cards []model.Card) []Card {
+	dtos := make([]Card, 0, len(cards))
+	for _, card := range cards {
+		dtos = append(dtos, NewCard(card))
+	}
+	return dtos
+}
+
+// NewTransientCardNumbers passes the numbers of the cards a transaction issues
+// in the transient map, in the order the cards are issued.
+func NewTransientCardNumbers(numbers []string) (map[string][]byte, error) {
+	numbersJSON, err := json.Marshal(numbers)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"cards": numbersJSON}, nil
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "app/model"
+
+type Currency struct {
+	Code       string             `json:"code"`
+	Name       string             `json:"name"`
+	MinorUnits int                `json:"minorUnits"`
+	Rounding   model.RoundingMode `json:"rounding"`
+	Enabled    bool               `json:"enabled"`
+}
+
+func NewCurrency(currency model.CurrencyDefinition) Currency {
+	return Currency{
+		Code:       string(currency.Code),
+		Name:       currency.Name,
+		MinorUnits: currency.MinorUnits,
+		Rounding:   currency.Rounding,
+		Enabled:    currency.Enabled,
+	}
+}
+
+func NewCurrencies(currencies []model.CurrencyDefinition) []Currency {
+	dtos := make([]Currency, 0, len(currencies))
+	for _, currency := range currencies {
+		dtos = append(dtos, NewCurrency(currency))
+	}
+	return dtos
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"app/model"
+	"time"
+)
+
+type ExchangeRate struct {
+	Id            string    `json:"id"`
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	Rate          string    `json:"rate"`
+	EffectiveFrom time.Time `json:"effectiveFrom"`
+	PublishedBy   string    `json:"publishedBy"`
+}
+
+func NewExchangeRate(exchangeRate model.ExchangeRate) ExchangeRate {
+	return ExchangeRate{
+		Id:            exchangeRate.ID,
+		From:          string(exchangeRate.From),
+		To:            string(exchangeRate.To),
+		Rate:          exchangeRate.Rate,
+		EffectiveFrom: exchangeRate.EffectiveFrom,
+		PublishedBy:   exchangeRate.PublishedBy,
+	}
+}
+
+func NewExchangeRates(exchangeRates []model.ExchangeRate) []ExchangeRate {
+	dtos := make([]ExchangeRate, 0, len(exchangeRates))
+	for _, exchangeRate := range exchangeRates {
+		dtos = append(dtos, NewExchangeRate(exchangeRate))
+	}
+	return dtos
+}
+
+type EffectiveExchangeRate struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Rate    string   `json:"rate"`
+	RateIds []string `json:"rateIds"`
+}
+
+func NewEffectiveExchangeRate(exchangeRate model.EffectiveExchangeRate) EffectiveExchangeRate {
+	return EffectiveExchangeRate{
+		From:    string(exchangeRate.From),
+		To:      string(exchangeRate.To),
+		Rate:    exchangeRate.Rate,
+		RateIds: exchangeRate.RateIDs,
+	}
+}
@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+)
+
+type AppliedFee struct {
+	Operation string `json:"operation"`
+	BankId    string `json:"bankId"`
+	Amount    string `json:"amount"`
+	Currency  string `json:"currency"`
+}
+
+// Flat fees have an amount, percentage fees a rate
+type Fee struct {
+	Type   string `json:"type"`
+	Amount string `json:"amount,omitempty"`
+	Rate   string `json:"rate,omitempty"`
+}
+
+type FeeSchedule struct {
+	BankId            string `json:"bankId"`
+	Currency          string `json:"currency"`
+	Withdrawal        *Fee   `json:"withdrawal,omitempty"`
+	InterbankTransfer *Fee   `json:"interbankTransfer,omitempty"`
+	Conversion        *Fee   `json:"conversion,omitempty"`
+}
+
+type RevenueAccount struct {
+	BankId    string `json:"bankId"`
+	Currency  string `json:"currency"`
+	Collected string `json:"collected"`
+}
+
+func NewAppliedFees(fees []model.AppliedFee, currencies utils.Currencies) []AppliedFee {
+	dtos := make([]AppliedFee, 0, len(fees))
+	for _, fee := range fees {
+		dtos = append(dtos, AppliedFee{
+			Operation: string(fee.Operation),
+			BankId:    fee.BankID,
+			Amount:    currencies.FormatAmount(fee.Amount, fee.Currency),
+			Currency:  string(fee.Currency),
+		})
+	}
+	return dtos
+}
+
+func NewFee(fee *model.Fee, currency model.Currency, currencies utils.Currencies) *Fee {
+	if fee == nil {
+		return nil
+	}
+	dto := &Fee{Type: string(fee.Type), Rate: fee.Rate}
+	if fee.Type == model.FeeFlat {
+		dto.Amount = currencies.FormatAmount(fee.Amount, currency)
+	}
+	return dto
+}
+
+func NewFeeSchedules(schedules []model.FeeSchedule, currencies utils.Currencies) []FeeSchedule {
+	dtos := make([]FeeSchedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		dtos = append(dtos, FeeSchedule{
+			BankId:            schedule.BankID,
+			Currency:          string(schedule.Currency),
+			Withdrawal:        NewFee(schedule.Withdrawal, schedule.Currency, currencies),
+			InterbankTransfer: NewFee(schedule.InterbankTransfer, schedule.Currency, currencies),
+			Conversion:        NewFee(schedule.Conversion, schedule.Currency, currencies),
+		})
+	}
+	return dtos
+}
+
+func NewRevenueAccounts(accounts []model.RevenueAccount, currencies utils.Currencies) []RevenueAccount {
+	dtos := make([]RevenueAccount, 0, len(accounts))
+	for _, account := range accounts {
+		dtos = append(dtos, RevenueAccount{
+			BankId:    account.BankID,
+			Currency:  string(account.Currency),
+			Collected: currencies.FormatAmount(account.Collected, account.Currency),
+		})
+	}
+	return dtos
+}
@@ -0,0 +1,81 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+	"time"
+)
+
+type HashTimeLock struct {
+	HashLock            string     `json:"hashLock"`
+	Direction           string     `json:"direction"`
+	AccountId           string     `json:"accountId"`
+	Amount              string     `json:"amount"`
+	Currency            string     `json:"currency"`
+	CounterpartyChannel string     `json:"counterpartyChannel"`
+	CounterpartyAccount string     `json:"counterpartyAccount"`
+	Status              string     `json:"status"`
+	LockedAt            time.Time  `json:"lockedAt"`
+	Timeout             time.Time  `json:"timeout"`
+	Secret              string     `json:"secret,omitempty"`
+	ClosedAt            *time.Time `json:"closedAt,omitempty"`
+	TransactionId       string     `json:"transactionId,omitempty"`
+}
+
+type ClearingAccount struct {
+	Currency string `json:"currency"`
+	Position string `json:"position"`
+}
+
+// CrossChannelTransfer is a transfer between channels, Outgoing is missing
+// while the source side is not claimed yet.
+type CrossChannelTransfer struct {
+	HashLock string       `json:"hashLock"`
+	Outgoing *Transaction `json:"outgoing,omitempty"`
+	Incoming *Transaction `json:"incoming,omitempty"`
+}
+
+func NewHashTimeLock(lock model.HashTimeLock, currencies utils.Currencies) HashTimeLock {
+	dto := HashTimeLock{
+		HashLock:            lock.HashLock,
+		Direction:           string(lock.Direction),
+		AccountId:           lock.AccountID,
+		Amount:              currencies.FormatAmount(lock.Amount, lock.Currency),
+		Currency:            string(lock.Currency),
+		CounterpartyChannel: lock.CounterpartyChannel,
+		CounterpartyAccount: lock.CounterpartyAccount,
+		Status:              string(lock.Status),
+		LockedAt:            lock.LockedAt,
+		Timeout:             lock.Timeout,
+		Secret:              lock.Secret,
+		TransactionId:       lock.TransactionID,
+	}
+	if !lock.ClosedAt.IsZero() {
+		dto.ClosedAt = &lock.ClosedAt
+	}
+	return dto
+}
+
+func NewClearingAccounts(accounts []model.ClearingAccount, currencies utils.Currencies) []ClearingAccount {
+	dtos := make([]ClearingAccount, 0, len(accounts))
+	for _, account := range accounts {
+		dtos = append(dtos, ClearingAccount{
+			Currency: string(account.Currency),
+			Position: currencies.FormatAmount(account.Position, account.Currency),
+		})
+	}
+	return dtos
+}
+
+func NewCrossChannelTransfer(hashLock string, outgoing, incoming *model.Transaction, outgoingCurrencies, incomingCurrencies utils.Currencies) CrossChannelTransfer {
+	dto := CrossChannelTransfer{HashLock: hashLock}
+	if outgoing != nil {
+		transaction := NewTransaction(*outgoing, outgoingCurrencies)
+		dto.Outgoing = &transaction
+	}
+	if incoming != nil {
+		transaction := NewTransaction(*incoming, incomingCurrencies)
+		dto.Incoming = &transaction
+	}
+	return dto
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+	"time"
+)
+
+type Hold struct {
+	Id            string     `json:"id"`
+	AccountId     string     `json:"accountId"`
+	PayeeAccount  string     `json:"payeeAccount"`
+	Amount        string     `json:"amount"`
+	Currency      string     `json:"currency"`
+	Status        string     `json:"status"`
+	PlacedAt      time.Time  `json:"placedAt"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	ClosedAt      *time.Time `json:"closedAt,omitempty"`
+	Captured      string     `json:"captured,omitempty"`
+	TransactionId string     `json:"transactionId,omitempty"`
+}
+
+func NewHold(hold model.Hold, currencies utils.Currencies) Hold {
+	dto := Hold{
+		Id:            hold.ID,
+		AccountId:     hold.AccountID,
+		PayeeAccount:  hold.PayeeAccount,
+		Amount:        currencies.FormatAmount(hold.Amount, hold.Currency),
+		Currency:      string(hold.Currency),
+		Status:        string(hold.Status),
+		PlacedAt:      hold.PlacedAt,
+		ExpiresAt:     hold.ExpiresAt,
+		TransactionId: hold.TransactionID,
+	}
+	if !hold.ClosedAt.IsZero() {
+		dto.ClosedAt = &hold.ClosedAt
+	}
+	if hold.Status == model.HoldCaptured {
+		dto.Captured = currencies.FormatAmount(hold.Captured, hold.Currency)
+	}
+	return dto
+}
+
+func NewHolds(holds []model.Hold, currencies utils.Currencies) []Hold {
+	dtos := make([]Hold, 0, len(holds))
+	for _, hold := range holds {
+		dtos = append(dtos, NewHold(hold, currencies))
+	}
+	return dtos
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "app/model"
+
+type InterestRate struct {
+	BankId  string `json:"bankId"`
+	Product string `json:"product"`
+	Rate    string `json:"rate"`
+}
+
+func NewInterestRates(rates []model.InterestRate) []InterestRate {
+	dtos := make([]InterestRate, 0, len(rates))
+	for _, rate := range rates {
+		dtos = append(dtos, InterestRate{
+			BankId:  rate.BankID,
+			Product: string(rate.Product),
+			Rate:    rate.Rate,
+		})
+	}
+	return dtos
+}
@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+)
+
+// Limits that do not restrict anything are left out
+type SpendingLimits struct {
+	PerTransaction  string `json:"perTransaction,omitempty"`
+	DailyWithdrawal string `json:"dailyWithdrawal,omitempty"`
+	DailyTransfer   string `json:"dailyTransfer,omitempty"`
+}
+
+type AccountLimits struct {
+	AccountId string `json:"accountId"`
+	Currency  string `json:"currency"`
+	Source    string `json:"source"`
+	SpendingLimits
+	Date        string `json:"date"`
+	Withdrawn   string `json:"withdrawnToday"`
+	Transferred string `json:"transferredToday"`
+}
+
+type BankLimits struct {
+	BankId   string `json:"bankId"`
+	Currency string `json:"currency"`
+	SpendingLimits
+}
+
+func NewSpendingLimits(limits model.SpendingLimits, currency model.Currency, currencies utils.Currencies) SpendingLimits {
+	var dto SpendingLimits
+	if limits.PerTransaction > 0 {
+		dto.PerTransaction = currencies.FormatAmount(limits.PerTransaction, currency)
+	}
+	if limits.DailyWithdrawal > 0 {
+		dto.DailyWithdrawal = currencies.FormatAmount(limits.DailyWithdrawal, currency)
+	}
+	if limits.DailyTransfer > 0 {
+		dto.DailyTransfer = currencies.FormatAmount(limits.DailyTransfer, currency)
+	}
+	return dto
+}
+
+func NewAccountLimits(limits model.AccountLimits, currencies utils.Currencies) AccountLimits {
+	return AccountLimits{
+		AccountId:      limits.AccountID,
+		Currency:       string(limits.Currency),
+		Source:         string(limits.Source),
+		SpendingLimits: NewSpendingLimits(limits.SpendingLimits, limits.Currency, currencies),
+		Date:           limits.Spending.Date,
+		Withdrawn:      currencies.FormatAmount(limits.Spending.Withdrawn, limits.Currency),
+		Transferred:    currencies.FormatAmount(limits.Spending.Transferred, limits.Currency),
+	}
+}
+
+func NewBankLimits(limits []model.BankLimits, currencies utils.Currencies) []BankLimits {
+	dtos := make([]BankLimits, 0, len(limits))
+	for _, bankLimits := range limits {
+		dtos = append(dtos, BankLimits{
+			BankId:         bankLimits.BankID,
+			Currency:       string(bankLimits.Currency),
+			SpendingLimits: NewSpendingLimits(bankLimits.SpendingLimits, bankLimits.Currency, currencies),
+		})
+	}
+	return dtos
+}
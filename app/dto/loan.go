@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+	"time"
+)
+
+type Loan struct {
+	Id           string            `json:"id"`
+	UserId       string            `json:"userId"`
+	AccountId    string            `json:"accountId"`
+	BankId       string            `json:"bankId"`
+	Principal    string            `json:"principal"`
+	Currency     string            `json:"currency"`
+	TermMonths   int               `json:"termMonths"`
+	Status       string            `json:"status"`
+	AppliedAt    time.Time         `json:"appliedAt"`
+	Rate         string            `json:"rate,omitempty"`
+	ApprovedAt   *time.Time        `json:"approvedAt,omitempty"`
+	DisbursedAt  *time.Time        `json:"disbursedAt,omitempty"`
+	NextDueDate  *time.Time        `json:"nextDueDate,omitempty"`
+	Repaid       string            `json:"repaid"`
+	Outstanding  string            `json:"outstanding"`
+	Installments []LoanInstallment `json:"installments,omitempty"`
+}
+
+type LoanFundingAccount struct {
+	BankId   string `json:"bankId"`
+	Currency string `json:"currency"`
+	Position string `json:"position"`
+}
+
+type LoanInstallment struct {
+	Number    int       `json:"number"`
+	DueDate   time.Time `json:"dueDate"`
+	Principal string    `json:"principal"`
+	Interest  string    `json:"interest"`
+	Amount    string    `json:"amount"`
+	Paid      string    `json:"paid"`
+}
+
+func NewLoan(loan model.Loan, currencies utils.Currencies) Loan {
+	dto := Loan{
+		Id:          loan.ID,
+		UserId:      loan.UserID,
+		AccountId:   loan.AccountID,
+		BankId:      loan.BankID,
+		Principal:   currencies.FormatAmount(loan.Principal, loan.Currency),
+		Currency:    string(loan.Currency),
+		TermMonths:  loan.TermMonths,
+		Status:      string(loan.Status),
+		AppliedAt:   loan.AppliedAt,
+		Rate:        loan.Rate,
+		Repaid:      currencies.FormatAmount(loan.Repaid, loan.Currency),
+		Outstanding: currencies.FormatAmount(loan.Outstanding, loan.Currency),
+	}
+	if !loan.ApprovedAt.IsZero() {
+		dto.ApprovedAt = &loan.ApprovedAt
+	}
+	if !loan.DisbursedAt.IsZero() {
+		dto.DisbursedAt = &loan.DisbursedAt
+	}
+	if !loan.NextDueDate.IsZero() {
+		dto.NextDueDate = &loan.NextDueDate
+	}
+	for _, installment := range loan.Installments {
+		dto.Installments = append(dto.Installments, LoanInstallment{
+			Number:    installment.Number,
+			DueDate:   installment.DueDate,
+			Principal: currencies.FormatAmount(installment.Principal, loan.Currency),
+			Interest:  currencies.FormatAmount(installment.Interest, loan.Currency),
+			Amount:    currencies.FormatAmount(installment.Amount, loan.Currency),
+			Paid:      currencies.FormatAmount(installment.Paid, loan.Currency),
+		})
+	}
+	return dto
+}
+
+func NewLoans(loans []model.Loan, currencies utils.Currencies) []Loan {
+	dtos := make([]Loan, 0, len(loans))
+	for _, loan := range loans {
+		dtos = append(dtos, NewLoan(loan, currencies))
+	}
+	return dtos
+}
+
+func NewLoanFundingAccounts(accounts []model.LoanFundingAccount, currencies utils.Currencies) []LoanFundingAccount {
+	dtos := make([]LoanFundingAccount, 0, len(accounts))
+	for _, account := range accounts {
+		dtos = append(dtos, LoanFundingAccount{
+			BankId:   account.BankID,
+			Currency: string(account.Currency),
+			Position: currencies.FormatAmount(account.Position, account.Currency),
+		})
+	}
+	return dtos
+}
@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+	"time"
+)
+
+type TransferQuote struct {
+	Id                 string       `json:"id"`
+	SourceAccount      string       `json:"sourceAccount"`
+	DestinationAccount string       `json:"destinationAccount"`
+	Amount             string       `json:"amount"`
+	Currency           string       `json:"currency"`
+	ConvertedAmount    string       `json:"convertedAmount"`
+	ConvertedCurrency  string       `json:"convertedCurrency"`
+	Rate               string       `json:"rate"`
+	Fee                string       `json:"fee"`
+	Fees               []AppliedFee `json:"fees"`
+	ExpiresAt          time.Time    `json:"expiresAt"`
+	Status             string       `json:"status"`
+	TransactionId      string       `json:"transactionId,omitempty"`
+}
+
+func NewTransferQuote(quote model.TransferQuote, currencies utils.Currencies) TransferQuote {
+	return TransferQuote{
+		Id:                 quote.ID,
+		SourceAccount:      quote.SourceAccount,
+		DestinationAccount: quote.DestinationAccount,
+		Amount:             currencies.FormatAmount(quote.Amount, quote.Currency),
+		Currency:           string(quote.Currency),
+		ConvertedAmount:    currencies.FormatAmount(quote.ConvertedAmount, quote.ConvertedCurrency),
+		ConvertedCurrency:  string(quote.ConvertedCurrency),
+		Rate:               quote.Rate,
+		Fee:                currencies.FormatAmount(quote.Fee, quote.Currency),
+		Fees:               NewAppliedFees(quote.Fees, currencies),
+		ExpiresAt:          quote.ExpiresAt,
+		Status:             string(quote.Status),
+		TransactionId:      quote.TransactionID,
+	}
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+	"time"
+)
+
+type StandingOrder struct {
+	Id                 string                       `json:"id"`
+	SourceAccount      string                       `json:"sourceAccount"`
+	DestinationAccount string                       `json:"destinationAccount"`
+	Amount             string                       `json:"amount"`
+	Currency           string                       `json:"currency"`
+	Schedule           string                       `json:"schedule"`
+	NextRun            time.Time                    `json:"nextRun"`
+	EndDate            *time.Time                   `json:"endDate,omitempty"`
+	Status             string                       `json:"status"`
+	LastTransactionId  string                       `json:"lastTransactionId,omitempty"`
+	Failures           []model.StandingOrderFailure `json:"failures,omitempty"`
+	FailedRuns         int                          `json:"failedRuns,omitempty"`
+}
+
+func NewStandingOrder(order model.StandingOrder, currencies utils.Currencies) StandingOrder {
+	dto := StandingOrder{
+		Id:                 order.ID,
+		SourceAccount:      order.SourceAccount,
+		DestinationAccount: order.DestinationAccount,
+		Amount:             currencies.FormatAmount(order.Amount, order.Currency),
+		Currency:           string(order.Currency),
+		Schedule:           string(order.Schedule),
+		NextRun:            order.NextRun,
+		Status:             string(order.Status),
+		LastTransactionId:  order.LastTransactionID,
+		Failures:           order.Failures,
+		FailedRuns:         order.FailedRuns,
+	}
+	if !order.EndDate.IsZero() {
+		dto.EndDate = &order.EndDate
+	}
+	return dto
+}
+
+func NewStandingOrders(orders []model.StandingOrder, currencies utils.Currencies) []StandingOrder {
+	dtos := make([]StandingOrder, 0, len(orders))
+	for _, order := range orders {
+		dtos = append(dtos, NewStandingOrder(order, currencies))
+	}
+	return dtos
+}
@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"app/model"
+	"app/utils"
+	"time"
+)
+
+type Transaction struct {
+	Id                 string                `json:"id"`
+	Type               model.TransactionType `json:"type"`
+	SourceAccount      string                `json:"sourceAccount,omitempty"`
+	DestinationAccount string                `json:"destinationAccount,omitempty"`
+	Amount             string                `json:"amount"`
+	Currency           string                `json:"currency"`
+	ConvertedAmount    string                `json:"convertedAmount"`
+	ConvertedCurrency  string                `json:"convertedCurrency"`
+	Rate               string                `json:"rate"`
+	RateIds            []string              `json:"rateIds,omitempty"`
+	Fees               []AppliedFee          `json:"fees"`
+	Loan               string                `json:"loan,omitempty"`
+	Hold               string                `json:"hold,omitempty"`
+	Lock               string                `json:"lock,omitempty"`
+	Timestamp          time.Time             `json:"timestamp"`
+	Initiator          string                `json:"initiator"`
+}
+
+func NewTransaction(transaction model.Transaction, currencies utils.Currencies) Transaction {
+	return Transaction{
+		Id:                 transaction.ID,
+		Type:               transaction.Type,
+		SourceAccount:      transaction.SourceAccount,
+		DestinationAccount: transaction.DestinationAccount,
+		Amount:             currencies.FormatAmount(transaction.Amount, transaction.Currency),
+		Currency:           string(transaction.Currency),
+		ConvertedAmount:    currencies.FormatAmount(transaction.ConvertedAmount, transaction.ConvertedCurrency),
+		ConvertedCurrency:  string(transaction.ConvertedCurrency),
+		Rate:               transaction.Rate,
+		RateIds:            transaction.RateIDs,
+		Fees:               NewAppliedFees(transaction.Fees, currencies),
+		Loan:               transaction.Loan,
+		Hold:               transaction.Hold,
+		Lock:               transaction.Lock,
+		Timestamp:          transaction.Timestamp,
+		Initiator:          transaction.Initiator,
+	}
+}
+
+func NewTransactions(transactions []model.Transaction, currencies utils.Currencies) []Transaction {
+	dtos := make([]Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		dtos = append(dtos, NewTransaction(transaction, currencies))
+	}
+	return dtos
+}
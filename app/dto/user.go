@@ -1,8 +1,42 @@
 package dto
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
 type User struct {
 	Id      string `json:"id"`
 	Name    string `json:"name"`
 	Surname string `json:"surname"`
 	Email   string `json:"email"`
 }
+
+// TransientUser is the personal data of a user as AddUser expects it in the
+// transient map. The random salt keeps the public hash from being guessed.
+type TransientUser struct {
+	Name    string `json:"name"`
+	Surname string `json:"surname"`
+	Email   string `json:"email"`
+	Salt    string `json:"salt"`
+}
+
+func NewTransientUser(user User) (map[string][]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	userJSON, err := json.Marshal(TransientUser{
+		Name:    user.Name,
+		Surname: user.Surname,
+		Email:   user.Email,
+		Salt:    hex.EncodeToString(salt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"user": userJSON}, nil
+}
@@ -0,0 +1,160 @@
+package events
+
+import (
+	"app/model"
+	"app/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Event is a chaincode event with its payload decoded into the model type of
+// its name, e.g. *model.TransferCompletedEvent for TransferCompleted.
+type Event struct {
+	Channel     string
+	Name        string
+	TxID        string
+	BlockNumber uint64
+	Payload     interface{}
+}
+
+type Handler func(Event)
+
+// Listener subscribes to the chaincode events of every channel through the
+// event service, with the identity of a user, and hands every event to the
+// subscribed handlers. A subscription that fails is registered again after
+// RetryInterval, events committed in between are not replayed.
+type Listener struct {
+	User          model.UserInfo
+	ChainCodes    map[string]string
+	RetryInterval time.Duration
+
+	mu       sync.Mutex
+	handlers []Handler
+}
+
+// Subscribe adds a handler for the events of all channels. Handlers are
+// called one event at a time per channel and should return quickly.
+func (l *Listener) Subscribe(handler Handler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handlers = append(l.handlers, handler)
+}
+
+// Run listens on every channel until the context is done.
+func (l *Listener) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for channel := range l.ChainCodes {
+		wg.Add(1)
+		go func(channel string) {
+			defer wg.Done()
+			for {
+				err := l.listen(ctx, channel)
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Event subscription on %s failed: %v", channel, err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(l.RetryInterval):
+				}
+			}
+		}(channel)
+	}
+	wg.Wait()
+}
+
+func (l *Listener) listen(ctx context.Context, channel string) error {
+	wallet, err := utils.CreateWallet(l.User.UserId, l.User.Organization, l.User.Admin)
+	if err != nil {
+		return fmt.Errorf("failed to create or populate wallet: %v", err)
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, l.User.Organization, l.User.UserId)
+	if err != nil {
+		return fmt.Errorf("failed to connect to gateway: %v", err)
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		return fmt.Errorf("failed to get network: %v", err)
+	}
+
+	contract := network.GetContract(l.ChainCodes[channel])
+	registration, notifications, err := contract.RegisterEvent(".*")
+	if err != nil {
+		return fmt.Errorf("failed to register for events: %v", err)
+	}
+	defer contract.Unregister(registration)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification, ok := <-notifications:
+			if !ok {
+				return fmt.Errorf("event stream closed")
+			}
+
+			payload, err := DecodePayload(notification.EventName, notification.Payload)
+			if err != nil {
+				log.Printf("Skipping event of transaction %s on %s: %v", notification.TxID, channel, err)
+				continue
+			}
+			l.dispatch(Event{
+				Channel:     channel,
+				Name:        notification.EventName,
+				TxID:        notification.TxID,
+				BlockNumber: notification.BlockNumber,
+				Payload:     payload,
+			})
+		}
+	}
+}
+
+func (l *Listener) dispatch(event Event) {
+	l.mu.Lock()
+	handlers := append([]Handler(nil), l.handlers...)
+	l.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// DecodePayload unmarshals the payload of a chaincode event into the model
+// type of its name.
+func DecodePayload(name string, payload []byte) (interface{}, error) {
+	var decoded interface{}
+	switch name {
+	case model.EventTransferCompleted:
+		decoded = &model.TransferCompletedEvent{}
+	case model.EventDepositCompleted, model.EventWithdrawalCompleted,
+		model.EventCrossChannelSent, model.EventCrossChannelReceived, model.EventLockRefunded,
+		model.EventLoanDisbursed, model.EventLoanRepaid, model.EventOverdraftInterestCharged:
+		decoded = &model.AccountMovementEvent{}
+	case model.EventInterestCredited:
+		decoded = &model.InterestCreditedEvent{}
+	case model.EventAccountCreated:
+		decoded = &model.AccountCreatedEvent{}
+	case model.EventUserAdded:
+		decoded = &model.UserAddedEvent{}
+	case model.EventAccountStatusChanged:
+		decoded = &model.AccountStatusChangedEvent{}
+	case model.EventStandingOrdersRun:
+		decoded = &model.StandingOrdersRunEvent{}
+	default:
+		return nil, fmt.Errorf("unknown event %s", name)
+	}
+
+	if err := json.Unmarshal(payload, decoded); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s event: %v", name, err)
+	}
+	return decoded, nil
+}
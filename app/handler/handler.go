@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"app/coordinator"
 	"app/dto"
 	jwtUtil "app/jwt"
 	"app/model"
@@ -9,14 +10,17 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
 )
 
 type Handler struct {
-	Users      map[string]model.UserInfo
-	ChainCodes map[string]string
+	Users       map[string]model.UserInfo
+	ChainCodes  map[string]string
+	Coordinator *coordinator.Coordinator
 }
 
 type Currency int
@@ -67,13 +71,13 @@ func (h *Handler) InitLedger(ctx *gin.Context) {
 
 	chaincodeId := h.ChainCodes[channel]
 
-	wallet, err := utils.CreateWallet(userId, userInfo.Organization)
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
 	if err != nil {
 		ctx.JSON(500, gin.H{"error": "Failed to create or populate wallet"})
 		return
 	}
 
-	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization)
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
 	if err != nil {
 		ctx.JSON(500, gin.H{"error": "Failed to connect to gateway"})
 		return
@@ -121,13 +125,13 @@ func (h *Handler) AddUser(ctx *gin.Context) {
 	}
 	chaincodeId := h.ChainCodes[channel]
 
-	wallet, err := utils.CreateWallet(adminId, adminUserInfo.Organization)
+	wallet, err := utils.CreateWallet(adminId, adminUserInfo.Organization, adminUserInfo.Admin)
 	if err != nil {
 		ctx.JSON(500, gin.H{"error": "Failed to create or populate wallet"})
 		return
 	}
 
-	gw, err := utils.ConnectToGateway(wallet, adminUserInfo.Organization)
+	gw, err := utils.ConnectToGateway(wallet, adminUserInfo.Organization, adminUserInfo.UserId)
 	if err != nil {
 		ctx.JSON(500, gin.H{"error": "Failed to connect to gateway"})
 		return
@@ -140,9 +144,21 @@ func (h *Handler) AddUser(ctx *gin.Context) {
 		return
 	}
 
+	transient, err := dto.NewTransientUser(user)
+	if err != nil {
+		ctx.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
 	contract := network.GetContract(chaincodeId)
+	transaction, err := contract.CreateTransaction("AddUser", gateway.WithTransient(transient))
+	if err != nil {
+		ctx.JSON(500, gin.H{"error": "Failed to create transaction"})
+		return
+	}
+
 	log.Println("Submit Transaction: AddUser")
-	_, err = contract.SubmitTransaction("AddUser", user.Id, user.Name, user.Surname, user.Email)
+	_, err = transaction.Submit(user.Id)
 	if err != nil {
 		ctx.JSON(400, gin.H{"error": "user already exists"})
 		return
@@ -166,6 +182,7 @@ func (h *Handler) CreateBankAccount(ctx *gin.Context) {
 		Cards    []string `json:"cards"`
 		BankId   string   `json:"bankId"`
 		UserID   string   `json:"userID"`
+		Product  string   `json:"product"`
 	}
 
 	if err := ctx.ShouldBindJSON(&bankAccount); err != nil {
@@ -180,16 +197,29 @@ func (h *Handler) CreateBankAccount(ctx *gin.Context) {
 	}
 	chaincodeId := h.ChainCodes[channel]
 
-	userId := bankAccount.UserID
+	// Accounts are opened by their owner, signed with the identity of the logged in user
+	userIdEntry, ok := ctx.Get("userId")
+	if !ok {
+		ctx.JSON(400, gin.H{"error": "no auth parameters provided"})
+		return
+	}
+	userId := userIdEntry.(string)
+	if bankAccount.UserID == "" {
+		bankAccount.UserID = userId
+	}
+	if bankAccount.UserID != userId {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "accounts can only be opened for the logged in user"})
+		return
+	}
 	userInfo := h.Users[userId]
 
-	wallet, err := utils.CreateWallet(userId, userInfo.Organization)
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
 		return
 	}
 
-	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization)
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
 		return
@@ -202,23 +232,50 @@ func (h *Handler) CreateBankAccount(ctx *gin.Context) {
 		return
 	}
 
+	// Card numbers are drawn here and only shown to the owner in the response
+	var cards []string
+	var cardNumbers []string
+	for _, card := range bankAccount.Cards {
+		if strings.TrimSpace(card) == "" {
+			continue
+		}
+		number, err := utils.GenerateCardNumber(card)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		cards = append(cards, card)
+		cardNumbers = append(cardNumbers, number)
+	}
+	transient, err := dto.NewTransientCardNumbers(cardNumbers)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	contract := network.GetContract(chaincodeId)
+	transaction, err := contract.CreateTransaction("CreateBankAccount", gateway.WithTransient(transient))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		return
+	}
+
 	log.Println("Submit Transaction: CreateBankAccount")
-	_, err = contract.SubmitTransaction("CreateBankAccount", bankAccount.Id, bankAccount.Currency, strings.Join(bankAccount.Cards, ","), bankAccount.BankId, bankAccount.UserID)
+	_, err = transaction.Submit(bankAccount.Id, bankAccount.Currency, strings.Join(cards, ","), bankAccount.BankId, bankAccount.UserID, bankAccount.Product)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "bank account created"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "bank account created", "cardNumbers": cardNumbers})
 }
 
+// TransferMoney quotes the transfer, it is made once the quote is confirmed.
 func (h *Handler) TransferMoney(ctx *gin.Context) {
 	var transfer struct {
-		SrcAccount      string `json:"srcAccount"`
-		DstAccount      string `json:"dstAccount"`
-		AmountStr       string `json:"amountStr"`
-		ConfirmationStr string `json:"confirmationStr"`
+		SrcAccount string `json:"srcAccount"`
+		DstAccount string `json:"dstAccount"`
+		AmountStr  string `json:"amountStr"`
 	}
 
 	if err := ctx.ShouldBindJSON(&transfer); err != nil {
@@ -238,13 +295,13 @@ func (h *Handler) TransferMoney(ctx *gin.Context) {
 
 	userInfo := h.Users[userId]
 
-	wallet, err := utils.CreateWallet(userId, userInfo.Organization)
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
 		return
 	}
 
-	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization)
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
 		return
@@ -258,24 +315,85 @@ func (h *Handler) TransferMoney(ctx *gin.Context) {
 	}
 
 	contract := network.GetContract(chaincodeID)
-	log.Println("Submit Transaction: TransferMoney")
-	response, err := contract.SubmitTransaction("TransferMoney", transfer.SrcAccount, transfer.DstAccount, transfer.AmountStr, transfer.ConfirmationStr)
+
+	log.Println("Submit Transaction: QuoteTransfer")
+	response, err := contract.SubmitTransaction("QuoteTransfer", transfer.SrcAccount, transfer.DstAccount, transfer.AmountStr)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	responseStr := string(response)
-	var resultMsg string
-	if responseStr == "true" {
-		resultMsg = "Successful money transfer"
-	} else {
-		resultMsg = "Different currencies, you have to confirm conversion"
+	var quote model.TransferQuote
+	if err := json.Unmarshal(response, &quote); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-	log.Println(resultMsg)
 
-	ctx.JSON(http.StatusOK, gin.H{"message": resultMsg})
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Confirm the transfer before the quote expires", "quote": dto.NewTransferQuote(quote, currencies)})
+}
+
+func (h *Handler) ConfirmTransfer(ctx *gin.Context) {
+	quoteId := ctx.Param("quote-id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
 
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: ExecuteQuote")
+	response, err := contract.SubmitTransaction("ExecuteQuote", quoteId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var transaction model.Transaction
+	if err := json.Unmarshal(response, &transaction); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Successful money transfer", "transaction": dto.NewTransaction(transaction, currencies)})
 }
 
 func (h *Handler) Query(ctx *gin.Context) {
@@ -304,13 +422,13 @@ func (h *Handler) Query(ctx *gin.Context) {
 	userID := userIDEntry.(string)
 
 	userInfo := h.Users[userID]
-	wallet, err := utils.CreateWallet(userID, userInfo.Organization)
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
 		return
 	}
 
-	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization)
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
 		return
@@ -325,6 +443,39 @@ func (h *Handler) Query(ctx *gin.Context) {
 
 	contract := network.GetContract(chaincodeID)
 
+	if pageSize := ctx.Query("pageSize"); pageSize != "" {
+		if size, err := strconv.Atoi(pageSize); err != nil || size <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "pageSize must be a positive number"})
+			return
+		}
+
+		var function string
+		switch by {
+		case "name":
+			function = "GetUsersByNameWithPagination"
+		case "surname":
+			function = "GetUsersBySurnameWithPagination"
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "only searches by name and surname are paginated"})
+			return
+		}
+
+		result, err := contract.EvaluateTransaction(function, param1, pageSize, ctx.Query("bookmark"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var page model.UserPIIPage
+		if err := json.Unmarshal(result, &page); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"users": page.Records, "fetchedRecordsCount": page.FetchedRecordsCount, "bookmark": page.Bookmark})
+		return
+	}
+
 	var result []byte
 	switch by {
 	case "name":
@@ -342,7 +493,7 @@ func (h *Handler) Query(ctx *gin.Context) {
 	}
 
 	if by == "account" {
-		var user model.User
+		var user model.UserPII
 		if err := json.Unmarshal(result, &user); err != nil {
 			log.Println("Error:", err)
 			return
@@ -355,7 +506,7 @@ func (h *Handler) Query(ctx *gin.Context) {
 
 		ctx.JSON(http.StatusOK, gin.H{"user": user})
 	} else {
-		var users []model.User
+		var users []model.UserPII
 		if err := json.Unmarshal(result, &users); err != nil {
 			log.Println("Error:", err)
 			return
@@ -394,13 +545,13 @@ func (h *Handler) MoneyWithdrawal(ctx *gin.Context) {
 
 	userInfo := h.Users[userId]
 
-	wallet, err := utils.CreateWallet(userId, userInfo.Organization)
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
 		return
 	}
 
-	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization)
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
 		return
@@ -416,20 +567,25 @@ func (h *Handler) MoneyWithdrawal(ctx *gin.Context) {
 	contract := network.GetContract(chaincodeID)
 
 	log.Println("Submit Transaction: MoneyWithdrawal")
-	response, err := contract.SubmitTransaction("MoneyWithdrawal", userId, transfer.BankAccountId, transfer.Amount)
+	response, err := contract.SubmitTransaction("MoneyWithdrawal", transfer.BankAccountId, transfer.Amount)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	responseStr := string(response)
-	var resultMsg string
-	if responseStr == "true" {
-		resultMsg = "Money withdrawal successful."
+	var transaction model.Transaction
+	if err := json.Unmarshal(response, &transaction); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-	log.Println(resultMsg)
 
-	ctx.JSON(http.StatusOK, gin.H{"message": resultMsg})
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Money withdrawal successful.", "transaction": dto.NewTransaction(transaction, currencies)})
 }
 
 func (h *Handler) GetAccountsByBankDesiredCurrencyAndBalance(ctx *gin.Context) {
@@ -464,13 +620,13 @@ func (h *Handler) GetAccountsByBankDesiredCurrencyAndBalance(ctx *gin.Context) {
 	userID := userIDEntry.(string)
 
 	userInfo := h.Users[userID]
-	wallet, err := utils.CreateWallet(userID, userInfo.Organization)
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
 		return
 	}
 
-	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization)
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
 		return
@@ -485,10 +641,38 @@ func (h *Handler) GetAccountsByBankDesiredCurrencyAndBalance(ctx *gin.Context) {
 
 	contract := network.GetContract(chaincodeID)
 
+	if pageSize := ctx.Query("pageSize"); pageSize != "" {
+		if size, err := strconv.Atoi(pageSize); err != nil || size <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "pageSize must be a positive number"})
+			return
+		}
+
+		result, err := contract.EvaluateTransaction("GetAccountsByBankDesiredCurrencyAndBalanceWithPagination", bankId, currency, balanceThreshold, pageSize, ctx.Query("bookmark"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var page model.BankAccountDetailsPage
+		if err := json.Unmarshal(result, &page); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		currencies, err := utils.LoadCurrencies(contract)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"accounts": dto.NewBankAccounts(page.Records, currencies), "fetchedRecordsCount": page.FetchedRecordsCount, "bookmark": page.Bookmark})
+		return
+	}
+
 	var result []byte
 	result, err = contract.EvaluateTransaction("GetAccountsByBankDesiredCurrencyAndBalance", bankId, currency, balanceThreshold)
 
-	var accounts []model.BankAccount
+	var accounts []model.BankAccountDetails
 	if err := json.Unmarshal(result, &accounts); err != nil {
 		log.Println("Error:", err)
 		return
@@ -499,7 +683,13 @@ func (h *Handler) GetAccountsByBankDesiredCurrencyAndBalance(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"accounts": accounts})
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"accounts": dto.NewBankAccounts(accounts, currencies)})
 }
 
 func (h *Handler) GetAccountByBankDesiredCurrencyAndMaxBalance(ctx *gin.Context) {
@@ -528,13 +718,13 @@ func (h *Handler) GetAccountByBankDesiredCurrencyAndMaxBalance(ctx *gin.Context)
 	userID := userIDEntry.(string)
 
 	userInfo := h.Users[userID]
-	wallet, err := utils.CreateWallet(userID, userInfo.Organization)
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
 		return
 	}
 
-	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization)
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
 		return
@@ -552,7 +742,7 @@ func (h *Handler) GetAccountByBankDesiredCurrencyAndMaxBalance(ctx *gin.Context)
 	var result []byte
 	result, err = contract.EvaluateTransaction("GetAccountByBankDesiredCurrencyAndMaxBalance", bankId, currency)
 
-	var account model.BankAccount
+	var account model.BankAccountDetails
 	if err := json.Unmarshal(result, &account); err != nil {
 		log.Println("Error:", err)
 		return
@@ -563,7 +753,13 @@ func (h *Handler) GetAccountByBankDesiredCurrencyAndMaxBalance(ctx *gin.Context)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewBankAccount(account, currencies))
 }
 
 func (h *Handler) MoneyDepositToAccount(ctx *gin.Context) {
@@ -589,13 +785,13 @@ func (h *Handler) MoneyDepositToAccount(ctx *gin.Context) {
 
 	userInfo := h.Users[userId]
 
-	wallet, err := utils.CreateWallet(userId, userInfo.Organization)
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
 		return
 	}
 
-	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization)
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
 		return
@@ -611,7 +807,7 @@ func (h *Handler) MoneyDepositToAccount(ctx *gin.Context) {
 	contract := network.GetContract(chaincodeID)
 
 	log.Println("Submit Transaction: MoneyDepositToAccount")
-	response, err := contract.SubmitTransaction("MoneyDepositToAccount", userId, transfer.BankAccountId, transfer.Amount)
+	response, err := contract.SubmitTransaction("MoneyDepositToAccount", transfer.BankAccountId, transfer.Amount)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -626,3 +822,3425 @@ func (h *Handler) MoneyDepositToAccount(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": resultMsg})
 }
+
+func (h *Handler) GetAccountHistory(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+	if accountId == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "account id is required"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+
+	chaincodeID := h.ChainCodes[channel]
+
+	userIDEntry, _ := ctx.Get("userId")
+	userID := userIDEntry.(string)
+
+	userInfo := h.Users[userID]
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	result, err := contract.EvaluateTransaction("GetAccountHistory", accountId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var history []model.AccountHistoryEntry
+	if err := json.Unmarshal(result, &history); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"history": dto.NewAccountHistory(history, currencies)})
+}
+
+func (h *Handler) GetTransactions(ctx *gin.Context) {
+	accountId := ctx.Query("account")
+	transactionType := ctx.Query("type")
+	from := ctx.Query("from")
+	to := ctx.Query("to")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+
+	chaincodeID := h.ChainCodes[channel]
+
+	userIDEntry, _ := ctx.Get("userId")
+	userID := userIDEntry.(string)
+
+	userInfo := h.Users[userID]
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	result, err := contract.EvaluateTransaction("GetTransactions", accountId, strings.ToUpper(transactionType), from, to)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var transactions []model.Transaction
+	if err := json.Unmarshal(result, &transactions); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"transactions": dto.NewTransactions(transactions, currencies)})
+}
+
+func (h *Handler) PublishExchangeRate(ctx *gin.Context) {
+	var exchangeRate struct {
+		From          string `json:"from"`
+		To            string `json:"to"`
+		Rate          string `json:"rate"`
+		EffectiveFrom string `json:"effectiveFrom"`
+	}
+
+	if err := ctx.ShouldBindJSON(&exchangeRate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: PublishExchangeRate")
+	response, err := contract.SubmitTransaction("PublishExchangeRate", strings.ToUpper(exchangeRate.From), strings.ToUpper(exchangeRate.To), exchangeRate.Rate, exchangeRate.EffectiveFrom)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var published model.ExchangeRate
+	if err := json.Unmarshal(response, &published); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewExchangeRate(published))
+}
+
+func (h *Handler) GetExchangeRates(ctx *gin.Context) {
+	from := strings.ToUpper(ctx.Query("from"))
+	to := strings.ToUpper(ctx.Query("to"))
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+
+	chaincodeID := h.ChainCodes[channel]
+
+	userIDEntry, _ := ctx.Get("userId")
+	userID := userIDEntry.(string)
+
+	userInfo := h.Users[userID]
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	result, err := contract.EvaluateTransaction("GetExchangeRates", from, to)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var exchangeRates []model.ExchangeRate
+	if err := json.Unmarshal(result, &exchangeRates); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"exchangeRates": dto.NewExchangeRates(exchangeRates)})
+}
+
+func (h *Handler) GetExchangeRate(ctx *gin.Context) {
+	from := strings.ToUpper(ctx.Param("from"))
+	to := strings.ToUpper(ctx.Param("to"))
+	at := ctx.Query("at")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+
+	chaincodeID := h.ChainCodes[channel]
+
+	userIDEntry, _ := ctx.Get("userId")
+	userID := userIDEntry.(string)
+
+	userInfo := h.Users[userID]
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	result, err := contract.EvaluateTransaction("GetExchangeRate", from, to, at)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var exchangeRate model.EffectiveExchangeRate
+	if err := json.Unmarshal(result, &exchangeRate); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewEffectiveExchangeRate(exchangeRate))
+}
+
+func (h *Handler) GetCurrencies(ctx *gin.Context) {
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+
+	chaincodeID := h.ChainCodes[channel]
+
+	userIDEntry, _ := ctx.Get("userId")
+	userID := userIDEntry.(string)
+
+	userInfo := h.Users[userID]
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	result, err := contract.EvaluateTransaction("GetCurrencies")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var currencies []model.CurrencyDefinition
+	if err := json.Unmarshal(result, &currencies); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	settingsResult, err := contract.EvaluateTransaction("GetCurrencySettings")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var settings model.CurrencySettings
+	if err := json.Unmarshal(settingsResult, &settings); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"currencies": dto.NewCurrencies(currencies), "baseCurrency": settings.BaseCurrency})
+}
+
+func (h *Handler) RegisterCurrency(ctx *gin.Context) {
+	var currency struct {
+		Code       string `json:"code"`
+		Name       string `json:"name"`
+		MinorUnits int    `json:"minorUnits"`
+		Rounding   string `json:"rounding"`
+	}
+
+	if err := ctx.ShouldBindJSON(&currency); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: RegisterCurrency")
+	response, err := contract.SubmitTransaction("RegisterCurrency", currency.Code, currency.Name, strconv.Itoa(currency.MinorUnits), currency.Rounding)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var registered model.CurrencyDefinition
+	if err := json.Unmarshal(response, &registered); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewCurrency(registered))
+}
+
+func (h *Handler) SetCurrencyEnabled(ctx *gin.Context) {
+	code := strings.ToUpper(ctx.Param("code"))
+
+	var status struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	if err := ctx.ShouldBindJSON(&status); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: SetCurrencyEnabled")
+	response, err := contract.SubmitTransaction("SetCurrencyEnabled", code, strconv.FormatBool(status.Enabled))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updated model.CurrencyDefinition
+	if err := json.Unmarshal(response, &updated); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewCurrency(updated))
+}
+
+func (h *Handler) SetBaseCurrency(ctx *gin.Context) {
+	var settings struct {
+		BaseCurrency string `json:"baseCurrency"`
+	}
+
+	if err := ctx.ShouldBindJSON(&settings); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: SetBaseCurrency")
+	_, err = contract.SubmitTransaction("SetBaseCurrency", strings.ToUpper(settings.BaseCurrency))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"baseCurrency": strings.ToUpper(settings.BaseCurrency)})
+}
+
+func (h *Handler) CreateBank(ctx *gin.Context) {
+	var bank struct {
+		Id           string `json:"id"`
+		Name         string `json:"name"`
+		Headquarters string `json:"headquarters"`
+		Since        int    `json:"since"`
+		PIB          int    `json:"pib"`
+	}
+
+	if err := ctx.ShouldBindJSON(&bank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: CreateBank")
+	response, err := contract.SubmitTransaction("CreateBank", bank.Id, bank.Name, bank.Headquarters, strconv.Itoa(bank.Since), strconv.Itoa(bank.PIB))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var created model.Bank
+	if err := json.Unmarshal(response, &created); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewBank(created))
+}
+
+func (h *Handler) UpdateBank(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+
+	var bank struct {
+		Name         string `json:"name"`
+		Headquarters string `json:"headquarters"`
+		Since        int    `json:"since"`
+	}
+
+	if err := ctx.ShouldBindJSON(&bank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: UpdateBank")
+	response, err := contract.SubmitTransaction("UpdateBank", bankId, bank.Name, bank.Headquarters, strconv.Itoa(bank.Since))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updated model.Bank
+	if err := json.Unmarshal(response, &updated); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewBank(updated))
+}
+
+func (h *Handler) ReadBank(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+	if bankId == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "bank id is required"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+
+	chaincodeID := h.ChainCodes[channel]
+
+	userIDEntry, _ := ctx.Get("userId")
+	userID := userIDEntry.(string)
+
+	userInfo := h.Users[userID]
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	result, err := contract.EvaluateTransaction("ReadBank", bankId)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var bank model.Bank
+	if err := json.Unmarshal(result, &bank); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewBank(bank))
+}
+
+func (h *Handler) ListBanks(ctx *gin.Context) {
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+
+	chaincodeID := h.ChainCodes[channel]
+
+	userIDEntry, _ := ctx.Get("userId")
+	userID := userIDEntry.(string)
+
+	userInfo := h.Users[userID]
+	wallet, err := utils.CreateWallet(userID, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	result, err := contract.EvaluateTransaction("ListBanks")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var banks []model.Bank
+	if err := json.Unmarshal(result, &banks); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"banks": dto.NewBanks(banks)})
+}
+
+func (h *Handler) FreezeAccount(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: FreezeAccount")
+	response, err := contract.SubmitTransaction("FreezeAccount", accountId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var account model.BankAccount
+	if err := json.Unmarshal(response, &account); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"id": account.ID, "status": account.Status})
+}
+
+func (h *Handler) UnfreezeAccount(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: UnfreezeAccount")
+	response, err := contract.SubmitTransaction("UnfreezeAccount", accountId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var account model.BankAccount
+	if err := json.Unmarshal(response, &account); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"id": account.ID, "status": account.Status})
+}
+
+func (h *Handler) CloseAccount(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	// Only needed when the account still holds money
+	var closure struct {
+		PayoutAccount string `json:"payoutAccount"`
+	}
+
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&closure); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+			return
+		}
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: CloseAccount")
+	response, err := contract.SubmitTransaction("CloseAccount", accountId, closure.PayoutAccount)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var account model.BankAccount
+	if err := json.Unmarshal(response, &account); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"id": account.ID, "status": account.Status})
+}
+
+func (h *Handler) IssueCard(ctx *gin.Context) {
+	var card struct {
+		AccountId string `json:"accountId"`
+		Network   string `json:"network"`
+	}
+
+	if err := ctx.ShouldBindJSON(&card); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	number, err := utils.GenerateCardNumber(card.Network)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	transient, err := dto.NewTransientCardNumbers([]string{number})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	transaction, err := contract.CreateTransaction("IssueCard", gateway.WithTransient(transient))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		return
+	}
+
+	log.Println("Submit Transaction: IssueCard")
+	response, err := transaction.Submit(card.AccountId, card.Network)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var issued model.Card
+	if err := json.Unmarshal(response, &issued); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	issuedCard := dto.NewCard(issued)
+	issuedCard.Number = number
+	ctx.JSON(http.StatusOK, issuedCard)
+}
+
+func (h *Handler) BlockCard(ctx *gin.Context) {
+	cardId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: BlockCard")
+	response, err := contract.SubmitTransaction("BlockCard", cardId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var card model.Card
+	if err := json.Unmarshal(response, &card); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewCard(card))
+}
+
+func (h *Handler) ReplaceCard(ctx *gin.Context) {
+	cardId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	// The new number has to be drawn for the network of the replaced card
+	result, err := contract.EvaluateTransaction("ReadCard", cardId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var replaced model.Card
+	if err := json.Unmarshal(result, &replaced); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	number, err := utils.GenerateCardNumber(string(replaced.Network))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	transient, err := dto.NewTransientCardNumbers([]string{number})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	transaction, err := contract.CreateTransaction("ReplaceCard", gateway.WithTransient(transient))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		return
+	}
+
+	log.Println("Submit Transaction: ReplaceCard")
+	response, err := transaction.Submit(cardId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var card model.Card
+	if err := json.Unmarshal(response, &card); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	replacement := dto.NewCard(card)
+	replacement.Number = number
+	ctx.JSON(http.StatusOK, replacement)
+}
+
+func (h *Handler) ListCards(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	result, err := contract.EvaluateTransaction("ListCards", accountId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cards []model.Card
+	if err := json.Unmarshal(result, &cards); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"cards": dto.NewCards(cards)})
+}
+
+func (h *Handler) SetOverdraftLimit(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	var overdraft struct {
+		Limit string `json:"limit"`
+		Rate  string `json:"rate"`
+	}
+
+	if err := ctx.ShouldBindJSON(&overdraft); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: SetOverdraftLimit")
+	response, err := contract.SubmitTransaction("SetOverdraftLimit", accountId, overdraft.Limit, overdraft.Rate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var account model.BankAccountDetails
+	if err := json.Unmarshal(response, &account); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewBankAccount(account, currencies))
+}
+
+func (h *Handler) ChargeOverdraftInterest(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: ChargeOverdraftInterest")
+	response, err := contract.SubmitTransaction("ChargeOverdraftInterest", accountId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var account model.BankAccountDetails
+	if err := json.Unmarshal(response, &account); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewBankAccount(account, currencies))
+}
+
+func (h *Handler) GetAccountsInOverdraft(ctx *gin.Context) {
+	bankId := ctx.Param("bank-id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	result, err := contract.EvaluateTransaction("GetAccountsInOverdraft", bankId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var accounts []model.BankAccountDetails
+	if err := json.Unmarshal(result, &accounts); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"accounts": dto.NewBankAccounts(accounts, currencies)})
+}
+
+func (h *Handler) GetAccountLimits(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("GetAccountLimits", accountId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var limits model.AccountLimits
+	if err := json.Unmarshal(response, &limits); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewAccountLimits(limits, currencies))
+}
+
+func (h *Handler) SetAccountLimits(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	var limits struct {
+		PerTransaction  string `json:"perTransaction"`
+		DailyWithdrawal string `json:"dailyWithdrawal"`
+		DailyTransfer   string `json:"dailyTransfer"`
+	}
+
+	if err := ctx.ShouldBindJSON(&limits); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: SetAccountLimits")
+	response, err := contract.SubmitTransaction("SetAccountLimits", accountId, limits.PerTransaction, limits.DailyWithdrawal, limits.DailyTransfer)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var accountLimits model.AccountLimits
+	if err := json.Unmarshal(response, &accountLimits); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewAccountLimits(accountLimits, currencies))
+}
+
+func (h *Handler) ResetAccountLimits(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: ResetAccountLimits")
+	response, err := contract.SubmitTransaction("ResetAccountLimits", accountId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var limits model.AccountLimits
+	if err := json.Unmarshal(response, &limits); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewAccountLimits(limits, currencies))
+}
+
+func (h *Handler) GetBankLimits(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("GetBankLimits", bankId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var limits []model.BankLimits
+	if err := json.Unmarshal(response, &limits); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"limits": dto.NewBankLimits(limits, currencies)})
+}
+
+func (h *Handler) SetBankLimits(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+	currency := ctx.Param("currency")
+
+	var limits struct {
+		PerTransaction  string `json:"perTransaction"`
+		DailyWithdrawal string `json:"dailyWithdrawal"`
+		DailyTransfer   string `json:"dailyTransfer"`
+	}
+
+	if err := ctx.ShouldBindJSON(&limits); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: SetBankLimits")
+	response, err := contract.SubmitTransaction("SetBankLimits", bankId, currency, limits.PerTransaction, limits.DailyWithdrawal, limits.DailyTransfer)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var bankLimits model.BankLimits
+	if err := json.Unmarshal(response, &bankLimits); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewBankLimits([]model.BankLimits{bankLimits}, currencies)[0])
+}
+
+func (h *Handler) CreateStandingOrder(ctx *gin.Context) {
+	var order struct {
+		SrcAccount string `json:"srcAccount"`
+		DstAccount string `json:"dstAccount"`
+		Amount     string `json:"amount"`
+		Schedule   string `json:"schedule"`
+		FirstRun   string `json:"firstRun"`
+		EndDate    string `json:"endDate"`
+	}
+
+	if err := ctx.ShouldBindJSON(&order); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: CreateStandingOrder")
+	response, err := contract.SubmitTransaction("CreateStandingOrder", order.SrcAccount, order.DstAccount, order.Amount, order.Schedule, order.FirstRun, order.EndDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var standingOrder model.StandingOrder
+	if err := json.Unmarshal(response, &standingOrder); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewStandingOrder(standingOrder, currencies))
+}
+
+func (h *Handler) CancelStandingOrder(ctx *gin.Context) {
+	orderId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: CancelStandingOrder")
+	response, err := contract.SubmitTransaction("CancelStandingOrder", orderId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order model.StandingOrder
+	if err := json.Unmarshal(response, &order); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewStandingOrder(order, currencies))
+}
+
+func (h *Handler) ListStandingOrders(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("ListStandingOrders", accountId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var orders []model.StandingOrder
+	if err := json.Unmarshal(response, &orders); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"standingOrders": dto.NewStandingOrders(orders, currencies)})
+}
+
+func (h *Handler) ExecuteDueStandingOrders(ctx *gin.Context) {
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: ExecuteDueStandingOrders")
+	response, err := contract.SubmitTransaction("ExecuteDueStandingOrders")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var runs []model.StandingOrderRun
+	if err := json.Unmarshal(response, &runs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+func (h *Handler) SetInterestRate(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+	product := ctx.Param("product")
+
+	var interestRate struct {
+		Rate string `json:"rate"`
+	}
+
+	if err := ctx.ShouldBindJSON(&interestRate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: SetInterestRate")
+	response, err := contract.SubmitTransaction("SetInterestRate", bankId, product, interestRate.Rate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rate model.InterestRate
+	if err := json.Unmarshal(response, &rate); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewInterestRates([]model.InterestRate{rate})[0])
+}
+
+func (h *Handler) GetInterestRates(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("GetInterestRates", bankId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rates []model.InterestRate
+	if err := json.Unmarshal(response, &rates); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewInterestRates(rates))
+}
+
+// interestBatchSize is the number of accounts paid in one AccrueInterest
+// transaction, at most what the chaincode accepts.
+const interestBatchSize = 100
+
+func (h *Handler) AccrueInterest(ctx *gin.Context) {
+	bankId := ctx.Param("bank-id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	// The accounts are paid a page at a time, every page in a transaction of its own
+	credits := []model.Transaction{}
+	bookmark := ""
+	for {
+		result, err := contract.EvaluateTransaction("GetAccountsByBankWithPagination", bankId, strconv.Itoa(interestBatchSize), bookmark)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var page model.BankAccountDetailsPage
+		if err := json.Unmarshal(result, &page); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(page.Records) == 0 {
+			break
+		}
+
+		accountIds := make([]string, 0, len(page.Records))
+		for _, account := range page.Records {
+			accountIds = append(accountIds, account.ID)
+		}
+		accountIdsJSON, err := json.Marshal(accountIds)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		log.Println("Submit Transaction: AccrueInterest")
+		response, err := contract.SubmitTransaction("AccrueInterest", bankId, string(accountIdsJSON))
+		if err != nil {
+			// Pages submitted before stay paid, running it again pays the rest
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var batch []model.Transaction
+		if err := json.Unmarshal(response, &batch); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		credits = append(credits, batch...)
+
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewTransactions(credits, currencies))
+}
+
+func (h *Handler) GetFeeSchedules(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("GetFeeSchedules", bankId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var schedules []model.FeeSchedule
+	if err := json.Unmarshal(response, &schedules); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewFeeSchedules(schedules, currencies))
+}
+
+func (h *Handler) SetFeeSchedule(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+	currency := ctx.Param("currency")
+
+	var fees struct {
+		Withdrawal        string `json:"withdrawal"`
+		InterbankTransfer string `json:"interbankTransfer"`
+		Conversion        string `json:"conversion"`
+	}
+
+	if err := ctx.ShouldBindJSON(&fees); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: SetFeeSchedule")
+	response, err := contract.SubmitTransaction("SetFeeSchedule", bankId, currency, fees.Withdrawal, fees.InterbankTransfer, fees.Conversion)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var schedule model.FeeSchedule
+	if err := json.Unmarshal(response, &schedule); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewFeeSchedules([]model.FeeSchedule{schedule}, currencies)[0])
+}
+
+func (h *Handler) GetRevenueAccounts(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("GetRevenueAccounts", bankId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var accounts []model.RevenueAccount
+	if err := json.Unmarshal(response, &accounts); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewRevenueAccounts(accounts, currencies))
+}
+
+func (h *Handler) GetLoanFundingAccounts(ctx *gin.Context) {
+	bankId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("GetLoanFundingAccounts", bankId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var accounts []model.LoanFundingAccount
+	if err := json.Unmarshal(response, &accounts); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoanFundingAccounts(accounts, currencies))
+}
+
+func (h *Handler) ApplyForLoan(ctx *gin.Context) {
+	var application struct {
+		AccountId  string `json:"accountId"`
+		Amount     string `json:"amount"`
+		TermMonths int    `json:"termMonths"`
+	}
+
+	if err := ctx.ShouldBindJSON(&application); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: ApplyForLoan")
+	response, err := contract.SubmitTransaction("ApplyForLoan", application.AccountId, application.Amount, strconv.Itoa(application.TermMonths))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loan model.Loan
+	if err := json.Unmarshal(response, &loan); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoan(loan, currencies))
+}
+
+func (h *Handler) ListLoans(ctx *gin.Context) {
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("ListLoans")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loans []model.Loan
+	if err := json.Unmarshal(response, &loans); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoans(loans, currencies))
+}
+
+func (h *Handler) ReadLoan(ctx *gin.Context) {
+	loanId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("ReadLoan", loanId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loan model.Loan
+	if err := json.Unmarshal(response, &loan); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoan(loan, currencies))
+}
+
+func (h *Handler) RepayLoan(ctx *gin.Context) {
+	loanId := ctx.Param("id")
+
+	var repayment struct {
+		Amount string `json:"amount"`
+	}
+
+	if err := ctx.ShouldBindJSON(&repayment); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: RepayLoan")
+	response, err := contract.SubmitTransaction("RepayLoan", loanId, repayment.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loan model.Loan
+	if err := json.Unmarshal(response, &loan); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoan(loan, currencies))
+}
+
+func (h *Handler) ApproveLoan(ctx *gin.Context) {
+	loanId := ctx.Param("id")
+
+	var approval struct {
+		Rate string `json:"rate"`
+	}
+
+	if err := ctx.ShouldBindJSON(&approval); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: ApproveLoan")
+	response, err := contract.SubmitTransaction("ApproveLoan", loanId, approval.Rate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loan model.Loan
+	if err := json.Unmarshal(response, &loan); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoan(loan, currencies))
+}
+
+func (h *Handler) RejectLoan(ctx *gin.Context) {
+	loanId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: RejectLoan")
+	response, err := contract.SubmitTransaction("RejectLoan", loanId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loan model.Loan
+	if err := json.Unmarshal(response, &loan); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoan(loan, currencies))
+}
+
+func (h *Handler) DisburseLoan(ctx *gin.Context) {
+	loanId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: DisburseLoan")
+	response, err := contract.SubmitTransaction("DisburseLoan", loanId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loan model.Loan
+	if err := json.Unmarshal(response, &loan); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoan(loan, currencies))
+}
+
+func (h *Handler) GetLoansByStatus(ctx *gin.Context) {
+	status := strings.ToUpper(ctx.Param("status"))
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("GetLoansByStatus", status)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loans []model.Loan
+	if err := json.Unmarshal(response, &loans); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoans(loans, currencies))
+}
+
+func (h *Handler) MarkLateLoans(ctx *gin.Context) {
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: MarkLateLoans")
+	response, err := contract.SubmitTransaction("MarkLateLoans")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loans []model.Loan
+	if err := json.Unmarshal(response, &loans); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewLoans(loans, currencies))
+}
+
+func (h *Handler) PlaceHold(ctx *gin.Context) {
+	var hold struct {
+		AccountId    string `json:"accountId"`
+		PayeeAccount string `json:"payeeAccount"`
+		Amount       string `json:"amount"`
+		ExpiresAt    string `json:"expiresAt"`
+	}
+
+	if err := ctx.ShouldBindJSON(&hold); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: PlaceHold")
+	response, err := contract.SubmitTransaction("PlaceHold", hold.AccountId, hold.PayeeAccount, hold.Amount, hold.ExpiresAt)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var placed model.Hold
+	if err := json.Unmarshal(response, &placed); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewHold(placed, currencies))
+}
+
+func (h *Handler) ListHolds(ctx *gin.Context) {
+	accountId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("ListHolds", accountId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var holds []model.Hold
+	if err := json.Unmarshal(response, &holds); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewHolds(holds, currencies))
+}
+
+func (h *Handler) CaptureHold(ctx *gin.Context) {
+	holdId := ctx.Param("id")
+
+	// Without an amount the whole hold is captured
+	var capture struct {
+		Amount string `json:"amount"`
+	}
+
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&capture); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+			return
+		}
+	}
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: CaptureHold")
+	response, err := contract.SubmitTransaction("CaptureHold", holdId, capture.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var transaction model.Transaction
+	if err := json.Unmarshal(response, &transaction); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewTransaction(transaction, currencies))
+}
+
+func (h *Handler) ReleaseHold(ctx *gin.Context) {
+	holdId := ctx.Param("id")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: ReleaseHold")
+	response, err := contract.SubmitTransaction("ReleaseHold", holdId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var hold model.Hold
+	if err := json.Unmarshal(response, &hold); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewHold(hold, currencies))
+}
+
+func (h *Handler) CrossChannelTransfer(ctx *gin.Context) {
+	var transfer struct {
+		SrcChannel string `json:"srcChannel"`
+		SrcAccount string `json:"srcAccount"`
+		DstChannel string `json:"dstChannel"`
+		DstAccount string `json:"dstAccount"`
+		Amount     string `json:"amount"`
+	}
+
+	if err := ctx.ShouldBindJSON(&transfer); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	log.Println("Submit Transaction: CrossChannelTransfer")
+	result, transferErr := h.Coordinator.Transfer(userInfo, coordinator.Transfer{
+		SrcChannel: transfer.SrcChannel,
+		SrcAccount: transfer.SrcAccount,
+		DstChannel: transfer.DstChannel,
+		DstAccount: transfer.DstAccount,
+		Amount:     transfer.Amount,
+	})
+	if result == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": transferErr.Error()})
+		return
+	}
+
+	srcCurrencies, err := h.Coordinator.Currencies(userInfo, transfer.SrcChannel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	dstCurrencies, err := h.Coordinator.Currencies(userInfo, transfer.DstChannel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := dto.NewCrossChannelTransfer(result.HashLock, result.Outgoing, result.Incoming, srcCurrencies, dstCurrencies)
+	if transferErr != nil {
+		// The money arrived, the source side is claimed by completing the transfer
+		ctx.JSON(http.StatusAccepted, gin.H{"error": transferErr.Error(), "transfer": response})
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CompleteCrossChannelTransfer(ctx *gin.Context) {
+	hashLock := ctx.Param("hash-lock")
+
+	var transfer struct {
+		SrcChannel string `json:"srcChannel"`
+		DstChannel string `json:"dstChannel"`
+	}
+
+	if err := ctx.ShouldBindJSON(&transfer); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "couldn't resolve body"})
+		return
+	}
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	log.Println("Submit Transaction: CompleteCrossChannelTransfer")
+	outgoing, err := h.Coordinator.Complete(userInfo, transfer.SrcChannel, transfer.DstChannel, hashLock)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := h.Coordinator.Currencies(userInfo, transfer.SrcChannel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewCrossChannelTransfer(hashLock, outgoing, nil, currencies, nil))
+}
+
+func (h *Handler) ReadHashTimeLock(ctx *gin.Context) {
+	hashLock := ctx.Param("hash-lock")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("ReadHashTimeLock", hashLock)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var lock model.HashTimeLock
+	if err := json.Unmarshal(response, &lock); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewHashTimeLock(lock, currencies))
+}
+
+func (h *Handler) RefundLock(ctx *gin.Context) {
+	hashLock := ctx.Param("hash-lock")
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	log.Println("Submit Transaction: RefundLock")
+	response, err := contract.SubmitTransaction("RefundLock", hashLock)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var lock model.HashTimeLock
+	if err := json.Unmarshal(response, &lock); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewHashTimeLock(lock, currencies))
+}
+
+func (h *Handler) GetClearingAccounts(ctx *gin.Context) {
+
+	channel := ctx.Param("channel")
+	if channel == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel is required"})
+		return
+	}
+	chaincodeID := h.ChainCodes[channel]
+
+	userIdContext, _ := ctx.Get("userId")
+	userId := fmt.Sprintf("%v", userIdContext)
+
+	userInfo := h.Users[userId]
+
+	wallet, err := utils.CreateWallet(userId, userInfo.Organization, userInfo.Admin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or populate wallet"})
+		return
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, userInfo.Organization, userInfo.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to gateway"})
+		return
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get network"})
+		return
+	}
+
+	contract := network.GetContract(chaincodeID)
+
+	response, err := contract.EvaluateTransaction("GetClearingAccounts")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var accounts []model.ClearingAccount
+	if err := json.Unmarshal(response, &accounts); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := utils.LoadCurrencies(contract)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewClearingAccounts(accounts, currencies))
+}
@@ -31,7 +31,8 @@ func AuthenticationMiddleware() gin.HandlerFunc {
 	}
 }
 
-func AuthorizationMiddleware(requiredRole string) gin.HandlerFunc {
+// AuthorizationMiddleware lets through callers with one of the given roles.
+func AuthorizationMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		providedRoleEntry, ok := ctx.Get("role")
 		if !ok {
@@ -40,12 +41,14 @@ func AuthorizationMiddleware(requiredRole string) gin.HandlerFunc {
 		}
 		providedRole := providedRoleEntry.(string)
 
-		if providedRole != requiredRole {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "you are not authorized for this action"})
-			ctx.Abort()
-			return
+		for _, allowedRole := range allowedRoles {
+			if providedRole == allowedRole {
+				ctx.Next()
+				return
+			}
 		}
 
-		ctx.Next()
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "you are not authorized for this action"})
+		ctx.Abort()
 	}
 }
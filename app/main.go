@@ -29,11 +29,13 @@ func main() {
 		ReadHeaderTimeout: 100 * time.Millisecond,
 		MaxHeaderBytes:    2048,
 	}
-	app.Config.Host = app.Config.Host
-	app.Config.Port = app.Config.Port
-
 	log.Printf("server started at %s:%s", app.Config.Host, app.Config.Port)
 
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go app.Scheduler.Run(backgroundCtx)
+	go app.Events.Run(backgroundCtx)
+
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("listen: %s\n", err)
@@ -41,13 +43,14 @@ func main() {
 	}()
 
 	//GRACEFUL SHUTDOWN
-	quitChan := make(chan os.Signal)
+	quitChan := make(chan os.Signal, 1)
 	// kill (no param) default send syscanll.SIGTERM
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall. SIGKILL but can"t be catch, so don't need add it
 	signal.Notify(quitChan, syscall.SIGINT, syscall.SIGTERM)
 	<-quitChan
 	log.Println("Shutdown Server ...")
+	stopBackground()
 
 	//TODO revert to 2
 	//timeoutTime := 2
@@ -62,7 +65,7 @@ func main() {
 
 	select {
 	case <-ctx.Done():
-		log.Printf("timeout of %d seconds.", timeoutTime)
+		log.Printf("timeout of %v seconds.", timeoutTime)
 	}
 	log.Println("Server exiting")
 }
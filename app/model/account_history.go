@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+type Operation string
+
+const (
+	OperationCreate            Operation = "CREATE"
+	OperationDeposit           Operation = "DEPOSIT"
+	OperationWithdrawal        Operation = "WITHDRAWAL"
+	OperationTransferIn        Operation = "TRANSFER_IN"
+	OperationTransferOut       Operation = "TRANSFER_OUT"
+	OperationFreeze            Operation = "FREEZE"
+	OperationUnfreeze          Operation = "UNFREEZE"
+	OperationClose             Operation = "CLOSE"
+	OperationOverdraftInterest Operation = "OVERDRAFT_INTEREST"
+	OperationInterest          Operation = "INTEREST"
+	OperationLoanDisbursement  Operation = "LOAN_DISBURSEMENT"
+	OperationLoanRepayment     Operation = "LOAN_REPAYMENT"
+	OperationHold              Operation = "HOLD"
+	OperationHoldRelease       Operation = "HOLD_RELEASE"
+	OperationCrossChannelOut   Operation = "CROSS_CHANNEL_OUT"
+	OperationCrossChannelIn    Operation = "CROSS_CHANNEL_IN"
+	OperationUpdate            Operation = "UPDATE"
+	OperationDelete            Operation = "DELETE"
+)
+
+type AccountHistoryEntry struct {
+	TxID      string    `json:"tx_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Currency  Currency  `json:"currency"`
+	Balance   int64     `json:"balance"`
+	Delta     int64     `json:"delta"`
+	Operation Operation `json:"operation"`
+}
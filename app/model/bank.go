@@ -6,4 +6,5 @@ type Bank struct {
 	Headquarters string `json:"headquarters"`
 	Since        int    `json:"since"`
 	PIB          int    `json:"pib"`
+	MSPID        string `json:"msp_id"` // organization running the bank
 }
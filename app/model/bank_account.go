@@ -1,18 +1,61 @@
 package model
 
-type Currency int8
+import "time"
+
+type AccountStatus string
+
+const (
+	AccountActive AccountStatus = "ACTIVE"
+	AccountFrozen AccountStatus = "FROZEN"
+	AccountClosed AccountStatus = "CLOSED"
+)
+
+type AccountProduct string
 
 const (
-	EUR Currency = iota
-	RSD
+	ProductCurrent AccountProduct = "CURRENT"
+	ProductSavings AccountProduct = "SAVINGS"
 )
 
 type BankAccount struct {
 	ID       string   `json:"ID"`
-	Balance  float64  `json:"balance"`
+	Balance  int64    `json:"balance"` // in minor units of Currency
 	Currency Currency `json:"currency"`
-	Cards    []string `json:"cards"`
+	// Total of the active holds, reserved out of the balance but not moved yet
+	Held int64 `json:"held,omitempty"`
 
-	Bank   Bank   `json:"bank"`
+	BankID string `json:"bank_id"`
 	UserID string `json:"user_id"`
+
+	// Accounts opened before statuses were introduced have none and are active
+	Status AccountStatus `json:"status,omitempty"`
+	// Accounts opened before products were introduced have none and are current accounts
+	Product AccountProduct `json:"product,omitempty"`
+
+	// How far below zero the balance may go, in minor units of Currency
+	OverdraftLimit int64 `json:"overdraft_limit,omitempty"`
+	// Yearly interest charged on a negative balance, e.g. "0.12"
+	OverdraftRate string `json:"overdraft_rate,omitempty"`
+	// Interest accrued up to OverdraftAccruedAt that has not been charged yet,
+	// in minor units with fractions
+	OverdraftInterest  string    `json:"overdraft_interest,omitempty"`
+	OverdraftAccruedAt time.Time `json:"overdraft_accrued_at"`
+
+	// Interest earned up to InterestAccruedAt that has not been paid yet, in
+	// minor units with fractions
+	AccruedInterest   string    `json:"accrued_interest,omitempty"`
+	InterestAccruedAt time.Time `json:"interest_accrued_at"`
+
+	// Limits of the account itself, without them the defaults of the bank apply
+	Limits   *SpendingLimits `json:"limits,omitempty"`
+	Spending *DailySpending  `json:"spending,omitempty"`
+
+	LastOperation Operation `json:"last_operation,omitempty"`
+}
+
+// BankAccountDetails is an account joined with the bank it is held at, it is
+// only returned by queries and never stored.
+type BankAccountDetails struct {
+	BankAccount
+	Bank Bank `json:"bank"`
 }
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+const CardDocType = "card"
+
+type CardNetwork string
+
+const (
+	CardVisa       CardNetwork = "VISA"
+	CardMasterCard CardNetwork = "MASTERCARD"
+	CardAmex       CardNetwork = "AMEX"
+	CardDina       CardNetwork = "DINA"
+)
+
+type CardStatus string
+
+const (
+	CardActive   CardStatus = "ACTIVE"
+	CardBlocked  CardStatus = "BLOCKED"
+	CardReplaced CardStatus = "REPLACED"
+)
+
+// Card only keeps the last four digits of the card number, the full number
+// never reaches the ledger.
+type Card struct {
+	DocType    string      `json:"docType"`
+	ID         string      `json:"ID"`
+	AccountID  string      `json:"account_id"`
+	UserID     string      `json:"user_id"`
+	MaskedPAN  string      `json:"masked_pan"`
+	Network    CardNetwork `json:"network"`
+	Expiry     string      `json:"expiry"` // YYYY-MM, valid through the end of the month
+	Status     CardStatus  `json:"status"`
+	Currency   Currency    `json:"currency"`
+	Replaces   string      `json:"replaces,omitempty"`
+	ReplacedBy string      `json:"replaced_by,omitempty"`
+	IssuedAt   time.Time   `json:"issued_at"`
+}
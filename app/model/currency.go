@@ -0,0 +1,33 @@
+package model
+
+// Currency is an ISO 4217 alphabetic code registered on the ledger.
+type Currency string
+
+const CurrencyDocType = "currency"
+
+type RoundingMode string
+
+const (
+	RoundHalfUp   RoundingMode = "HALF_UP"
+	RoundHalfEven RoundingMode = "HALF_EVEN"
+)
+
+type CurrencyDefinition struct {
+	DocType    string       `json:"docType"`
+	Code       Currency     `json:"code"`
+	Name       string       `json:"name"`
+	MinorUnits int          `json:"minor_units"`
+	Rounding   RoundingMode `json:"rounding"`
+	Enabled    bool         `json:"enabled"`
+}
+
+type CurrencySettings struct {
+	BaseCurrency Currency `json:"base_currency"`
+}
+
+type EffectiveExchangeRate struct {
+	From    Currency `json:"from"`
+	To      Currency `json:"to"`
+	Rate    string   `json:"rate"`
+	RateIDs []string `json:"rate_ids"`
+}
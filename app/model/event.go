@@ -0,0 +1,85 @@
+package model
+
+import "time"
+
+const (
+	EventTransferCompleted        = "TransferCompleted"
+	EventDepositCompleted         = "DepositCompleted"
+	EventWithdrawalCompleted      = "WithdrawalCompleted"
+	EventAccountCreated           = "AccountCreated"
+	EventUserAdded                = "UserAdded"
+	EventAccountStatusChanged     = "AccountStatusChanged"
+	EventStandingOrdersRun        = "StandingOrdersRun"
+	EventCrossChannelSent         = "CrossChannelSent"
+	EventCrossChannelReceived     = "CrossChannelReceived"
+	EventLockRefunded             = "LockRefunded"
+	EventLoanDisbursed            = "LoanDisbursed"
+	EventLoanRepaid               = "LoanRepaid"
+	EventInterestCredited         = "InterestCredited"
+	EventOverdraftInterestCharged = "OverdraftInterestCharged"
+)
+
+// Event payloads are consumed outside the ledger, fields can be added but
+// never renamed or removed.
+
+type TransferCompletedEvent struct {
+	TxID               string    `json:"tx_id"`
+	SourceAccount      string    `json:"source_account"`
+	DestinationAccount string    `json:"destination_account"`
+	Amount             int64     `json:"amount"`
+	Currency           Currency  `json:"currency"`
+	ConvertedAmount    int64     `json:"converted_amount"`
+	ConvertedCurrency  Currency  `json:"converted_currency"`
+	Rate               string    `json:"rate"`
+	Timestamp          time.Time `json:"timestamp"`
+}
+
+// AccountMovementEvent is the payload of deposits, withdrawals and the other
+// operations that change the money of a single account, Balance is the
+// balance after it.
+type AccountMovementEvent struct {
+	TxID      string    `json:"tx_id"`
+	Account   string    `json:"account"`
+	UserID    string    `json:"user_id"`
+	Amount    int64     `json:"amount"`
+	Currency  Currency  `json:"currency"`
+	Balance   int64     `json:"balance"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type AccountCreatedEvent struct {
+	TxID      string    `json:"tx_id"`
+	Account   string    `json:"account"`
+	UserID    string    `json:"user_id"`
+	BankID    string    `json:"bank_id"`
+	Currency  Currency  `json:"currency"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type UserAddedEvent struct {
+	TxID      string    `json:"tx_id"`
+	UserID    string    `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type AccountStatusChangedEvent struct {
+	TxID      string        `json:"tx_id"`
+	Account   string        `json:"account"`
+	UserID    string        `json:"user_id"`
+	Status    AccountStatus `json:"status"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+type StandingOrdersRunEvent struct {
+	TxID      string             `json:"tx_id"`
+	Runs      []StandingOrderRun `json:"runs"`
+	Timestamp time.Time          `json:"timestamp"`
+}
+
+// InterestCreditedEvent carries every credit of an accrual run, a transaction
+// emits only one event. TxID of a credit is the ID of its transaction record.
+type InterestCreditedEvent struct {
+	TxID      string                 `json:"tx_id"`
+	Credits   []AccountMovementEvent `json:"credits"`
+	Timestamp time.Time              `json:"timestamp"`
+}
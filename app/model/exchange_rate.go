@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+const ExchangeRateDocType = "exchangeRate"
+
+type ExchangeRate struct {
+	DocType       string    `json:"docType"`
+	ID            string    `json:"ID"`
+	From          Currency  `json:"from"`
+	To            Currency  `json:"to"`
+	Rate          string    `json:"rate"` // units of To per one unit of From
+	EffectiveFrom time.Time `json:"effective_from"`
+	PublishedBy   string    `json:"published_by"`
+}
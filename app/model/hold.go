@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+const HoldDocType = "hold"
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "ACTIVE"
+	HoldCaptured HoldStatus = "CAPTURED"
+	HoldReleased HoldStatus = "RELEASED"
+	HoldExpired  HoldStatus = "EXPIRED"
+)
+
+// Hold reserves money of an account for a payee without moving it, amounts
+// are in minor units of the account currency.
+type Hold struct {
+	DocType      string     `json:"docType"`
+	ID           string     `json:"ID"`
+	AccountID    string     `json:"account_id"`
+	PayeeAccount string     `json:"payee_account"`
+	Amount       int64      `json:"amount"`
+	Currency     Currency   `json:"currency"`
+	Status       HoldStatus `json:"status"`
+	PlacedAt     time.Time  `json:"placed_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	// Set once the hold is no longer active
+	ClosedAt      time.Time `json:"closed_at"`
+	Captured      int64     `json:"captured,omitempty"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+}
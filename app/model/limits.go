@@ -0,0 +1,47 @@
+package model
+
+const BankLimitsDocType = "bankLimits"
+
+// SpendingLimits cap what can leave an account, in minor units of the account
+// currency. A zero limit does not restrict anything.
+type SpendingLimits struct {
+	PerTransaction  int64 `json:"per_transaction,omitempty"`
+	DailyWithdrawal int64 `json:"daily_withdrawal,omitempty"`
+	DailyTransfer   int64 `json:"daily_transfer,omitempty"`
+}
+
+// DailySpending is the running total of what left an account on one day,
+// dates are taken from the transaction timestamp in UTC.
+type DailySpending struct {
+	Date        string `json:"date"` // YYYY-MM-DD
+	Withdrawn   int64  `json:"withdrawn"`
+	Transferred int64  `json:"transferred"`
+}
+
+// BankLimits are the defaults for accounts of a bank in one currency that
+// have no limits of their own.
+type BankLimits struct {
+	DocType  string   `json:"docType"`
+	ID       string   `json:"ID"`
+	BankID   string   `json:"bank_id"`
+	Currency Currency `json:"currency"`
+	SpendingLimits
+}
+
+type LimitsSource string
+
+const (
+	LimitsFromAccount LimitsSource = "ACCOUNT"
+	LimitsFromBank    LimitsSource = "BANK"
+	LimitsNone        LimitsSource = "NONE"
+)
+
+// AccountLimits are the limits in force for an account together with what
+// was spent today, it is only returned by queries and never stored.
+type AccountLimits struct {
+	AccountID string       `json:"account_id"`
+	Currency  Currency     `json:"currency"`
+	Source    LimitsSource `json:"source"`
+	SpendingLimits
+	Spending DailySpending `json:"spending"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+const (
+	LoanDocType               = "loan"
+	LoanFundingAccountDocType = "loanFundingAccount"
+)
+
+type LoanStatus string
+
+const (
+	LoanPending  LoanStatus = "PENDING"
+	LoanApproved LoanStatus = "APPROVED"
+	LoanRejected LoanStatus = "REJECTED"
+	LoanActive   LoanStatus = "ACTIVE"
+	LoanLate     LoanStatus = "LATE"
+	LoanRepaid   LoanStatus = "REPAID"
+)
+
+// Loan is paid out into and repaid from one account, amounts are in minor
+// units of its currency.
+type Loan struct {
+	DocType    string     `json:"docType"`
+	ID         string     `json:"ID"`
+	UserID     string     `json:"user_id"`
+	AccountID  string     `json:"account_id"`
+	BankID     string     `json:"bank_id"`
+	Principal  int64      `json:"principal"`
+	Currency   Currency   `json:"currency"`
+	TermMonths int        `json:"term_months"`
+	Status     LoanStatus `json:"status"`
+	AppliedAt  time.Time  `json:"applied_at"`
+	// Yearly interest rate, set when the loan is approved, e.g. "0.065"
+	Rate       string    `json:"rate,omitempty"`
+	ApprovedAt time.Time `json:"approved_at"`
+	// The schedule is drawn up when the loan is disbursed
+	DisbursedAt  time.Time         `json:"disbursed_at"`
+	Installments []LoanInstallment `json:"installments,omitempty"`
+	// Due date of the first installment not paid in full, queried to find late loans
+	NextDueDate time.Time `json:"next_due_date"`
+	Repaid      int64     `json:"repaid"`
+	Outstanding int64     `json:"outstanding"`
+}
+
+type LoanInstallment struct {
+	Number    int       `json:"number"`
+	DueDate   time.Time `json:"due_date"`
+	Principal int64     `json:"principal"`
+	Interest  int64     `json:"interest"`
+	Amount    int64     `json:"amount"` // principal and interest
+	Paid      int64     `json:"paid"`
+}
+
+// LoanFundingAccount is where a bank lends from in one currency: disbursements
+// take from its position and repayments add to it, so loans move money
+// between it and customer accounts instead of creating it.
+type LoanFundingAccount struct {
+	DocType  string   `json:"docType"`
+	ID       string   `json:"ID"`
+	BankID   string   `json:"bank_id"`
+	Currency Currency `json:"currency"`
+	Position int64    `json:"position"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+const TransactionDocType = "transaction"
+
+type TransactionType string
+
+const (
+	TransactionTransfer          TransactionType = "TRANSFER"
+	TransactionDeposit           TransactionType = "DEPOSIT"
+	TransactionWithdrawal        TransactionType = "WITHDRAWAL"
+	TransactionPayout            TransactionType = "PAYOUT" // balance of an account being closed
+	TransactionOverdraftInterest TransactionType = "OVERDRAFT_INTEREST"
+	TransactionInterest          TransactionType = "INTEREST"
+	TransactionLoanDisbursement  TransactionType = "LOAN_DISBURSEMENT"
+	TransactionLoanRepayment     TransactionType = "LOAN_REPAYMENT"
+	TransactionCrossChannel      TransactionType = "CROSS_CHANNEL"
+)
+
+type Transaction struct {
+	DocType            string          `json:"docType"`
+	ID                 string          `json:"ID"`
+	Type               TransactionType `json:"type"`
+	SourceAccount      string          `json:"source_account,omitempty"`
+	DestinationAccount string          `json:"destination_account,omitempty"`
+	Amount             int64           `json:"amount"`
+	Currency           Currency        `json:"currency"`
+	ConvertedAmount    int64           `json:"converted_amount"`
+	ConvertedCurrency  Currency        `json:"converted_currency"`
+	Rate               string          `json:"rate"`
+	RateIDs            []string        `json:"rate_ids,omitempty"`
+	Fees               []AppliedFee    `json:"fees,omitempty"` // charged on top of Amount
+	Timestamp          time.Time       `json:"timestamp"`
+	Initiator          string          `json:"initiator"`
+	StandingOrder      string          `json:"standing_order,omitempty"` // order the transfer was made for
+	Loan               string          `json:"loan,omitempty"`           // loan disbursed or repaid
+	Hold               string          `json:"hold,omitempty"`           // hold the transfer captured
+	Lock               string          `json:"lock,omitempty"`           // cross-channel lock claimed
+}
@@ -1,8 +1,42 @@
 package model
 
+const UserPIIDocType = "userPII"
+
+// User is the public record of a user. The personal data is kept in the
+// private data collection of the organization that registered the user, the
+// public record only commits to it with a hash.
 type User struct {
+	ID string `json:"ID"`
+
+	PIIHash       string `json:"pii_hash,omitempty"` // hex encoded SHA-256 of the stored UserPII
+	PIICollection string `json:"pii_collection,omitempty"`
+
+	MSPID string `json:"msp_id,omitempty"` // organization the user was registered by
+}
+
+// UserPII is the personal data of a user, readable only by the organization
+// keeping it.
+type UserPII struct {
+	DocType string `json:"docType"`
 	ID      string `json:"ID"`
 	Name    string `json:"name"`
 	Surname string `json:"surname"`
 	Email   string `json:"email"`
+	// Random value keeping the public hash from being matched against guesses
+	Salt string `json:"salt"`
+}
+
+// UserDetails is a user with its personal data, it is never stored as a whole.
+type UserDetails struct {
+	User
+	Name    string `json:"name"`
+	Surname string `json:"surname"`
+	Email   string `json:"email"`
+}
+
+// PIIMigration is the outcome of moving personal data into the collection of
+// an organization. Unassigned users have no organization and were not moved.
+type PIIMigration struct {
+	Migrated   int      `json:"migrated"`
+	Unassigned []string `json:"unassigned"`
 }
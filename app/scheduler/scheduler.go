@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"app/model"
+	"app/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Scheduler submits ExecuteDueStandingOrders on every channel at a fixed
+// interval, signed by an administrator. Orders that cannot be executed keep
+// the reason on the ledger, the scheduler only logs it.
+type Scheduler struct {
+	User       model.UserInfo
+	ChainCodes map[string]string
+	Interval   time.Duration
+}
+
+// Run executes the due orders right away and then on every tick, until the
+// context is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		for channel := range s.ChainCodes {
+			runs, err := s.ExecuteDueStandingOrders(channel)
+			if err != nil {
+				log.Printf("Failed to execute standing orders on %s: %v", channel, err)
+				continue
+			}
+			for _, run := range runs {
+				if run.Error != "" {
+					log.Printf("Standing order %s on %s failed: %s", run.OrderID, channel, run.Error)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) ExecuteDueStandingOrders(channel string) ([]model.StandingOrderRun, error) {
+	wallet, err := utils.CreateWallet(s.User.UserId, s.User.Organization, s.User.Admin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create or populate wallet: %v", err)
+	}
+
+	gw, err := utils.ConnectToGateway(wallet, s.User.Organization, s.User.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gateway: %v", err)
+	}
+	defer gw.Close()
+
+	network, err := gw.GetNetwork(channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network: %v", err)
+	}
+
+	contract := network.GetContract(s.ChainCodes[channel])
+	response, err := contract.SubmitTransaction("ExecuteDueStandingOrders")
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []model.StandingOrderRun
+	if err := json.Unmarshal(response, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
@@ -2,13 +2,26 @@ package server
 
 import (
 	"app/config"
+	"app/events"
+	"app/scheduler"
+	"app/utils"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"log"
 )
 
 type Server struct {
-	Config config.Config
-	Router *gin.Engine
+	Config    config.Config
+	Router    *gin.Engine
+	Scheduler *scheduler.Scheduler
+	Events    *events.Listener
+}
+
+func chainCodes() map[string]string {
+	return map[string]string{
+		"channel1": "bankchaincode1",
+		"channel2": "bankchaincode2",
+	}
 }
 
 func NewServer() (*Server, error) {
@@ -22,11 +35,38 @@ func NewServer() (*Server, error) {
 		Config: config,
 	}
 
+	if user, ok := utils.SetupUsers()[config.CrossChannelUser]; !ok || !user.Admin {
+		return nil, fmt.Errorf("cross-channel user %s is not an administrator", config.CrossChannelUser)
+	}
+
 	err = server.CreateRoutersAndSetRoutes()
 	if err != nil {
 		log.Fatal(err.Error())
 		return nil, err
 	}
 
+	user, ok := utils.SetupUsers()[config.StandingOrdersUser]
+	if !ok || !user.Admin {
+		return nil, fmt.Errorf("standing orders user %s is not an administrator", config.StandingOrdersUser)
+	}
+	server.Scheduler = &scheduler.Scheduler{
+		User:       user,
+		ChainCodes: chainCodes(),
+		Interval:   config.StandingOrdersInterval,
+	}
+
+	user, ok = utils.SetupUsers()[config.EventsUser]
+	if !ok {
+		return nil, fmt.Errorf("events user %s does not exist", config.EventsUser)
+	}
+	server.Events = &events.Listener{
+		User:          user,
+		ChainCodes:    chainCodes(),
+		RetryInterval: config.EventsRetryInterval,
+	}
+	server.Events.Subscribe(func(event events.Event) {
+		log.Printf("Event %s of transaction %s on %s", event.Name, event.TxID, event.Channel)
+	})
+
 	return server, nil
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"app/coordinator"
 	"app/handler"
 	"app/jwt"
 	"app/utils"
@@ -10,9 +11,11 @@ import (
 func (s *Server) CreateRoutersAndSetRoutes() error {
 	handler := handler.Handler{}
 	handler.Users = utils.SetupUsers()
-	handler.ChainCodes = map[string]string{
-		"channel1": "bankchaincode1",
-		"channel2": "bankchaincode2",
+	handler.ChainCodes = chainCodes()
+	handler.Coordinator = &coordinator.Coordinator{
+		Admin:      handler.Users[s.Config.CrossChannelUser],
+		ChainCodes: handler.ChainCodes,
+		Timeout:    s.Config.CrossChannelTimeout,
 	}
 
 	// ROUTES
@@ -29,11 +32,69 @@ func (s *Server) CreateRoutersAndSetRoutes() error {
 	router.POST("/add-user/:channel", jwt.AuthorizationMiddleware("ADMIN"), handler.AddUser)
 	router.POST("/create-bank-account/:channel", jwt.AuthorizationMiddleware("USER"), handler.CreateBankAccount)
 	router.POST("/transfer-money/:channel", jwt.AuthorizationMiddleware("USER"), handler.TransferMoney)
+	router.POST("/transfer-money/:channel/:quote-id/confirm", jwt.AuthorizationMiddleware("USER"), handler.ConfirmTransfer)
 	router.POST("/money-withdrawal/:channel", jwt.AuthorizationMiddleware("USER"), handler.MoneyWithdrawal)
 	router.POST("/money-deposit/:channel", jwt.AuthorizationMiddleware("USER"), handler.MoneyDepositToAccount)
 	router.GET("/search/:channel/:by/:param1/:param2", jwt.AuthorizationMiddleware("ADMIN"), handler.Query)
 	router.GET("/search-accounts/:channel/:bank-id/:currency/:balance-thresh", jwt.AuthorizationMiddleware("ADMIN"), handler.GetAccountsByBankDesiredCurrencyAndBalance)
 	router.GET("/max-account/:channel/:bank-id/:currency", jwt.AuthorizationMiddleware("ADMIN"), handler.GetAccountByBankDesiredCurrencyAndMaxBalance)
+	router.GET("/accounts/:channel/:id/history", jwt.AuthorizationMiddleware("USER", "ADMIN"), handler.GetAccountHistory)
+	router.PUT("/accounts/:channel/:id/freeze", jwt.AuthorizationMiddleware("ADMIN"), handler.FreezeAccount)
+	router.PUT("/accounts/:channel/:id/unfreeze", jwt.AuthorizationMiddleware("ADMIN"), handler.UnfreezeAccount)
+	router.PUT("/accounts/:channel/:id/close", jwt.AuthorizationMiddleware("ADMIN"), handler.CloseAccount)
+	router.PUT("/accounts/:channel/:id/overdraft", jwt.AuthorizationMiddleware("ADMIN"), handler.SetOverdraftLimit)
+	router.POST("/accounts/:channel/:id/overdraft-interest", jwt.AuthorizationMiddleware("ADMIN"), handler.ChargeOverdraftInterest)
+	router.GET("/overdrafts/:channel/:bank-id", jwt.AuthorizationMiddleware("ADMIN"), handler.GetAccountsInOverdraft)
+	router.GET("/accounts/:channel/:id/limits", jwt.AuthorizationMiddleware("ADMIN"), handler.GetAccountLimits)
+	router.PUT("/accounts/:channel/:id/limits", jwt.AuthorizationMiddleware("ADMIN"), handler.SetAccountLimits)
+	router.DELETE("/accounts/:channel/:id/limits", jwt.AuthorizationMiddleware("ADMIN"), handler.ResetAccountLimits)
+	router.GET("/accounts/:channel/:id/cards", jwt.AuthorizationMiddleware("USER"), handler.ListCards)
+	router.POST("/cards/:channel", jwt.AuthorizationMiddleware("USER"), handler.IssueCard)
+	router.PUT("/cards/:channel/:id/block", jwt.AuthorizationMiddleware("USER"), handler.BlockCard)
+	router.POST("/cards/:channel/:id/replace", jwt.AuthorizationMiddleware("USER"), handler.ReplaceCard)
+	router.GET("/accounts/:channel/:id/standing-orders", jwt.AuthorizationMiddleware("USER"), handler.ListStandingOrders)
+	router.POST("/standing-orders/:channel", jwt.AuthorizationMiddleware("USER"), handler.CreateStandingOrder)
+	router.DELETE("/standing-orders/:channel/:id", jwt.AuthorizationMiddleware("USER"), handler.CancelStandingOrder)
+	router.POST("/standing-orders/:channel/execute", jwt.AuthorizationMiddleware("ADMIN"), handler.ExecuteDueStandingOrders)
+	router.GET("/accounts/:channel/:id/holds", jwt.AuthorizationMiddleware("USER", "ADMIN"), handler.ListHolds)
+	router.POST("/holds/:channel", jwt.AuthorizationMiddleware("USER"), handler.PlaceHold)
+	router.POST("/holds/:channel/:id/capture", jwt.AuthorizationMiddleware("USER", "ADMIN"), handler.CaptureHold)
+	router.POST("/holds/:channel/:id/release", jwt.AuthorizationMiddleware("USER", "ADMIN"), handler.ReleaseHold)
+	router.POST("/cross-channel-transfers", jwt.AuthorizationMiddleware("USER"), handler.CrossChannelTransfer)
+	router.POST("/cross-channel-transfers/:hash-lock/complete", jwt.AuthorizationMiddleware("USER", "ADMIN"), handler.CompleteCrossChannelTransfer)
+	router.GET("/locks/:channel/:hash-lock", jwt.AuthorizationMiddleware("USER", "ADMIN"), handler.ReadHashTimeLock)
+	router.POST("/locks/:channel/:hash-lock/refund", jwt.AuthorizationMiddleware("USER", "ADMIN"), handler.RefundLock)
+	router.GET("/clearing-accounts/:channel", jwt.AuthorizationMiddleware("ADMIN"), handler.GetClearingAccounts)
+	router.POST("/loans/:channel", jwt.AuthorizationMiddleware("USER"), handler.ApplyForLoan)
+	router.GET("/loans/:channel", jwt.AuthorizationMiddleware("USER"), handler.ListLoans)
+	router.GET("/loans/:channel/:id", jwt.AuthorizationMiddleware("USER", "ADMIN"), handler.ReadLoan)
+	router.POST("/loans/:channel/:id/repay", jwt.AuthorizationMiddleware("USER"), handler.RepayLoan)
+	router.PUT("/loans/:channel/:id/approve", jwt.AuthorizationMiddleware("ADMIN"), handler.ApproveLoan)
+	router.PUT("/loans/:channel/:id/reject", jwt.AuthorizationMiddleware("ADMIN"), handler.RejectLoan)
+	router.POST("/loans/:channel/:id/disburse", jwt.AuthorizationMiddleware("ADMIN"), handler.DisburseLoan)
+	router.GET("/loans/:channel/status/:status", jwt.AuthorizationMiddleware("ADMIN"), handler.GetLoansByStatus)
+	router.POST("/loans/:channel/mark-late", jwt.AuthorizationMiddleware("ADMIN"), handler.MarkLateLoans)
+	router.POST("/interest/:channel/:bank-id", jwt.AuthorizationMiddleware("ADMIN"), handler.AccrueInterest)
+	router.GET("/transactions/:channel", jwt.AuthorizationMiddleware("USER", "ADMIN"), handler.GetTransactions)
+	router.POST("/exchange-rates/:channel", jwt.AuthorizationMiddleware("ADMIN"), handler.PublishExchangeRate)
+	router.GET("/exchange-rates/:channel", handler.GetExchangeRates)
+	router.GET("/exchange-rates/:channel/:from/:to", handler.GetExchangeRate)
+	router.GET("/currencies/:channel", handler.GetCurrencies)
+	router.POST("/currencies/:channel", jwt.AuthorizationMiddleware("ADMIN"), handler.RegisterCurrency)
+	router.PUT("/currencies/:channel/:code/enabled", jwt.AuthorizationMiddleware("ADMIN"), handler.SetCurrencyEnabled)
+	router.PUT("/currencies/:channel/base", jwt.AuthorizationMiddleware("ADMIN"), handler.SetBaseCurrency)
+	router.POST("/banks/:channel", jwt.AuthorizationMiddleware("ADMIN"), handler.CreateBank)
+	router.GET("/banks/:channel", jwt.AuthorizationMiddleware("ADMIN"), handler.ListBanks)
+	router.GET("/banks/:channel/:id", jwt.AuthorizationMiddleware("ADMIN"), handler.ReadBank)
+	router.PUT("/banks/:channel/:id", jwt.AuthorizationMiddleware("ADMIN"), handler.UpdateBank)
+	router.GET("/banks/:channel/:id/limits", jwt.AuthorizationMiddleware("ADMIN"), handler.GetBankLimits)
+	router.PUT("/banks/:channel/:id/limits/:currency", jwt.AuthorizationMiddleware("ADMIN"), handler.SetBankLimits)
+	router.GET("/banks/:channel/:id/interest-rates", handler.GetInterestRates)
+	router.GET("/banks/:channel/:id/fees", handler.GetFeeSchedules)
+	router.PUT("/banks/:channel/:id/fees/:currency", jwt.AuthorizationMiddleware("ADMIN"), handler.SetFeeSchedule)
+	router.GET("/banks/:channel/:id/revenue", jwt.AuthorizationMiddleware("ADMIN"), handler.GetRevenueAccounts)
+	router.GET("/banks/:channel/:id/loan-funding", jwt.AuthorizationMiddleware("ADMIN"), handler.GetLoanFundingAccounts)
+	router.PUT("/banks/:channel/:id/interest-rates/:product", jwt.AuthorizationMiddleware("ADMIN"), handler.SetInterestRate)
 
 	s.Router = router
 	return nil
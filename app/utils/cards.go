@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Issuer identification numbers and lengths of the card numbers per network,
+// the chaincode only accepts numbers that match them.
+var cardIINs = map[string]string{
+	"VISA":       "4",
+	"MASTERCARD": "51",
+	"AMEX":       "37",
+	"DINA":       "9891",
+}
+
+var cardNumberLengths = map[string]int{
+	"VISA":       16,
+	"MASTERCARD": 16,
+	"AMEX":       15,
+	"DINA":       16,
+}
+
+// GenerateCardNumber draws a random card number of the network with a valid
+// Luhn check digit. The number is passed to the chaincode in the transient map,
+// so it never becomes part of a transaction.
+func GenerateCardNumber(network string) (string, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(network), " ", ""))
+	if normalized == "AMERICANEXPRESS" {
+		normalized = "AMEX"
+	}
+	iin, ok := cardIINs[normalized]
+	if !ok {
+		return "", fmt.Errorf("unsupported card network: %s", network)
+	}
+
+	digits := []byte(iin)
+	for len(digits) < cardNumberLengths[normalized]-1 {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate card number: %v", err)
+		}
+		digits = append(digits, '0'+byte(digit.Int64()))
+	}
+	return string(digits) + string('0'+luhnCheckDigit(digits)), nil
+}
+
+func luhnCheckDigit(digits []byte) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		// Doubling starts with the rightmost digit since the check digit follows it
+		if (len(digits)-i)%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return byte((10 - sum%10) % 10)
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// Role of the API user, added to the enrollment certificate next to the
+// hf.EnrollmentID and hf.Type attributes the CA always includes.
+const roleAttribute = "role"
+
+func orgName(org string) string {
+	return strings.ToUpper(org[:1]) + org[1:]
+}
+
+func orgMSP(org string) string {
+	return orgName(org) + "MSP"
+}
+
+// enrollUser registers the user with the organization CA and enrolls it once
+// with the random secret the CA generated, so the identity cannot be enrolled
+// again by anyone knowing the user ID. Administrators are registered with the
+// admin type the chaincode checks for.
+func enrollUser(userId, org string, admin bool) (*gateway.X509Identity, error) {
+	sdk, err := fabsdk.New(config.FromFile(filepath.Clean(connectionProfilePath(org))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sdk: %v", err)
+	}
+	defer sdk.Close()
+
+	mspClient, err := msp.New(sdk.Context(), msp.WithOrg(orgName(org)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA client: %v", err)
+	}
+
+	identityType, role := "client", "USER"
+	if admin {
+		identityType, role = "admin", "ADMIN"
+	}
+
+	secret, err := mspClient.Register(&msp.RegistrationRequest{
+		Name:           userId,
+		Type:           identityType,
+		MaxEnrollments: 1,
+		Attributes: []msp.Attribute{
+			{Name: roleAttribute, Value: role, ECert: true},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "is already registered") {
+			return nil, fmt.Errorf("%s is already registered with the CA but missing from the wallet", userId)
+		}
+		return nil, fmt.Errorf("failed to register %s: %v", userId, err)
+	}
+
+	if err := mspClient.Enroll(userId, msp.WithSecret(secret)); err != nil {
+		return nil, fmt.Errorf("failed to enroll %s: %v", userId, err)
+	}
+
+	signingIdentity, err := mspClient.GetSigningIdentity(userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity of %s: %v", userId, err)
+	}
+
+	// The SDK keeps the enrollment key in its key store, named after the key's SKI
+	configBackend, err := sdk.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sdk config: %v", err)
+	}
+	keyPath := filepath.Join(
+		cryptosuite.ConfigFromBackend(configBackend).KeyStorePath(),
+		hex.EncodeToString(signingIdentity.PrivateKey().SKI())+"_sk",
+	)
+	key, err := ioutil.ReadFile(filepath.Clean(keyPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key of %s: %v", userId, err)
+	}
+
+	return gateway.NewX509Identity(orgMSP(org), string(signingIdentity.EnrollmentCertificate()), string(key)), nil
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"app/model"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+type Currencies map[model.Currency]model.CurrencyDefinition
+
+// LoadCurrencies reads the currency registry so amounts can be rendered with
+// the number of minor units each currency uses.
+func LoadCurrencies(contract *gateway.Contract) (Currencies, error) {
+	result, err := contract.EvaluateTransaction("GetCurrencies")
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions []model.CurrencyDefinition
+	if err := json.Unmarshal(result, &definitions); err != nil {
+		return nil, err
+	}
+
+	currencies := Currencies{}
+	for _, definition := range definitions {
+		currencies[definition.Code] = definition
+	}
+	return currencies, nil
+}
+
+// FormatAmount renders minor units as a decimal string, e.g. 150050 EUR as "1500.50".
+func (c Currencies) FormatAmount(amount int64, currency model.Currency) string {
+	definition, ok := c[currency]
+	if !ok {
+		return fmt.Sprintf("%d", amount)
+	}
+
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(definition.MinorUnits)), nil)
+	return new(big.Rat).SetFrac(big.NewInt(amount), divisor).FloatString(definition.MinorUnits)
+}
@@ -4,56 +4,22 @@ import (
 	"fmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
-	"io/ioutil"
 	"log"
 	"path/filepath"
-	"strings"
 )
 
-func PopulateWallet(wallet *gateway.Wallet, org string) error {
-	orgPath := fmt.Sprintf("%s.example.com", org)
-	usrPath := fmt.Sprintf("User1@%s.example.com", org)
-	orgMSP := strings.ToUpper(org[:1]) + org[1:] + "MSP"
-
-	credPath := filepath.Join(
-		"..",
-		"infrastructure",
-		"organizations",
-		"peerOrganizations",
-		orgPath,
-		"users",
-		usrPath,
-		"msp",
-	)
-
-	certPath := filepath.Join(credPath, "signcerts", "cert.pem")
-	// read the certificate pem
-	cert, err := ioutil.ReadFile(filepath.Clean(certPath))
+// Every API user transacts with an identity of its own, enrolled with the
+// organization CA under the user ID and stored in the wallet under that label.
+func PopulateWallet(wallet *gateway.Wallet, userId, org string, admin bool) error {
+	identity, err := enrollUser(userId, org, admin)
 	if err != nil {
 		return err
 	}
 
-	keyDir := filepath.Join(credPath, "keystore")
-	// there's a single file in this dir containing the private key
-	files, err := ioutil.ReadDir(keyDir)
-	if err != nil {
-		return err
-	}
-	if len(files) != 1 {
-		return fmt.Errorf("keystore folder should have contain one file")
-	}
-	keyPath := filepath.Join(keyDir, files[0].Name())
-	key, err := ioutil.ReadFile(filepath.Clean(keyPath))
-	if err != nil {
-		return err
-	}
-
-	identity := gateway.NewX509Identity(orgMSP, string(cert), string(key))
-
-	return wallet.Put("usr1", identity)
+	return wallet.Put(userId, identity)
 }
 
-func CreateWallet(userId, userOrg string) (*gateway.Wallet, error) {
+func CreateWallet(userId, userOrg string, admin bool) (*gateway.Wallet, error) {
 	walletPath := fmt.Sprintf("wallet/%s", userOrg)
 	wallet, err := gateway.NewFileSystemWallet(walletPath)
 	if err != nil {
@@ -62,9 +28,9 @@ func CreateWallet(userId, userOrg string) (*gateway.Wallet, error) {
 	}
 
 	if !wallet.Exists(userId) {
-		err = PopulateWallet(wallet, userOrg)
+		err = PopulateWallet(wallet, userId, userOrg, admin)
 		if err != nil {
-			log.Fatalf("Failed to populate wallet contents: %v", err)
+			log.Printf("Failed to populate wallet contents: %v", err)
 			return nil, err
 		}
 	}
@@ -72,10 +38,10 @@ func CreateWallet(userId, userOrg string) (*gateway.Wallet, error) {
 	return wallet, nil
 }
 
-func ConnectToGateway(wallet *gateway.Wallet, org string) (*gateway.Gateway, error) {
+func connectionProfilePath(org string) string {
 	orgPath := fmt.Sprintf("%s.example.com", org)
 	connection := fmt.Sprintf("connection-%s.json", org)
-	ccpPath := filepath.Join(
+	return filepath.Join(
 		"..",
 		"infrastructure",
 		"organizations",
@@ -83,10 +49,12 @@ func ConnectToGateway(wallet *gateway.Wallet, org string) (*gateway.Gateway, err
 		orgPath,
 		connection,
 	)
+}
 
+func ConnectToGateway(wallet *gateway.Wallet, org string, userId string) (*gateway.Gateway, error) {
 	gw, err := gateway.Connect(
-		gateway.WithConfig(config.FromFile(filepath.Clean(ccpPath))),
-		gateway.WithIdentity(wallet, "usr1"),
+		gateway.WithConfig(config.FromFile(filepath.Clean(connectionProfilePath(org)))),
+		gateway.WithIdentity(wallet, userId),
 	)
 	if err != nil {
 		log.Fatalf("Failed to connect to gateway: %v", err)
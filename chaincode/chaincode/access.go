@@ -0,0 +1,180 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Identities enrolled with the organization CA get these attributes in their
+// enrollment certificate, the enrollment ID of a bank user is its user ID.
+const (
+	identityTypeAttribute = "hf.Type"
+	enrollmentIDAttribute = "hf.EnrollmentID"
+	adminIdentityType     = "admin"
+)
+
+type caller struct {
+	MSPID  string
+	UserID string
+	Admin  bool
+}
+
+func callerIdentity(ctx contractapi.TransactionContextInterface) (*caller, error) {
+	identity := ctx.GetClientIdentity()
+
+	mspID, err := identity.GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client identity: %v", err)
+	}
+
+	userID, found, err := identity.GetAttributeValue(enrollmentIDAttribute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client identity: %v", err)
+	}
+	if !found || userID == "" {
+		return nil, fmt.Errorf("client identity has no enrollment ID")
+	}
+
+	identityType, _, err := identity.GetAttributeValue(identityTypeAttribute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client identity: %v", err)
+	}
+
+	return &caller{
+		MSPID:  mspID,
+		UserID: userID,
+		Admin:  identityType == adminIdentityType,
+	}, nil
+}
+
+func assertAdmin(ctx contractapi.TransactionContextInterface) error {
+	identityType, found, err := ctx.GetClientIdentity().GetAttributeValue(identityTypeAttribute)
+	if err != nil {
+		return fmt.Errorf("failed to read client identity: %v", err)
+	}
+	if !found || identityType != adminIdentityType {
+		return fmt.Errorf("only administrators are allowed to perform this action")
+	}
+	return nil
+}
+
+// assertBankAdmin lets only administrators of the organization running the
+// bank manage it and the accounts, loans and products it holds.
+func assertBankAdmin(ctx contractapi.TransactionContextInterface, bankID string) error {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !identity.Admin {
+		return fmt.Errorf("only administrators are allowed to perform this action")
+	}
+
+	mspID, err := bankOrganization(ctx, bankID)
+	if err != nil {
+		return err
+	}
+	if mspID != identity.MSPID {
+		return fmt.Errorf("only administrators of the organization running bank %s are allowed to perform this action", bankID)
+	}
+	return nil
+}
+
+// bankOrganization returns the MSP ID of the organization running the bank.
+// Banks seeded before organizations were recorded are run by the organization
+// they were seeded for, also before MigrateBankReferences gave them a document
+// type.
+func bankOrganization(ctx contractapi.TransactionContextInterface, bankID string) (string, error) {
+	bankJSON, err := ctx.GetStub().GetState(bankID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bankJSON == nil {
+		return "", fmt.Errorf("the bank with id %s does not exist", bankID)
+	}
+
+	var bank model.Bank
+	if err := json.Unmarshal(bankJSON, &bank); err != nil {
+		return "", err
+	}
+	if bank.DocType != model.BankDocType && (bank.DocType != "" || bank.PIB == 0) {
+		return "", fmt.Errorf("the bank with id %s does not exist", bankID)
+	}
+	if bank.MSPID != "" {
+		return bank.MSPID, nil
+	}
+
+	banks, _, _ := utils.InitializeData()
+	for _, seeded := range banks {
+		if seeded.ID == bankID {
+			return seeded.MSPID, nil
+		}
+	}
+	return "", fmt.Errorf("bank %s is not run by any organization", bankID)
+}
+
+// adminBanks lets only administrators through and returns the banks run by
+// their organization.
+func adminBanks(ctx contractapi.TransactionContextInterface) (map[string]bool, error) {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !identity.Admin {
+		return nil, fmt.Errorf("only administrators are allowed to perform this action")
+	}
+
+	banks, err := queryBanks(ctx, map[string]interface{}{"docType": model.BankDocType})
+	if err != nil {
+		return nil, err
+	}
+
+	bankIDs := map[string]bool{}
+	for _, bank := range banks {
+		mspID, err := bankOrganization(ctx, bank.ID)
+		if err != nil {
+			return nil, err
+		}
+		if mspID == identity.MSPID {
+			bankIDs[bank.ID] = true
+		}
+	}
+	return bankIDs, nil
+}
+
+// assertAccountOwner lets only the owner of the account, enrolled with the
+// organization the owner was registered by, move money from or to it.
+// Accounts of other users are reported as missing so their IDs do not leak.
+func assertAccountOwner(ctx contractapi.TransactionContextInterface, account *model.BankAccount) (*caller, error) {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	notFound := fmt.Errorf("bank account with ID %s not found for user %s", account.ID, identity.UserID)
+	if account.UserID != identity.UserID {
+		return nil, notFound
+	}
+
+	userJSON, err := ctx.GetStub().GetState(account.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if userJSON == nil {
+		return nil, notFound
+	}
+
+	var user model.User
+	if err := json.Unmarshal(userJSON, &user); err != nil {
+		return nil, err
+	}
+	// Users added before organizations were recorded can only be checked by ID
+	if user.MSPID != "" && user.MSPID != identity.MSPID {
+		return nil, notFound
+	}
+
+	return identity, nil
+}
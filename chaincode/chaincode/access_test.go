@@ -0,0 +1,90 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferMoney_OnlyOwnerCanSpend(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	// Test Case: Source account belongs to somebody else
+	transactionContext.GetClientIdentityReturns(userIdentity("u2"))
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+
+	_, err := smartContract.TransferMoney(transactionContext, "a1", "a2", "10")
+	require.EqualError(t, err, "bank account with ID a1 not found for user u2")
+
+	// Test Case: Same enrollment ID issued by another organization
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"ID":"u1","msp_id":"Org2MSP"}`), nil)
+
+	_, err = smartContract.TransferMoney(transactionContext, "a1", "a2", "10")
+	require.EqualError(t, err, "bank account with ID a1 not found for user u1")
+
+	// Test Case: Certificate without enrollment ID
+	transactionContext.GetClientIdentityReturns(&mocks.ClientIdentity{})
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+
+	_, err = smartContract.TransferMoney(transactionContext, "a1", "a2", "10")
+	require.EqualError(t, err, "client identity has no enrollment ID")
+
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestMoneyDepositToAccount_OwnerFromAnotherOrganization(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1","msp_id":"Org3MSP"}`), nil)
+
+	_, err := smartContract.MoneyDepositToAccount(transactionContext, "a1", "10")
+	require.EqualError(t, err, "bank account with ID a1 not found for user u1")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestCreateBankAccount_ForAnotherUser(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	err := smartContract.CreateBankAccount(transactionContext, "a1", "EUR", "Visa", "b1", "u2", "")
+	require.EqualError(t, err, "users can only open bank accounts for themselves")
+	require.Equal(t, 0, chaincodeStub.GetStateCallCount())
+}
+
+func TestAdminOnlyOperations(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	err := smartContract.InitLedger(transactionContext)
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+
+	err = smartContract.AddUser(transactionContext, "u13")
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+
+	_, err = smartContract.MigrateBalancesToMinorUnits(transactionContext)
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
@@ -0,0 +1,227 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func accountStatus(account *model.BankAccount) model.AccountStatus {
+	if account.Status == "" {
+		return model.AccountActive
+	}
+	return account.Status
+}
+
+// assertAccountActive fails for frozen and closed accounts, money can neither
+// leave nor reach them.
+func assertAccountActive(account *model.BankAccount) error {
+	if status := accountStatus(account); status != model.AccountActive {
+		return fmt.Errorf("bank account %s is %s", account.ID, strings.ToLower(string(status)))
+	}
+	return nil
+}
+
+func (s *SmartContract) FreezeAccount(ctx contractapi.TransactionContextInterface, id string) (*model.BankAccount, error) {
+	account, err := readBankAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, account.BankID); err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+
+	account.Status = model.AccountFrozen
+	account.LastOperation = model.OperationFreeze
+	return account, putAccountStatus(ctx, account)
+}
+
+func (s *SmartContract) UnfreezeAccount(ctx contractapi.TransactionContextInterface, id string) (*model.BankAccount, error) {
+	account, err := readBankAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, account.BankID); err != nil {
+		return nil, err
+	}
+	if accountStatus(account) != model.AccountFrozen {
+		return nil, fmt.Errorf("bank account %s is not frozen", id)
+	}
+
+	account.Status = model.AccountActive
+	account.LastOperation = model.OperationUnfreeze
+	return account, putAccountStatus(ctx, account)
+}
+
+// CloseAccount closes an active or frozen account for good. Savings interest
+// earned up to the close is credited first. Accounts holding money are only
+// closed together with a payout of the balance to another active account,
+// converted at the exchange rate in force.
+func (s *SmartContract) CloseAccount(ctx contractapi.TransactionContextInterface, id, payoutAccountID string) (*model.BankAccount, error) {
+	account, err := readBankAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, account.BankID); err != nil {
+		return nil, err
+	}
+	if accountStatus(account) == model.AccountClosed {
+		return nil, fmt.Errorf("bank account %s is closed", id)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if err := accrueInterest(ctx, account, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+
+	accrued, err := utils.ParseAccruedInterest(account.OverdraftInterest)
+	if err != nil {
+		return nil, err
+	}
+	if charge, _ := utils.WholeMinorUnits(accrued); charge > 0 {
+		return nil, fmt.Errorf("overdraft interest of bank account %s has to be charged before it is closed", id)
+	}
+	if err := creditClosingInterest(ctx, account); err != nil {
+		return nil, err
+	}
+
+	if err := expireHolds(ctx, account, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+	if account.Held > 0 {
+		return nil, fmt.Errorf("bank account %s has money on hold and cannot be closed", id)
+	}
+
+	if account.Balance != 0 {
+		if payoutAccountID == "" {
+			return nil, fmt.Errorf("bank account %s still holds money, a payout account is required to close it", id)
+		}
+		if account.Balance < 0 {
+			return nil, fmt.Errorf("bank account %s is overdrawn and cannot be closed", id)
+		}
+		if err := payOut(ctx, account, payoutAccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	account.Status = model.AccountClosed
+	account.LastOperation = model.OperationClose
+	return account, putAccountStatus(ctx, account)
+}
+
+// creditClosingInterest credits the whole minor units of the savings interest
+// accrued so far, no later accrual pays the fractions of a closed account.
+func creditClosingInterest(ctx contractapi.TransactionContextInterface, account *model.BankAccount) error {
+	accrued, err := utils.ParseAccruedInterest(account.AccruedInterest)
+	if err != nil {
+		return err
+	}
+	credit, remainder := utils.WholeMinorUnits(accrued)
+	if credit <= 0 {
+		return nil
+	}
+
+	account.Balance += credit
+	account.AccruedInterest = remainder.FloatString(utils.InterestDecimals)
+	// The payout is recorded under the ID of the transaction
+	_, err = recordTransaction(ctx, model.Transaction{
+		ID:                 fmt.Sprintf("%s-interest", ctx.GetStub().GetTxID()),
+		Type:               model.TransactionInterest,
+		DestinationAccount: account.ID,
+		Amount:             credit,
+		Currency:           account.Currency,
+		ConvertedAmount:    credit,
+		ConvertedCurrency:  account.Currency,
+		Rate:               utils.SameCurrencyRate,
+	})
+	return err
+}
+
+func payOut(ctx contractapi.TransactionContextInterface, account *model.BankAccount, payoutAccountID string) error {
+	if payoutAccountID == account.ID {
+		return fmt.Errorf("the payout account has to differ from the account being closed")
+	}
+
+	payoutAccount, err := readBankAccount(ctx, payoutAccountID)
+	if err != nil {
+		return err
+	}
+	if err := assertAccountActive(payoutAccount); err != nil {
+		return err
+	}
+
+	currency, err := readCurrency(ctx, account.Currency)
+	if err != nil {
+		return err
+	}
+	payoutCurrency, err := readCurrency(ctx, payoutAccount.Currency)
+	if err != nil {
+		return err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	appliedRates, rate, err := effectiveExchangeRate(ctx, account.Currency, payoutAccount.Currency, timestamp.AsTime())
+	if err != nil {
+		return err
+	}
+	convertedAmount, err := utils.ConvertAmount(account.Balance, *currency, *payoutCurrency, rate)
+	if err != nil {
+		return err
+	}
+
+	if err := accrueInterest(ctx, payoutAccount, timestamp.AsTime()); err != nil {
+		return err
+	}
+
+	amount := account.Balance
+	account.Balance = 0
+	payoutAccount.Balance += convertedAmount
+	payoutAccount.LastOperation = model.OperationTransferIn
+	if err := utils.PutDataToState(ctx, payoutAccount, payoutAccount.ID); err != nil {
+		return err
+	}
+
+	_, err = recordTransaction(ctx, model.Transaction{
+		Type:               model.TransactionPayout,
+		SourceAccount:      account.ID,
+		DestinationAccount: payoutAccount.ID,
+		Amount:             amount,
+		Currency:           account.Currency,
+		ConvertedAmount:    convertedAmount,
+		ConvertedCurrency:  payoutAccount.Currency,
+		Rate:               rate.FloatString(utils.RateDecimals),
+		RateIDs:            exchangeRateIDs(appliedRates),
+	})
+	return err
+}
+
+func putAccountStatus(ctx contractapi.TransactionContextInterface, account *model.BankAccount) error {
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	return emitEvent(ctx, model.EventAccountStatusChanged, model.AccountStatusChangedEvent{
+		TxID:      ctx.GetStub().GetTxID(),
+		Account:   account.ID,
+		UserID:    account.UserID,
+		Status:    account.Status,
+		Timestamp: timestamp.AsTime(),
+	})
+}
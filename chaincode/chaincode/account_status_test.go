@@ -0,0 +1,172 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFreezeAndUnfreezeAccount(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	state := map[string][]byte{
+		"a1": []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"bank_id":"b1"}`),
+		"b1": []byte(`{"docType":"bank","ID":"b1","name":"UniCredit","msp_id":"Org1MSP"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	account, err := smartContract.FreezeAccount(transactionContext, "a1")
+	require.NoError(t, err)
+	require.Equal(t, model.AccountFrozen, account.Status)
+	require.Equal(t, model.OperationFreeze, account.LastOperation)
+
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "AccountStatusChanged", name)
+	require.JSONEq(t, `{"tx_id":"","account":"a1","user_id":"u1","status":"FROZEN","timestamp":"2024-02-01T10:00:00Z"}`, string(payload))
+
+	// Test Case: Already frozen
+	state["a1"] = []byte(`{"ID":"a1","user_id":"u1","status":"FROZEN","bank_id":"b1"}`)
+	_, err = smartContract.FreezeAccount(transactionContext, "a1")
+	require.EqualError(t, err, "bank account a1 is frozen")
+
+	// Test Case: Administrator of another organization
+	transactionContext.GetClientIdentityReturns(organizationAdminIdentity("Org2MSP"))
+	_, err = smartContract.UnfreezeAccount(transactionContext, "a1")
+	require.EqualError(t, err, "only administrators of the organization running bank b1 are allowed to perform this action")
+
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	account, err = smartContract.UnfreezeAccount(transactionContext, "a1")
+	require.NoError(t, err)
+	require.Equal(t, model.AccountActive, account.Status)
+
+	// Test Case: Not frozen
+	state["a1"] = []byte(`{"ID":"a1","user_id":"u1","status":"ACTIVE","bank_id":"b1"}`)
+	_, err = smartContract.UnfreezeAccount(transactionContext, "a1")
+	require.EqualError(t, err, "bank account a1 is not frozen")
+
+	require.Equal(t, 2, chaincodeStub.PutStateCallCount())
+}
+
+func TestMoneyOperations_RejectInactiveAccounts(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"status":"FROZEN"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	_, err := smartContract.MoneyWithdrawal(transactionContext, "a1", "10")
+	require.EqualError(t, err, "bank account a1 is frozen")
+
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":0,"status":"CLOSED"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"u1"}`), nil)
+	_, err = smartContract.MoneyDepositToAccount(transactionContext, "a1", "10")
+	require.EqualError(t, err, "bank account a1 is closed")
+
+	// Test Case: Transfer to a frozen account
+	chaincodeStub.GetStateReturnsOnCall(4, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(5, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(6, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(7, []byte(`{"ID":"a2","user_id":"u2","currency":"EUR","balance":0,"status":"FROZEN"}`), nil)
+	_, err = smartContract.TransferMoney(transactionContext, "a1", "a2", "10")
+	require.EqualError(t, err, "bank account a2 is frozen")
+
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestCloseAccount(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"a1":           []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"bank_id":"b1"}`),
+		"a13":          []byte(`{"ID":"a13","user_id":"u1","currency":"EUR","balance":500,"bank_id":"b1"}`),
+		"b1":           []byte(`{"docType":"bank","ID":"b1","name":"UniCredit","msp_id":"Org1MSP"}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	// Test Case: Money left without a payout account
+	_, err := smartContract.CloseAccount(transactionContext, "a1", "")
+	require.EqualError(t, err, "bank account a1 still holds money, a payout account is required to close it")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+
+	// Test Case: Balance paid out to another account
+	state["a1"] = []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"status":"FROZEN","bank_id":"b1"}`)
+
+	account, err := smartContract.CloseAccount(transactionContext, "a1", "a13")
+	require.NoError(t, err)
+	require.Equal(t, model.AccountClosed, account.Status)
+	require.Equal(t, int64(0), account.Balance)
+
+	require.Equal(t, 3, chaincodeStub.PutStateCallCount())
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "a13", key)
+	var payoutAccount model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &payoutAccount))
+	require.Equal(t, int64(10500), payoutAccount.Balance)
+
+	_, value = chaincodeStub.PutStateArgsForCall(1)
+	var transaction model.Transaction
+	require.NoError(t, json.Unmarshal(value, &transaction))
+	require.Equal(t, model.TransactionPayout, transaction.Type)
+	require.Equal(t, int64(10000), transaction.ConvertedAmount)
+
+	key, _ = chaincodeStub.PutStateArgsForCall(2)
+	require.Equal(t, "a1", key)
+
+	// Test Case: Savings interest earned up to the close is paid out with the balance
+	state["interestRate~b1~SAVINGS"] = []byte(`{"docType":"interestRate","bank_id":"b1","product":"SAVINGS","rate":"0.0365"}`)
+	state["a2"] = []byte(`{"ID":"a2","user_id":"u1","currency":"EUR","balance":100000,"bank_id":"b1","product":"SAVINGS","accrued_interest":"0.5","interest_accrued_at":"2024-01-22T10:00:00Z"}`)
+
+	account, err = smartContract.CloseAccount(transactionContext, "a2", "a13")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), account.Balance)
+	require.Equal(t, "0.50000000", account.AccruedInterest)
+
+	require.Equal(t, 7, chaincodeStub.PutStateCallCount())
+	key, value = chaincodeStub.PutStateArgsForCall(3)
+	require.Equal(t, "tx1-interest", key)
+	require.NoError(t, json.Unmarshal(value, &transaction))
+	require.Equal(t, model.TransactionInterest, transaction.Type)
+	require.Equal(t, int64(100), transaction.Amount)
+
+	_, value = chaincodeStub.PutStateArgsForCall(5)
+	require.NoError(t, json.Unmarshal(value, &transaction))
+	require.Equal(t, model.TransactionPayout, transaction.Type)
+	require.Equal(t, int64(100100), transaction.Amount)
+
+	// Test Case: Already closed
+	state["a1"] = []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":0,"status":"CLOSED","bank_id":"b1"}`)
+	_, err = smartContract.CloseAccount(transactionContext, "a1", "")
+	require.EqualError(t, err, "bank account a1 is closed")
+}
@@ -0,0 +1,218 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// The oldest bank still in business was founded in 1472
+const minBankFoundingYear = 1400
+
+// PIB, the Serbian tax identification number, always has nine digits
+const (
+	minPIB = 100000000
+	maxPIB = 999999999
+)
+
+// CreateBank registers a bank run by the organization of the administrator
+// creating it, only that organization's administrators may manage it.
+func (s *SmartContract) CreateBank(ctx contractapi.TransactionContextInterface, id, name, headquarters string, since, pib int) (*model.Bank, error) {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !identity.Admin {
+		return nil, fmt.Errorf("only administrators are allowed to perform this action")
+	}
+
+	bank := model.Bank{
+		DocType:      model.BankDocType,
+		ID:           strings.TrimSpace(id),
+		Name:         strings.TrimSpace(name),
+		Headquarters: strings.TrimSpace(headquarters),
+		Since:        since,
+		PIB:          pib,
+		MSPID:        identity.MSPID,
+	}
+	if bank.ID == "" {
+		return nil, fmt.Errorf("bank ID is required")
+	}
+	if err := validateBank(ctx, bank); err != nil {
+		return nil, err
+	}
+	if pib < minPIB || pib > maxPIB {
+		return nil, fmt.Errorf("PIB must be a number with 9 digits")
+	}
+
+	exists, err := s.AssetExists(ctx, bank.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("an asset with id %s already exists", bank.ID)
+	}
+
+	owner, err := bankByPIB(ctx, pib)
+	if err != nil {
+		return nil, err
+	}
+	if owner != nil {
+		return nil, fmt.Errorf("PIB %d is already used by bank %s", pib, owner.ID)
+	}
+
+	if err := utils.PutDataToState(ctx, bank, bank.ID); err != nil {
+		return nil, err
+	}
+	return &bank, nil
+}
+
+// UpdateBank changes the bank's name, headquarters and founding year. The PIB
+// identifies the bank with the tax authorities and cannot be changed.
+func (s *SmartContract) UpdateBank(ctx contractapi.TransactionContextInterface, id, name, headquarters string, since int) (*model.Bank, error) {
+	if err := assertBankAdmin(ctx, id); err != nil {
+		return nil, err
+	}
+
+	bank, err := s.ReadBank(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := bankOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	bank.DocType = model.BankDocType
+	bank.MSPID = mspID
+	bank.Name = strings.TrimSpace(name)
+	bank.Headquarters = strings.TrimSpace(headquarters)
+	bank.Since = since
+	if err := validateBank(ctx, *bank); err != nil {
+		return nil, err
+	}
+
+	if err := utils.PutDataToState(ctx, bank, bank.ID); err != nil {
+		return nil, err
+	}
+	return bank, nil
+}
+
+func (s *SmartContract) ReadBank(ctx contractapi.TransactionContextInterface, id string) (*model.Bank, error) {
+	return readBank(ctx, id)
+}
+
+func readBank(ctx contractapi.TransactionContextInterface, id string) (*model.Bank, error) {
+	bankJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bankJSON == nil {
+		return nil, fmt.Errorf("the bank with id %s does not exist", id)
+	}
+
+	var bank model.Bank
+	if err := json.Unmarshal(bankJSON, &bank); err != nil {
+		return nil, err
+	}
+	// Banks seeded before the document type was introduced get it from MigrateBankReferences
+	if bank.DocType != model.BankDocType {
+		return nil, fmt.Errorf("the bank with id %s does not exist", id)
+	}
+
+	return &bank, nil
+}
+
+func (s *SmartContract) ListBanks(ctx contractapi.TransactionContextInterface) ([]model.Bank, error) {
+	return queryBanks(ctx, map[string]interface{}{"docType": model.BankDocType})
+}
+
+func validateBank(ctx contractapi.TransactionContextInterface, bank model.Bank) error {
+	if bank.Name == "" {
+		return fmt.Errorf("bank name is required")
+	}
+	if bank.Headquarters == "" {
+		return fmt.Errorf("bank headquarters are required")
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if currentYear := timestamp.AsTime().Year(); bank.Since < minBankFoundingYear || bank.Since > currentYear {
+		return fmt.Errorf("founding year must be between %d and %d", minBankFoundingYear, currentYear)
+	}
+
+	return nil
+}
+
+func bankByPIB(ctx contractapi.TransactionContextInterface, pib int) (*model.Bank, error) {
+	banks, err := queryBanks(ctx, map[string]interface{}{"pib": pib})
+	if err != nil {
+		return nil, err
+	}
+	if len(banks) == 0 {
+		return nil, nil
+	}
+	return &banks[0], nil
+}
+
+func queryBanks(ctx contractapi.TransactionContextInterface, selector map[string]interface{}) ([]model.Bank, error) {
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %v", err)
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	var banks []model.Bank
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var bank model.Bank
+		if err := json.Unmarshal(queryResult.Value, &bank); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bank: %v", err)
+		}
+		banks = append(banks, bank)
+	}
+
+	return banks, nil
+}
+
+// joinBanks attaches the referenced bank to each account, reading every bank
+// only once. Accounts without a bank reference predate the migration and are
+// returned as they are.
+func joinBanks(ctx contractapi.TransactionContextInterface, accounts []model.BankAccount) ([]model.BankAccountDetails, error) {
+	banks := map[string]*model.Bank{}
+
+	details := make([]model.BankAccountDetails, 0, len(accounts))
+	for _, account := range accounts {
+		accountDetails := model.BankAccountDetails{BankAccount: account}
+		if account.BankID != "" {
+			bank, ok := banks[account.BankID]
+			if !ok {
+				var err error
+				bank, err = readBank(ctx, account.BankID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read bank of account %s: %v", account.ID, err)
+				}
+				banks[account.BankID] = bank
+			}
+			accountDetails.Bank = *bank
+		}
+		details = append(details, accountDetails)
+	}
+
+	return details, nil
+}
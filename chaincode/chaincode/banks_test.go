@@ -0,0 +1,195 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCreateBank(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetQueryResultReturns(&mocks.StateQueryIterator{}, nil)
+
+	bank, err := smartContract.CreateBank(transactionContext, "b5", "Erste Bank", "Novi Sad, Serbia", 1864, 101626723)
+	require.NoError(t, err)
+	require.Equal(t, &model.Bank{
+		DocType:      model.BankDocType,
+		ID:           "b5",
+		Name:         "Erste Bank",
+		Headquarters: "Novi Sad, Serbia",
+		Since:        1864,
+		PIB:          101626723,
+		MSPID:        "Org1MSP",
+	}, bank)
+
+	require.JSONEq(t, `{"selector":{"pib":101626723}}`, chaincodeStub.GetQueryResultArgsForCall(0))
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "b5", key)
+	var stored model.Bank
+	require.NoError(t, json.Unmarshal(value, &stored))
+	require.Equal(t, *bank, stored)
+}
+
+func TestCreateBank_Invalid(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)), nil)
+
+	_, err := smartContract.CreateBank(transactionContext, "b5", "Erste Bank", "Novi Sad, Serbia", 2025, 101626723)
+	require.EqualError(t, err, "founding year must be between 1400 and 2024")
+
+	_, err = smartContract.CreateBank(transactionContext, "b5", "Erste Bank", "Novi Sad, Serbia", 864, 101626723)
+	require.EqualError(t, err, "founding year must be between 1400 and 2024")
+
+	_, err = smartContract.CreateBank(transactionContext, "b5", "Erste Bank", "Novi Sad, Serbia", 1864, 1016267)
+	require.EqualError(t, err, "PIB must be a number with 9 digits")
+
+	_, err = smartContract.CreateBank(transactionContext, "b5", " ", "Novi Sad, Serbia", 1864, 101626723)
+	require.EqualError(t, err, "bank name is required")
+
+	// Test Case: ID taken
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"b1"}`), nil)
+	_, err = smartContract.CreateBank(transactionContext, "b1", "Erste Bank", "Novi Sad, Serbia", 1864, 101626723)
+	require.EqualError(t, err, "an asset with id b1 already exists")
+
+	// Test Case: PIB taken
+	banks := &mocks.StateQueryIterator{}
+	banks.HasNextReturnsOnCall(0, true)
+	banks.NextReturns(&queryresult.KV{Value: []byte(`{"docType":"bank","ID":"b2","pib":537891234}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(banks, nil)
+	_, err = smartContract.CreateBank(transactionContext, "b5", "Erste Bank", "Novi Sad, Serbia", 1864, 537891234)
+	require.EqualError(t, err, "PIB 537891234 is already used by bank b2")
+
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestUpdateBank(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(organizationAdminIdentity("Org2MSP"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)), nil)
+	state := map[string][]byte{
+		// Seeded before organizations were recorded
+		"b2":  []byte(`{"docType":"bank","ID":"b2","name":"Raiffeisen Bank","headquarters":"Vienna, Austria","since":1927,"pib":537891234}`),
+		"tx1": []byte(`{"docType":"transaction","ID":"tx1"}`),
+		"a1":  []byte(`{"ID":"a1","user_id":"u1","balance":0}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	bank, err := smartContract.UpdateBank(transactionContext, "b2", "Raiffeisen Bank International", "Belgrade, Serbia", 1927)
+	require.NoError(t, err)
+	require.Equal(t, &model.Bank{
+		DocType:      model.BankDocType,
+		ID:           "b2",
+		Name:         "Raiffeisen Bank International",
+		Headquarters: "Belgrade, Serbia",
+		Since:        1927,
+		PIB:          537891234,
+		MSPID:        "Org2MSP",
+	}, bank)
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+
+	// Test Case: Key holds another asset
+	_, err = smartContract.UpdateBank(transactionContext, "tx1", "Raiffeisen Bank", "Vienna, Austria", 1927)
+	require.EqualError(t, err, "the bank with id tx1 does not exist")
+
+	// Test Case: Key holds an asset without a document type
+	_, err = smartContract.UpdateBank(transactionContext, "a1", "Raiffeisen Bank", "Vienna, Austria", 1927)
+	require.EqualError(t, err, "the bank with id a1 does not exist")
+
+	// Test Case: Administrator of another organization
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	_, err = smartContract.UpdateBank(transactionContext, "b2", "Raiffeisen Bank", "Vienna, Austria", 1927)
+	require.EqualError(t, err, "only administrators of the organization running bank b2 are allowed to perform this action")
+
+	// Test Case: Not an administrator
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	_, err = smartContract.UpdateBank(transactionContext, "b2", "Raiffeisen Bank", "Vienna, Austria", 1927)
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
+
+func TestListBanks(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.HasNextReturnsOnCall(1, true)
+	iterator.NextReturnsOnCall(0, &queryresult.KV{Value: []byte(`{"docType":"bank","ID":"b1"}`)}, nil)
+	iterator.NextReturnsOnCall(1, &queryresult.KV{Value: []byte(`{"docType":"bank","ID":"b2"}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	banks, err := smartContract.ListBanks(transactionContext)
+	require.NoError(t, err)
+	require.Len(t, banks, 2)
+	require.Equal(t, "b2", banks[1].ID)
+	require.JSONEq(t, `{"selector":{"docType":"bank"}}`, chaincodeStub.GetQueryResultArgsForCall(0))
+}
+
+func TestGetAccountsByBankDesiredCurrencyAndBalance_JoinsBank(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"b1":           []byte(`{"docType":"bank","ID":"b1","name":"Banca Intesa","msp_id":"Org1MSP"}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.HasNextReturnsOnCall(1, true)
+	iterator.NextReturnsOnCall(0, &queryresult.KV{Value: []byte(`{"ID":"a5","currency":"EUR","balance":120000,"bank_id":"b1"}`)}, nil)
+	iterator.NextReturnsOnCall(1, &queryresult.KV{Value: []byte(`{"ID":"a17","currency":"EUR","balance":95000,"bank_id":"b1"}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	accounts, err := smartContract.GetAccountsByBankDesiredCurrencyAndBalance(transactionContext, "b1", "EUR", "500")
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	require.Equal(t, "b1", accounts[0].BankID)
+	require.Equal(t, "Banca Intesa", accounts[0].Bank.Name)
+	require.Equal(t, "Banca Intesa", accounts[1].Bank.Name)
+
+	// The bank is read for the access check and once for all of its accounts
+	require.Equal(t, 3, chaincodeStub.GetStateCallCount())
+	require.JSONEq(t, `{"selector":{"bank_id":"b1","currency":"EUR","balance":{"$gte":50000}}}`, chaincodeStub.GetQueryResultArgsForCall(0))
+}
@@ -0,0 +1,259 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Cards are valid until the end of the month this many years after issuing
+const cardValidityYears = 4
+
+// cardNumbersTransientKey is where the client passes the numbers of the cards a
+// transaction issues, generated off-chain, so they are never part of the
+// transaction. Only their last four digits are kept on the ledger.
+const cardNumbersTransientKey = "cards"
+
+// newCard prepares a card for the account. The card ID is derived from the
+// transaction ID, sequence tells apart cards issued in the same transaction.
+// Cards without a number are the ones seeded or migrated from older ledgers.
+func newCard(ctx contractapi.TransactionContextInterface, account *model.BankAccount, network model.CardNetwork, pan string, sequence int) (*model.Card, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	issuedAt := timestamp.AsTime()
+
+	seed := sha256.Sum256([]byte(ctx.GetStub().GetTxID() + "/" + account.ID + "/" + strconv.Itoa(sequence)))
+
+	card := model.Card{
+		DocType:   model.CardDocType,
+		ID:        "c" + hex.EncodeToString(seed[:8]),
+		AccountID: account.ID,
+		UserID:    account.UserID,
+		Network:   network,
+		Expiry:    issuedAt.AddDate(cardValidityYears, 0, 0).Format("2006-01"),
+		Status:    model.CardActive,
+		Currency:  account.Currency,
+		IssuedAt:  issuedAt,
+	}
+	if pan != "" {
+		card.MaskedPAN = utils.MaskPAN(pan)
+	}
+	return &card, nil
+}
+
+func issueCard(ctx contractapi.TransactionContextInterface, account *model.BankAccount, network model.CardNetwork, pan string, sequence int) (*model.Card, error) {
+	card, err := newCard(ctx, account, network, pan, sequence)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.PutDataToState(ctx, card, card.ID); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// transientCardNumbers returns the numbers passed for cards on the networks, in
+// the same order.
+func transientCardNumbers(ctx contractapi.TransactionContextInterface, networks []model.CardNetwork) ([]string, error) {
+	if len(networks) == 0 {
+		return nil, nil
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient map: %v", err)
+	}
+
+	var numbers []string
+	if numbersJSON, ok := transient[cardNumbersTransientKey]; ok {
+		if err := json.Unmarshal(numbersJSON, &numbers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal card numbers: %v", err)
+		}
+	}
+	if len(numbers) != len(networks) {
+		return nil, fmt.Errorf("the numbers of %d cards have to be passed in the transient map under %q", len(networks), cardNumbersTransientKey)
+	}
+
+	for i, number := range numbers {
+		if err := utils.ValidatePAN(networks[i], number); err != nil {
+			return nil, err
+		}
+	}
+	return numbers, nil
+}
+
+func readCard(ctx contractapi.TransactionContextInterface, id string) (*model.Card, error) {
+	cardJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if cardJSON == nil {
+		return nil, fmt.Errorf("the card with id %s does not exist", id)
+	}
+
+	var card model.Card
+	if err := json.Unmarshal(cardJSON, &card); err != nil {
+		return nil, err
+	}
+	if card.DocType != model.CardDocType {
+		return nil, fmt.Errorf("the card with id %s does not exist", id)
+	}
+
+	return &card, nil
+}
+
+// readOwnCard returns the card together with its account, as long as the
+// caller owns the account.
+func readOwnCard(ctx contractapi.TransactionContextInterface, id string) (*model.Card, *model.BankAccount, error) {
+	card, err := readCard(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	account, err := readBankAccount(ctx, card.AccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return nil, nil, fmt.Errorf("the card with id %s does not exist", id)
+	}
+
+	return card, account, nil
+}
+
+func (s *SmartContract) ReadCard(ctx contractapi.TransactionContextInterface, id string) (*model.Card, error) {
+	card, _, err := readOwnCard(ctx, id)
+	return card, err
+}
+
+// IssueCard issues a card with the number passed in the transient map.
+func (s *SmartContract) IssueCard(ctx contractapi.TransactionContextInterface, accountID, network string) (*model.Card, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+
+	cardNetwork, err := utils.ParseCardNetwork(network)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers, err := transientCardNumbers(ctx, []model.CardNetwork{cardNetwork})
+	if err != nil {
+		return nil, err
+	}
+
+	return issueCard(ctx, account, cardNetwork, numbers[0], 0)
+}
+
+// BlockCard stops a lost or stolen card for good, a replacement can be issued
+// with ReplaceCard.
+func (s *SmartContract) BlockCard(ctx contractapi.TransactionContextInterface, id string) (*model.Card, error) {
+	card, _, err := readOwnCard(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if card.Status != model.CardActive {
+		return nil, fmt.Errorf("card %s is %s", id, strings.ToLower(string(card.Status)))
+	}
+
+	card.Status = model.CardBlocked
+	if err := utils.PutDataToState(ctx, card, card.ID); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// ReplaceCard issues a new card on the same network as an active or blocked
+// card, which stops being usable. The new number is passed in the transient map.
+func (s *SmartContract) ReplaceCard(ctx contractapi.TransactionContextInterface, id string) (*model.Card, error) {
+	card, account, err := readOwnCard(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if card.Status == model.CardReplaced {
+		return nil, fmt.Errorf("card %s is already replaced by card %s", id, card.ReplacedBy)
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+
+	numbers, err := transientCardNumbers(ctx, []model.CardNetwork{card.Network})
+	if err != nil {
+		return nil, err
+	}
+
+	replacement, err := newCard(ctx, account, card.Network, numbers[0], 0)
+	if err != nil {
+		return nil, err
+	}
+	replacement.Replaces = card.ID
+	if err := utils.PutDataToState(ctx, replacement, replacement.ID); err != nil {
+		return nil, err
+	}
+
+	card.Status = model.CardReplaced
+	card.ReplacedBy = replacement.ID
+	if err := utils.PutDataToState(ctx, card, card.ID); err != nil {
+		return nil, err
+	}
+
+	return replacement, nil
+}
+
+func (s *SmartContract) ListCards(ctx contractapi.TransactionContextInterface, accountID string) ([]model.Card, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return nil, err
+	}
+
+	queryJSON, err := json.Marshal(map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType":    model.CardDocType,
+			"account_id": accountID,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %v", err)
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	var cards []model.Card
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var card model.Card
+		if err := json.Unmarshal(queryResult.Value, &card); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal card: %v", err)
+		}
+		cards = append(cards, card)
+	}
+
+	return cards, nil
+}
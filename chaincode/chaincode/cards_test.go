@@ -0,0 +1,199 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestIssueCard(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	issuedAt := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(issuedAt), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetTransientReturns(map[string][]byte{"cards": []byte(`["4111111111111111"]`)}, nil)
+
+	card, err := smartContract.IssueCard(transactionContext, "a1", "Visa")
+	require.NoError(t, err)
+	require.Equal(t, "************1111", card.MaskedPAN)
+	require.Equal(t, model.CardVisa, card.Network)
+	require.Equal(t, "2028-02", card.Expiry)
+	require.Equal(t, model.CardActive, card.Status)
+	require.Equal(t, model.EUR, card.Currency)
+	require.Equal(t, "a1", card.AccountID)
+	require.Equal(t, issuedAt, card.IssuedAt)
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, card.ID, key)
+	var stored model.Card
+	require.NoError(t, json.Unmarshal(value, &stored))
+	require.Equal(t, *card, stored)
+	require.NotContains(t, string(value), "4111111111111111")
+
+	// Test Case: Another user's account
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"ID":"a2","user_id":"u2","currency":"EUR"}`), nil)
+	_, err = smartContract.IssueCard(transactionContext, "a2", "Visa")
+	require.EqualError(t, err, "bank account with ID a2 not found for user u1")
+
+	// Test Case: Unknown network
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(4, []byte(`{"ID":"u1"}`), nil)
+	_, err = smartContract.IssueCard(transactionContext, "a1", "Diners")
+	require.EqualError(t, err, "unsupported card network: Diners")
+
+	// Test Case: The number belongs to another network
+	chaincodeStub.GetStateReturnsOnCall(5, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(6, []byte(`{"ID":"u1"}`), nil)
+	_, err = smartContract.IssueCard(transactionContext, "a1", "Amex")
+	require.EqualError(t, err, "invalid AMEX card number")
+
+	// Test Case: No card number
+	chaincodeStub.GetStateReturnsOnCall(7, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(8, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetTransientReturns(map[string][]byte{}, nil)
+	_, err = smartContract.IssueCard(transactionContext, "a1", "Visa")
+	require.EqualError(t, err, `the numbers of 1 cards have to be passed in the transient map under "cards"`)
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
+
+func TestBlockCard(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"docType":"card","ID":"c1","account_id":"a1","status":"ACTIVE"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"ID":"u1"}`), nil)
+
+	card, err := smartContract.BlockCard(transactionContext, "c1")
+	require.NoError(t, err)
+	require.Equal(t, model.CardBlocked, card.Status)
+
+	// Test Case: Already blocked
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"docType":"card","ID":"c1","account_id":"a1","status":"BLOCKED"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(4, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(5, []byte(`{"ID":"u1"}`), nil)
+	_, err = smartContract.BlockCard(transactionContext, "c1")
+	require.EqualError(t, err, "card c1 is blocked")
+
+	// Test Case: Card of another user
+	chaincodeStub.GetStateReturnsOnCall(6, []byte(`{"docType":"card","ID":"c2","account_id":"a2","status":"ACTIVE"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(7, []byte(`{"ID":"a2","user_id":"u2","currency":"EUR"}`), nil)
+	_, err = smartContract.BlockCard(transactionContext, "c2")
+	require.EqualError(t, err, "the card with id c2 does not exist")
+
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
+
+func TestReplaceCard(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx2")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"docType":"card","ID":"c1","account_id":"a1","network":"AMEX","status":"BLOCKED"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetTransientReturns(map[string][]byte{"cards": []byte(`["378282246310005"]`)}, nil)
+
+	replacement, err := smartContract.ReplaceCard(transactionContext, "c1")
+	require.NoError(t, err)
+	require.Equal(t, model.CardAmex, replacement.Network)
+	require.Equal(t, "c1", replacement.Replaces)
+	require.Equal(t, "***********0005", replacement.MaskedPAN)
+
+	require.Equal(t, 2, chaincodeStub.PutStateCallCount())
+	key, value := chaincodeStub.PutStateArgsForCall(1)
+	require.Equal(t, "c1", key)
+	var replaced model.Card
+	require.NoError(t, json.Unmarshal(value, &replaced))
+	require.Equal(t, model.CardReplaced, replaced.Status)
+	require.Equal(t, replacement.ID, replaced.ReplacedBy)
+
+	// Test Case: Replaced already
+	chaincodeStub.GetStateReturnsOnCall(3, value, nil)
+	chaincodeStub.GetStateReturnsOnCall(4, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(5, []byte(`{"ID":"u1"}`), nil)
+	_, err = smartContract.ReplaceCard(transactionContext, "c1")
+	require.EqualError(t, err, "card c1 is already replaced by card "+replacement.ID)
+}
+
+func TestListCards(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.NextReturns(&queryresult.KV{Value: []byte(`{"docType":"card","ID":"c1","account_id":"a1"}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	cards, err := smartContract.ListCards(transactionContext, "a1")
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+	require.JSONEq(t, `{"selector":{"docType":"card","account_id":"a1"}}`, chaincodeStub.GetQueryResultArgsForCall(0))
+}
+
+func TestCreateBankAccount_IssuesCards(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"docType":"bank","ID":"b1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(3, eurDefinition, nil)
+	chaincodeStub.GetTransientReturns(map[string][]byte{"cards": []byte(`["4111111111111111","9891000000000011"]`)}, nil)
+
+	err := smartContract.CreateBankAccount(transactionContext, "a1", "EUR", "Visa, Dina", "b1", "u1", "")
+	require.NoError(t, err)
+
+	require.Equal(t, 3, chaincodeStub.PutStateCallCount())
+	_, first := chaincodeStub.PutStateArgsForCall(1)
+	_, second := chaincodeStub.PutStateArgsForCall(2)
+	var visa, dina model.Card
+	require.NoError(t, json.Unmarshal(first, &visa))
+	require.NoError(t, json.Unmarshal(second, &dina))
+	require.Equal(t, model.CardVisa, visa.Network)
+	require.Equal(t, model.CardDina, dina.Network)
+	require.NotEqual(t, visa.ID, dina.ID)
+	require.Equal(t, "************0011", dina.MaskedPAN)
+
+	// Test Case: Unknown network fails before anything is written
+	chaincodeStub.GetStateReturnsOnCall(5, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(6, []byte(`{"docType":"bank","ID":"b1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(7, eurDefinition, nil)
+	err = smartContract.CreateBankAccount(transactionContext, "a2", "EUR", "Diners", "b1", "u1", "")
+	require.EqualError(t, err, "unsupported card network: Diners")
+	require.Equal(t, 3, chaincodeStub.PutStateCallCount())
+}
@@ -0,0 +1,228 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	currencyObjectType         = "currency"
+	currencySettingsObjectType = "currencySettings"
+)
+
+// ISO 4217 knows no currency with more than four minor unit digits
+const maxMinorUnits = 4
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func currencyKey(ctx contractapi.TransactionContextInterface, code model.Currency) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(currencyObjectType, []string{string(code)})
+}
+
+func putCurrency(ctx contractapi.TransactionContextInterface, currency model.CurrencyDefinition) error {
+	key, err := currencyKey(ctx, currency.Code)
+	if err != nil {
+		return fmt.Errorf("failed to create currency key: %v", err)
+	}
+
+	currency.DocType = model.CurrencyDocType
+	return utils.PutDataToState(ctx, currency, key)
+}
+
+func readCurrency(ctx contractapi.TransactionContextInterface, code model.Currency) (*model.CurrencyDefinition, error) {
+	key, err := currencyKey(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create currency key: %v", err)
+	}
+
+	currencyJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if currencyJSON == nil {
+		return nil, fmt.Errorf("currency %s is not registered", code)
+	}
+
+	var currency model.CurrencyDefinition
+	if err := json.Unmarshal(currencyJSON, &currency); err != nil {
+		return nil, err
+	}
+	if currency.DocType != model.CurrencyDocType {
+		return nil, fmt.Errorf("currency %s is not registered", code)
+	}
+
+	return &currency, nil
+}
+
+func readEnabledCurrency(ctx contractapi.TransactionContextInterface, code model.Currency) (*model.CurrencyDefinition, error) {
+	currency, err := readCurrency(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if !currency.Enabled {
+		return nil, fmt.Errorf("currency %s is not enabled", code)
+	}
+	return currency, nil
+}
+
+func normalizeCurrencyCode(code string) model.Currency {
+	return model.Currency(strings.ToUpper(strings.TrimSpace(code)))
+}
+
+func (s *SmartContract) RegisterCurrency(ctx contractapi.TransactionContextInterface, code, name string, minorUnits int, rounding string) (*model.CurrencyDefinition, error) {
+	if err := assertAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	currencyCode := normalizeCurrencyCode(code)
+	if !currencyCodePattern.MatchString(string(currencyCode)) {
+		return nil, fmt.Errorf("invalid ISO 4217 currency code: %s", code)
+	}
+	if minorUnits < 0 || minorUnits > maxMinorUnits {
+		return nil, fmt.Errorf("minor units must be between 0 and %d", maxMinorUnits)
+	}
+
+	roundingMode := model.RoundingMode(strings.ToUpper(rounding))
+	switch roundingMode {
+	case "":
+		roundingMode = model.RoundHalfEven
+	case model.RoundHalfUp, model.RoundHalfEven:
+	default:
+		return nil, fmt.Errorf("invalid rounding mode: %s", rounding)
+	}
+
+	key, err := currencyKey(ctx, currencyCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create currency key: %v", err)
+	}
+	exists, err := s.AssetExists(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("currency %s is already registered", currencyCode)
+	}
+
+	currency := model.CurrencyDefinition{
+		DocType:    model.CurrencyDocType,
+		Code:       currencyCode,
+		Name:       name,
+		MinorUnits: minorUnits,
+		Rounding:   roundingMode,
+		Enabled:    true,
+	}
+	if err := putCurrency(ctx, currency); err != nil {
+		return nil, err
+	}
+
+	return &currency, nil
+}
+
+// SetCurrencyEnabled controls whether new accounts and exchange rates can use
+// the currency, existing accounts keep working.
+func (s *SmartContract) SetCurrencyEnabled(ctx contractapi.TransactionContextInterface, code string, enabled bool) (*model.CurrencyDefinition, error) {
+	if err := assertAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	currency, err := readCurrency(ctx, normalizeCurrencyCode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	currency.Enabled = enabled
+	if err := putCurrency(ctx, *currency); err != nil {
+		return nil, err
+	}
+
+	return currency, nil
+}
+
+func (s *SmartContract) ReadCurrency(ctx contractapi.TransactionContextInterface, code string) (*model.CurrencyDefinition, error) {
+	return readCurrency(ctx, normalizeCurrencyCode(code))
+}
+
+func (s *SmartContract) GetCurrencies(ctx contractapi.TransactionContextInterface) ([]model.CurrencyDefinition, error) {
+	results, err := ctx.GetStub().GetStateByPartialCompositeKey(currencyObjectType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read currencies: %v", err)
+	}
+	defer results.Close()
+
+	var currencies []model.CurrencyDefinition
+	for results.HasNext() {
+		result, err := results.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate currencies: %v", err)
+		}
+
+		var currency model.CurrencyDefinition
+		if err := json.Unmarshal(result.Value, &currency); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal currency: %v", err)
+		}
+		currencies = append(currencies, currency)
+	}
+
+	return currencies, nil
+}
+
+func currencySettingsKey(ctx contractapi.TransactionContextInterface) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(currencySettingsObjectType, []string{})
+}
+
+func putCurrencySettings(ctx contractapi.TransactionContextInterface, settings model.CurrencySettings) error {
+	key, err := currencySettingsKey(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create currency settings key: %v", err)
+	}
+	return utils.PutDataToState(ctx, settings, key)
+}
+
+func (s *SmartContract) GetCurrencySettings(ctx contractapi.TransactionContextInterface) (*model.CurrencySettings, error) {
+	return readCurrencySettings(ctx)
+}
+
+func readCurrencySettings(ctx contractapi.TransactionContextInterface) (*model.CurrencySettings, error) {
+	key, err := currencySettingsKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create currency settings key: %v", err)
+	}
+
+	settingsJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	var settings model.CurrencySettings
+	if settingsJSON != nil {
+		if err := json.Unmarshal(settingsJSON, &settings); err != nil {
+			return nil, err
+		}
+	}
+	return &settings, nil
+}
+
+// SetBaseCurrency picks the currency used to convert between two currencies
+// that have no exchange rate published for each other.
+func (s *SmartContract) SetBaseCurrency(ctx contractapi.TransactionContextInterface, code string) (*model.CurrencySettings, error) {
+	if err := assertAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	currency, err := readEnabledCurrency(ctx, normalizeCurrencyCode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	settings := model.CurrencySettings{BaseCurrency: currency.Code}
+	if err := putCurrencySettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
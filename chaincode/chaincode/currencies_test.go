@@ -0,0 +1,117 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterCurrency(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetAttributeValueReturns("admin", true, nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyReturns("currency~JPY", nil)
+
+	currency, err := smartContract.RegisterCurrency(transactionContext, "jpy", "Japanese yen", 0, "")
+	require.NoError(t, err)
+	require.Equal(t, &model.CurrencyDefinition{
+		DocType:    model.CurrencyDocType,
+		Code:       "JPY",
+		Name:       "Japanese yen",
+		MinorUnits: 0,
+		Rounding:   model.RoundHalfEven,
+		Enabled:    true,
+	}, currency)
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "currency~JPY", key)
+	var stored model.CurrencyDefinition
+	require.NoError(t, json.Unmarshal(value, &stored))
+	require.Equal(t, *currency, stored)
+}
+
+func TestRegisterCurrency_Invalid(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+	smartContract := chaincode.SmartContract{}
+
+	// Test Case: Not an administrator
+	clientIdentity.GetAttributeValueReturns("client", true, nil)
+	_, err := smartContract.RegisterCurrency(transactionContext, "JPY", "Japanese yen", 0, "")
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+
+	clientIdentity.GetAttributeValueReturns("admin", true, nil)
+
+	// Test Case: Not an ISO 4217 code
+	_, err = smartContract.RegisterCurrency(transactionContext, "YEN1", "Japanese yen", 0, "")
+	require.EqualError(t, err, "invalid ISO 4217 currency code: YEN1")
+
+	// Test Case: Too many minor units
+	_, err = smartContract.RegisterCurrency(transactionContext, "JPY", "Japanese yen", 5, "")
+	require.EqualError(t, err, "minor units must be between 0 and 4")
+
+	// Test Case: Unknown rounding mode
+	_, err = smartContract.RegisterCurrency(transactionContext, "JPY", "Japanese yen", 0, "DOWN")
+	require.EqualError(t, err, "invalid rounding mode: DOWN")
+
+	// Test Case: Already registered
+	chaincodeStub.GetStateReturns(eurDefinition, nil)
+	_, err = smartContract.RegisterCurrency(transactionContext, "EUR", "Euro", 2, "HALF_EVEN")
+	require.EqualError(t, err, "currency EUR is already registered")
+
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestSetCurrencyEnabled(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetAttributeValueReturns("admin", true, nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturns(rsdDefinition, nil)
+
+	currency, err := smartContract.SetCurrencyEnabled(transactionContext, "RSD", false)
+	require.NoError(t, err)
+	require.False(t, currency.Enabled)
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+
+	// Test Case: Unknown currency
+	chaincodeStub.GetStateReturns(nil, nil)
+	_, err = smartContract.SetCurrencyEnabled(transactionContext, "JPY", true)
+	require.EqualError(t, err, "currency JPY is not registered")
+}
+
+func TestCreateBankAccount_CurrencyDisabled(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"someUserData":"value"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"docType":"bank","ID":"b1","Name":"UniCredit"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"docType":"currency","code":"USD","minor_units":2,"rounding":"HALF_EVEN","enabled":false}`), nil)
+
+	err := smartContract.CreateBankAccount(transactionContext, "a1", "usd", "Visa", "b1", "u1", "")
+	require.EqualError(t, err, "currency USD is not enabled")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
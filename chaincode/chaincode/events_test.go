@@ -0,0 +1,118 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestTransferMoney_EmitsEvent(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"dstAccount","currency":"EUR","balance":0}`), nil)
+
+	_, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "25")
+	require.NoError(t, err)
+
+	require.Equal(t, 1, chaincodeStub.SetEventCallCount())
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "TransferCompleted", name)
+	require.JSONEq(t, `{
+		"tx_id":"tx1",
+		"source_account":"srcAccount",
+		"destination_account":"dstAccount",
+		"amount":2500,
+		"currency":"EUR",
+		"converted_amount":2500,
+		"converted_currency":"EUR",
+		"rate":"1.00000000",
+		"timestamp":"2024-02-01T10:00:00Z"
+	}`, string(payload))
+}
+
+func TestMoneyDepositAndWithdrawal_EmitEvents(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturns([]byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(5, eurDefinition, nil)
+
+	_, err := smartContract.MoneyDepositToAccount(transactionContext, "a1", "50")
+	require.NoError(t, err)
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "DepositCompleted", name)
+	require.JSONEq(t, `{"tx_id":"tx1","account":"a1","user_id":"u1","amount":5000,"currency":"EUR","balance":15000,"timestamp":"2024-02-01T10:00:00Z"}`, string(payload))
+
+	_, err = smartContract.MoneyWithdrawal(transactionContext, "a1", "30")
+	require.NoError(t, err)
+	name, payload = chaincodeStub.SetEventArgsForCall(1)
+	require.Equal(t, "WithdrawalCompleted", name)
+	require.JSONEq(t, `{"tx_id":"tx1","account":"a1","user_id":"u1","amount":3000,"currency":"EUR","balance":7000,"timestamp":"2024-02-01T10:00:00Z"}`, string(payload))
+}
+
+func TestMoneyWithdrawal_FailureEmitsNoEvent(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturns([]byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":1000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+
+	_, err := smartContract.MoneyWithdrawal(transactionContext, "a1", "30")
+	require.EqualError(t, err, "Insufficient funds")
+	require.Equal(t, 0, chaincodeStub.SetEventCallCount())
+}
+
+func TestCreateBankAccountAndAddUser_EmitEvents(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+
+	chaincodeStub.GetTransientReturns(map[string][]byte{"user": []byte(`{"name":"Aleksandar","surname":"Stojanovic","email":"aleksandar@gmail.com","salt":"s4lt"}`)}, nil)
+	err := smartContract.AddUser(transactionContext, "u1")
+	require.NoError(t, err)
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "UserAdded", name)
+	require.JSONEq(t, `{"tx_id":"tx1","user_id":"u1","timestamp":"2024-02-01T10:00:00Z"}`, string(payload))
+
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"someUserData":"value"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"docType":"bank","ID":"b1","name":"UniCredit"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(4, eurDefinition, nil)
+	chaincodeStub.GetTransientReturns(map[string][]byte{"cards": []byte(`["4111111111111111"]`)}, nil)
+
+	err = smartContract.CreateBankAccount(transactionContext, "a1", "EUR", "Visa", "b1", "u1", "")
+	require.NoError(t, err)
+	name, payload = chaincodeStub.SetEventArgsForCall(1)
+	require.Equal(t, "AccountCreated", name)
+	require.JSONEq(t, `{"tx_id":"tx1","account":"a1","user_id":"u1","bank_id":"b1","currency":"EUR","timestamp":"2024-02-01T10:00:00Z"}`, string(payload))
+}
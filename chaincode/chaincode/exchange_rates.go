@@ -0,0 +1,280 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const exchangeRateObjectType = "exchangeRate"
+
+// Keys sort by effective time within a currency pair, so the last rate not in
+// the future is the one in force.
+func exchangeRateKey(ctx contractapi.TransactionContextInterface, from, to model.Currency, effectiveFrom time.Time) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(exchangeRateObjectType, []string{
+		string(from),
+		string(to),
+		fmt.Sprintf("%020d", effectiveFrom.UnixNano()),
+	})
+}
+
+func putExchangeRate(ctx contractapi.TransactionContextInterface, exchangeRate model.ExchangeRate) (*model.ExchangeRate, error) {
+	key, err := exchangeRateKey(ctx, exchangeRate.From, exchangeRate.To, exchangeRate.EffectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exchange rate key: %v", err)
+	}
+
+	exchangeRate.DocType = model.ExchangeRateDocType
+	exchangeRate.ID = key
+	if err := utils.PutDataToState(ctx, exchangeRate, key); err != nil {
+		return nil, err
+	}
+	return &exchangeRate, nil
+}
+
+func (s *SmartContract) PublishExchangeRate(ctx contractapi.TransactionContextInterface, from, to, rateStr, effectiveFromStr string) (*model.ExchangeRate, error) {
+	if err := assertAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	fromCurrency, err := readEnabledCurrency(ctx, normalizeCurrencyCode(from))
+	if err != nil {
+		return nil, err
+	}
+	toCurrency, err := readEnabledCurrency(ctx, normalizeCurrencyCode(to))
+	if err != nil {
+		return nil, err
+	}
+	if fromCurrency.Code == toCurrency.Code {
+		return nil, fmt.Errorf("exchange rate needs two different currencies")
+	}
+
+	rate, err := utils.ParseRate(rateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	effectiveFrom := timestamp.AsTime()
+	if effectiveFromStr != "" {
+		requested, err := time.Parse(time.RFC3339, effectiveFromStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse effective from date: %v", err)
+		}
+		if requested.Before(effectiveFrom) {
+			return nil, fmt.Errorf("exchange rates cannot be published retroactively")
+		}
+		effectiveFrom = requested.UTC()
+	}
+
+	key, err := exchangeRateKey(ctx, fromCurrency.Code, toCurrency.Code, effectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exchange rate key: %v", err)
+	}
+	exists, err := s.AssetExists(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("an exchange rate from %s to %s effective at %s already exists", fromCurrency.Code, toCurrency.Code, effectiveFrom.Format(time.RFC3339))
+	}
+
+	publisher, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client identity: %v", err)
+	}
+
+	return putExchangeRate(ctx, model.ExchangeRate{
+		From:          fromCurrency.Code,
+		To:            toCurrency.Code,
+		Rate:          rate.FloatString(utils.RateDecimals),
+		EffectiveFrom: effectiveFrom,
+		PublishedBy:   publisher,
+	})
+}
+
+// GetExchangeRates lists published rates, optionally narrowed down to a source
+// currency or a currency pair.
+func (s *SmartContract) GetExchangeRates(ctx contractapi.TransactionContextInterface, from, to string) ([]model.ExchangeRate, error) {
+	var attributes []string
+	if from != "" {
+		attributes = append(attributes, string(normalizeCurrencyCode(from)))
+	}
+	if to != "" {
+		if from == "" {
+			return nil, fmt.Errorf("target currency can only be used together with source currency")
+		}
+		attributes = append(attributes, string(normalizeCurrencyCode(to)))
+	}
+
+	return listExchangeRates(ctx, attributes)
+}
+
+func (s *SmartContract) GetExchangeRate(ctx contractapi.TransactionContextInterface, from, to, at string) (*model.EffectiveExchangeRate, error) {
+	fromCurrency, err := readCurrency(ctx, normalizeCurrencyCode(from))
+	if err != nil {
+		return nil, err
+	}
+	toCurrency, err := readCurrency(ctx, normalizeCurrencyCode(to))
+	if err != nil {
+		return nil, err
+	}
+
+	var atTime time.Time
+	if at == "" {
+		timestamp, err := ctx.GetStub().GetTxTimestamp()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+		}
+		atTime = timestamp.AsTime()
+	} else {
+		atTime, err = time.Parse(time.RFC3339, at)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse date: %v", err)
+		}
+	}
+
+	legs, rate, err := effectiveExchangeRate(ctx, fromCurrency.Code, toCurrency.Code, atTime)
+	if err != nil {
+		return nil, err
+	}
+	return &model.EffectiveExchangeRate{
+		From:    fromCurrency.Code,
+		To:      toCurrency.Code,
+		Rate:    rate.FloatString(utils.RateDecimals),
+		RateIDs: exchangeRateIDs(legs),
+	}, nil
+}
+
+func listExchangeRates(ctx contractapi.TransactionContextInterface, attributes []string) ([]model.ExchangeRate, error) {
+	results, err := ctx.GetStub().GetStateByPartialCompositeKey(exchangeRateObjectType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates: %v", err)
+	}
+	defer results.Close()
+
+	var exchangeRates []model.ExchangeRate
+	for results.HasNext() {
+		result, err := results.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate exchange rates: %v", err)
+		}
+
+		var exchangeRate model.ExchangeRate
+		if err := json.Unmarshal(result.Value, &exchangeRate); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal exchange rate: %v", err)
+		}
+		exchangeRates = append(exchangeRates, exchangeRate)
+	}
+
+	return exchangeRates, nil
+}
+
+func latestExchangeRate(ctx contractapi.TransactionContextInterface, from, to model.Currency, at time.Time) (*model.ExchangeRate, error) {
+	exchangeRates, err := listExchangeRates(ctx, []string{string(from), string(to)})
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *model.ExchangeRate
+	for i := range exchangeRates {
+		if exchangeRates[i].EffectiveFrom.After(at) {
+			continue
+		}
+		if latest == nil || exchangeRates[i].EffectiveFrom.After(latest.EffectiveFrom) {
+			latest = &exchangeRates[i]
+		}
+	}
+	return latest, nil
+}
+
+// pairExchangeRate finds the rate in force at the given time for a single
+// currency pair, a rate published for the opposite direction is inverted.
+// Nothing is returned when neither direction has a rate.
+func pairExchangeRate(ctx contractapi.TransactionContextInterface, from, to model.Currency, at time.Time) (*model.ExchangeRate, *big.Rat, error) {
+	direct, err := latestExchangeRate(ctx, from, to, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	inverse, err := latestExchangeRate(ctx, to, from, at)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if direct == nil && inverse == nil {
+		return nil, nil, nil
+	}
+
+	if direct != nil && (inverse == nil || !inverse.EffectiveFrom.After(direct.EffectiveFrom)) {
+		rate, err := utils.ParseRate(direct.Rate)
+		if err != nil {
+			return nil, nil, err
+		}
+		return direct, rate, nil
+	}
+
+	rate, err := utils.ParseRate(inverse.Rate)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inverse, rate.Inv(rate), nil
+}
+
+// effectiveExchangeRate returns the rate in force at the given time together
+// with the published rates it was derived from. Pairs without a rate of their
+// own are converted through the base currency. No rates are returned when both
+// currencies are the same.
+func effectiveExchangeRate(ctx contractapi.TransactionContextInterface, from, to model.Currency, at time.Time) ([]model.ExchangeRate, *big.Rat, error) {
+	if from == to {
+		return nil, big.NewRat(1, 1), nil
+	}
+
+	exchangeRate, rate, err := pairExchangeRate(ctx, from, to, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exchangeRate != nil {
+		return []model.ExchangeRate{*exchangeRate}, rate, nil
+	}
+
+	missing := fmt.Errorf("no exchange rate from %s to %s in force at %s", from, to, at.Format(time.RFC3339))
+
+	settings, err := readCurrencySettings(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	base := settings.BaseCurrency
+	if base == "" || base == from || base == to {
+		return nil, nil, missing
+	}
+
+	toBase, toBaseRate, err := pairExchangeRate(ctx, from, base, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	fromBase, fromBaseRate, err := pairExchangeRate(ctx, base, to, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	if toBase == nil || fromBase == nil {
+		return nil, nil, missing
+	}
+
+	return []model.ExchangeRate{*toBase, *fromBase}, new(big.Rat).Mul(toBaseRate, fromBaseRate), nil
+}
+
+func exchangeRateIDs(exchangeRates []model.ExchangeRate) []string {
+	var ids []string
+	for _, exchangeRate := range exchangeRates {
+		ids = append(ids, exchangeRate.ID)
+	}
+	return ids
+}
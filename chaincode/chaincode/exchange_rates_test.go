@@ -0,0 +1,229 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPublishExchangeRate(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetIDReturns("x509::CN=org1admin", nil)
+	clientIdentity.GetAttributeValueReturns("admin", true, nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+	smartContract := chaincode.SmartContract{}
+
+	now := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(now), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		if objectType == "currency" {
+			return "currency~" + attributes[0], nil
+		}
+		require.Equal(t, "exchangeRate", objectType)
+		require.Equal(t, []string{"EUR", "RSD", "01706781600000000000"}, attributes)
+		return "rateKey", nil
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		switch key {
+		case "currency~EUR":
+			return eurDefinition, nil
+		case "currency~RSD":
+			return rsdDefinition, nil
+		}
+		return nil, nil
+	}
+
+	exchangeRate, err := smartContract.PublishExchangeRate(transactionContext, "EUR", "RSD", "117.15", "")
+	require.NoError(t, err)
+	require.Equal(t, &model.ExchangeRate{
+		DocType:       model.ExchangeRateDocType,
+		ID:            "rateKey",
+		From:          model.EUR,
+		To:            model.RSD,
+		Rate:          "117.15000000",
+		EffectiveFrom: now,
+		PublishedBy:   "x509::CN=org1admin",
+	}, exchangeRate)
+	require.Equal(t, "hf.Type", clientIdentity.GetAttributeValueArgsForCall(0))
+
+	key, _ := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "rateKey", key)
+}
+
+func TestPublishExchangeRate_Invalid(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	rateExists := false
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		switch {
+		case key == "currency~EUR":
+			return eurDefinition, nil
+		case key == "currency~RSD":
+			return rsdDefinition, nil
+		case key == "currency~USD":
+			return []byte(`{"docType":"currency","code":"USD","minor_units":2,"rounding":"HALF_EVEN","enabled":false}`), nil
+		case strings.HasPrefix(key, "exchangeRate~") && rateExists:
+			return []byte(`{"docType":"exchangeRate"}`), nil
+		}
+		return nil, nil
+	}
+
+	// Test Case: Not an administrator
+	clientIdentity.GetAttributeValueReturns("client", true, nil)
+	_, err := smartContract.PublishExchangeRate(transactionContext, "EUR", "RSD", "117", "")
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+
+	clientIdentity.GetAttributeValueReturns("admin", true, nil)
+
+	// Test Case: Same currency
+	_, err = smartContract.PublishExchangeRate(transactionContext, "EUR", "EUR", "1", "")
+	require.EqualError(t, err, "exchange rate needs two different currencies")
+
+	// Test Case: Unknown currency
+	_, err = smartContract.PublishExchangeRate(transactionContext, "EUR", "JPY", "160", "")
+	require.EqualError(t, err, "currency JPY is not registered")
+
+	// Test Case: Disabled currency
+	_, err = smartContract.PublishExchangeRate(transactionContext, "EUR", "USD", "1.08", "")
+	require.EqualError(t, err, "currency USD is not enabled")
+
+	// Test Case: Backdated rate
+	_, err = smartContract.PublishExchangeRate(transactionContext, "EUR", "RSD", "117", "2024-01-01T00:00:00Z")
+	require.EqualError(t, err, "exchange rates cannot be published retroactively")
+
+	// Test Case: Rate already published for that moment
+	rateExists = true
+	_, err = smartContract.PublishExchangeRate(transactionContext, "EUR", "RSD", "117", "2024-03-01T00:00:00Z")
+	require.EqualError(t, err, "an exchange rate from EUR to RSD effective at 2024-03-01T00:00:00Z already exists")
+
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestGetExchangeRate_PicksRateInForce(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	rates := &mocks.StateQueryIterator{}
+	rates.HasNextReturnsOnCall(0, true)
+	rates.HasNextReturnsOnCall(1, true)
+	rates.HasNextReturnsOnCall(2, true)
+	rates.NextReturnsOnCall(0, &queryresult.KV{Value: []byte(`{"ID":"old","rate":"116.00000000","effective_from":"2024-01-01T00:00:00Z"}`)}, nil)
+	rates.NextReturnsOnCall(1, &queryresult.KV{Value: []byte(`{"ID":"current","rate":"117.00000000","effective_from":"2024-02-01T00:00:00Z"}`)}, nil)
+	rates.NextReturnsOnCall(2, &queryresult.KV{Value: []byte(`{"ID":"future","rate":"118.00000000","effective_from":"2024-03-01T00:00:00Z"}`)}, nil)
+	chaincodeStub.GetStateByPartialCompositeKeyReturnsOnCall(0, rates, nil)
+	chaincodeStub.GetStateByPartialCompositeKeyReturnsOnCall(1, &mocks.StateQueryIterator{}, nil)
+	chaincodeStub.GetStateReturnsOnCall(0, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(1, rsdDefinition, nil)
+
+	exchangeRate, err := smartContract.GetExchangeRate(transactionContext, "EUR", "RSD", "2024-02-15T00:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, &model.EffectiveExchangeRate{
+		From:    model.EUR,
+		To:      model.RSD,
+		Rate:    "117.00000000",
+		RateIDs: []string{"current"},
+	}, exchangeRate)
+
+	objectType, attributes := chaincodeStub.GetStateByPartialCompositeKeyArgsForCall(0)
+	require.Equal(t, "exchangeRate", objectType)
+	require.Equal(t, []string{"EUR", "RSD"}, attributes)
+	_, attributes = chaincodeStub.GetStateByPartialCompositeKeyArgsForCall(1)
+	require.Equal(t, []string{"RSD", "EUR"}, attributes)
+}
+
+func TestGetExchangeRate_NoneInForce(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(&mocks.StateQueryIterator{}, nil)
+	chaincodeStub.GetStateReturnsOnCall(0, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(1, rsdDefinition, nil)
+
+	_, err := smartContract.GetExchangeRate(transactionContext, "EUR", "RSD", "2024-02-15T00:00:00Z")
+	require.EqualError(t, err, "no exchange rate from EUR to RSD in force at 2024-02-15T00:00:00Z")
+}
+
+func TestGetExchangeRate_ThroughBaseCurrency(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturnsOnCall(0, rsdDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"docType":"currency","code":"USD","minor_units":2,"rounding":"HALF_EVEN","enabled":true}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"base_currency":"EUR"}`), nil)
+
+	rates := map[string][]byte{
+		"EUR~RSD": []byte(`{"ID":"eurRsd","from":"EUR","to":"RSD","rate":"117.00000000","effective_from":"2024-01-01T00:00:00Z"}`),
+		"EUR~USD": []byte(`{"ID":"eurUsd","from":"EUR","to":"USD","rate":"1.08000000","effective_from":"2024-01-01T00:00:00Z"}`),
+	}
+	chaincodeStub.GetStateByPartialCompositeKeyStub = func(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+		iterator := &mocks.StateQueryIterator{}
+		if rate, ok := rates[strings.Join(attributes, "~")]; ok {
+			iterator.HasNextReturnsOnCall(0, true)
+			iterator.NextReturns(&queryresult.KV{Value: rate}, nil)
+		}
+		return iterator, nil
+	}
+
+	exchangeRate, err := smartContract.GetExchangeRate(transactionContext, "RSD", "USD", "2024-02-15T00:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, &model.EffectiveExchangeRate{
+		From:    "RSD",
+		To:      "USD",
+		Rate:    "0.00923077",
+		RateIDs: []string{"eurRsd", "eurUsd"},
+	}, exchangeRate)
+}
+
+func TestGetExchangeRates(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(&mocks.StateQueryIterator{}, nil)
+
+	_, err := smartContract.GetExchangeRates(transactionContext, "", "")
+	require.NoError(t, err)
+	_, attributes := chaincodeStub.GetStateByPartialCompositeKeyArgsForCall(0)
+	require.Empty(t, attributes)
+
+	_, err = smartContract.GetExchangeRates(transactionContext, "RSD", "")
+	require.NoError(t, err)
+	_, attributes = chaincodeStub.GetStateByPartialCompositeKeyArgsForCall(1)
+	require.Equal(t, []string{"RSD"}, attributes)
+
+	_, err = smartContract.GetExchangeRates(transactionContext, "", "EUR")
+	require.EqualError(t, err, "target currency can only be used together with source currency")
+}
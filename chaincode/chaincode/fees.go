@@ -0,0 +1,323 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	feeScheduleObjectType    = "feeSchedule"
+	revenueAccountObjectType = "revenueAccount"
+)
+
+func feeScheduleKey(ctx contractapi.TransactionContextInterface, bankID string, currency model.Currency) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(feeScheduleObjectType, []string{bankID, string(currency)})
+}
+
+func revenueAccountKey(ctx contractapi.TransactionContextInterface, bankID string, currency model.Currency) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(revenueAccountObjectType, []string{bankID, string(currency)})
+}
+
+// readFeeSchedule returns nothing when the bank charges no fees in the currency.
+func readFeeSchedule(ctx contractapi.TransactionContextInterface, bankID string, currency model.Currency) (*model.FeeSchedule, error) {
+	key, err := feeScheduleKey(ctx, bankID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fee schedule key: %v", err)
+	}
+
+	scheduleJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if scheduleJSON == nil {
+		return nil, nil
+	}
+
+	var schedule model.FeeSchedule
+	if err := json.Unmarshal(scheduleJSON, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func scheduledFee(schedule *model.FeeSchedule, operation model.FeeOperation) *model.Fee {
+	switch operation {
+	case model.FeeOnWithdrawal:
+		return schedule.Withdrawal
+	case model.FeeOnInterbankTransfer:
+		return schedule.InterbankTransfer
+	case model.FeeOnConversion:
+		return schedule.Conversion
+	default:
+		return nil
+	}
+}
+
+// feeAmount computes the fee on an amount in the currency of the schedule,
+// percentages are rounded like conversions to the currency.
+func feeAmount(fee *model.Fee, amount int64, currency model.CurrencyDefinition) (int64, error) {
+	switch fee.Type {
+	case model.FeeFlat:
+		return fee.Amount, nil
+	case model.FeePercentage:
+		rate, ok := new(big.Rat).SetString(fee.Rate)
+		if !ok {
+			return 0, fmt.Errorf("invalid fee rate: %s", fee.Rate)
+		}
+		return utils.ConvertAmount(amount, currency, currency, rate)
+	default:
+		return 0, fmt.Errorf("unknown fee type %s", fee.Type)
+	}
+}
+
+// operationFees returns the fees the bank of the account charges on the
+// operations for an amount in the account currency.
+func operationFees(ctx contractapi.TransactionContextInterface, account *model.BankAccount, currency model.CurrencyDefinition, amount int64, operations ...model.FeeOperation) ([]model.AppliedFee, error) {
+	if account.BankID == "" || len(operations) == 0 {
+		return nil, nil
+	}
+
+	schedule, err := readFeeSchedule(ctx, account.BankID, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, nil
+	}
+
+	var fees []model.AppliedFee
+	for _, operation := range operations {
+		fee := scheduledFee(schedule, operation)
+		if fee == nil {
+			continue
+		}
+		charge, err := feeAmount(fee, amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		if charge > 0 {
+			fees = append(fees, model.AppliedFee{
+				Operation: operation,
+				BankID:    account.BankID,
+				Amount:    charge,
+				Currency:  account.Currency,
+			})
+		}
+	}
+	return fees, nil
+}
+
+// transferFees returns the fees on a transfer, all charged by the bank of the
+// source account: one for leaving the bank and one for changing currency.
+func transferFees(ctx contractapi.TransactionContextInterface, sourceAccount, destAccount *model.BankAccount, sourceCurrency model.CurrencyDefinition, amount int64) ([]model.AppliedFee, error) {
+	var operations []model.FeeOperation
+	if sourceAccount.BankID != destAccount.BankID {
+		operations = append(operations, model.FeeOnInterbankTransfer)
+	}
+	if sourceAccount.Currency != destAccount.Currency {
+		operations = append(operations, model.FeeOnConversion)
+	}
+	return operationFees(ctx, sourceAccount, sourceCurrency, amount, operations...)
+}
+
+func totalFees(fees []model.AppliedFee) int64 {
+	var total int64
+	for _, fee := range fees {
+		total += fee.Amount
+	}
+	return total
+}
+
+// creditFees books the fees on the revenue accounts of the banks that charged
+// them. Writes of this transaction cannot be read back, contract functions
+// crediting fees more than once pass the same revenue cache to every call.
+func creditFees(ctx contractapi.TransactionContextInterface, revenue map[string]*model.RevenueAccount, fees []model.AppliedFee) error {
+	if revenue == nil {
+		revenue = map[string]*model.RevenueAccount{}
+	}
+
+	for _, fee := range fees {
+		key, err := revenueAccountKey(ctx, fee.BankID, fee.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to create revenue account key: %v", err)
+		}
+
+		account, ok := revenue[key]
+		if !ok {
+			account, err = readRevenueAccount(ctx, key)
+			if err != nil {
+				return err
+			}
+			if account == nil {
+				account = &model.RevenueAccount{
+					DocType:  model.RevenueAccountDocType,
+					ID:       key,
+					BankID:   fee.BankID,
+					Currency: fee.Currency,
+				}
+			}
+			revenue[key] = account
+		}
+
+		account.Collected += fee.Amount
+		if err := utils.PutDataToState(ctx, account, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readRevenueAccount(ctx contractapi.TransactionContextInterface, key string) (*model.RevenueAccount, error) {
+	accountJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if accountJSON == nil {
+		return nil, nil
+	}
+
+	var account model.RevenueAccount
+	if err := json.Unmarshal(accountJSON, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// parseFee reads a flat fee in major units of the currency, e.g. "1.50", or a
+// percentage, e.g. "0.5%". An empty fee is no fee.
+func parseFee(feeStr string, currency model.CurrencyDefinition) (*model.Fee, error) {
+	feeStr = strings.TrimSpace(feeStr)
+	if feeStr == "" {
+		return nil, nil
+	}
+
+	if percentage, ok := strings.CutSuffix(feeStr, "%"); ok {
+		rate, ok := new(big.Rat).SetString(strings.TrimSpace(percentage))
+		if !ok {
+			return nil, fmt.Errorf("invalid fee: %s", feeStr)
+		}
+		if rate.Sign() < 0 {
+			return nil, fmt.Errorf("fees cannot be negative")
+		}
+		rate.Quo(rate, big.NewRat(100, 1))
+
+		precision := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(utils.RateDecimals), nil))
+		if !new(big.Rat).Mul(rate, precision).IsInt() {
+			return nil, fmt.Errorf("fee %s is more precise than %d decimal places of a fraction", feeStr, utils.RateDecimals)
+		}
+		return &model.Fee{Type: model.FeePercentage, Rate: rate.FloatString(utils.RateDecimals)}, nil
+	}
+
+	amount, err := utils.ParseAmount(feeStr, currency)
+	if err != nil {
+		return nil, err
+	}
+	if amount < 0 {
+		return nil, fmt.Errorf("fees cannot be negative")
+	}
+	return &model.Fee{Type: model.FeeFlat, Amount: amount}, nil
+}
+
+func (s *SmartContract) GetFeeSchedules(ctx contractapi.TransactionContextInterface, bankID string) ([]model.FeeSchedule, error) {
+	results, err := ctx.GetStub().GetStateByPartialCompositeKey(feeScheduleObjectType, []string{bankID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fee schedules: %v", err)
+	}
+	defer results.Close()
+
+	var schedules []model.FeeSchedule
+	for results.HasNext() {
+		result, err := results.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate fee schedules: %v", err)
+		}
+
+		var schedule model.FeeSchedule
+		if err := json.Unmarshal(result.Value, &schedule); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fee schedule: %v", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+// SetFeeSchedule sets what the bank charges on operations of its accounts in
+// the currency, each fee either flat or a percentage. Empty fees are not
+// charged.
+func (s *SmartContract) SetFeeSchedule(ctx contractapi.TransactionContextInterface, bankID, currencyCode, withdrawal, interbankTransfer, conversion string) (*model.FeeSchedule, error) {
+	if err := assertBankAdmin(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	bank, err := readBank(ctx, bankID)
+	if err != nil {
+		return nil, err
+	}
+	currency, err := readCurrency(ctx, normalizeCurrencyCode(currencyCode))
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := feeScheduleKey(ctx, bank.ID, currency.Code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fee schedule key: %v", err)
+	}
+	schedule := model.FeeSchedule{
+		DocType:  model.FeeScheduleDocType,
+		ID:       key,
+		BankID:   bank.ID,
+		Currency: currency.Code,
+	}
+	for _, fee := range []struct {
+		value string
+		dest  **model.Fee
+	}{
+		{withdrawal, &schedule.Withdrawal},
+		{interbankTransfer, &schedule.InterbankTransfer},
+		{conversion, &schedule.Conversion},
+	} {
+		if *fee.dest, err = parseFee(fee.value, *currency); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := utils.PutDataToState(ctx, schedule, key); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (s *SmartContract) GetRevenueAccounts(ctx contractapi.TransactionContextInterface, bankID string) ([]model.RevenueAccount, error) {
+	if err := assertBankAdmin(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	results, err := ctx.GetStub().GetStateByPartialCompositeKey(revenueAccountObjectType, []string{bankID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revenue accounts: %v", err)
+	}
+	defer results.Close()
+
+	var accounts []model.RevenueAccount
+	for results.HasNext() {
+		result, err := results.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate revenue accounts: %v", err)
+		}
+
+		var account model.RevenueAccount
+		if err := json.Unmarshal(result.Value, &account); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal revenue account: %v", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,150 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestMoneyWithdrawal_PercentageFee(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"a1":                    []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"bank_id":"b1"}`),
+		"u1":                    []byte(`{"ID":"u1"}`),
+		"currency~EUR":          eurDefinition,
+		"feeSchedule~b1~EUR":    []byte(`{"docType":"feeSchedule","bank_id":"b1","currency":"EUR","withdrawal":{"type":"PERCENTAGE","rate":"0.015"}}`),
+		"revenueAccount~b1~EUR": []byte(`{"docType":"revenueAccount","ID":"revenueAccount~b1~EUR","bank_id":"b1","currency":"EUR","collected":500}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	// 1.5% of 33.33 is 0.49995, rounded to 0.50
+	withdrawal, err := smartContract.MoneyWithdrawal(transactionContext, "a1", "33.33")
+	require.NoError(t, err)
+	require.Equal(t, []model.AppliedFee{{Operation: model.FeeOnWithdrawal, BankID: "b1", Amount: 50, Currency: model.EUR}}, withdrawal.Fees)
+
+	written := map[string][]byte{}
+	for i := 0; i < chaincodeStub.PutStateCallCount(); i++ {
+		key, value := chaincodeStub.PutStateArgsForCall(i)
+		written[key] = value
+	}
+
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(written["a1"], &account))
+	require.Equal(t, int64(100_00-33_33-50), account.Balance)
+	require.JSONEq(t, `{"docType":"revenueAccount","ID":"revenueAccount~b1~EUR","bank_id":"b1","currency":"EUR","collected":550}`, string(written["revenueAccount~b1~EUR"]))
+
+	// Test Case: The fee does not fit in the balance
+	state["a1"] = []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"bank_id":"b1"}`)
+	_, err = smartContract.MoneyWithdrawal(transactionContext, "a1", "99.00")
+	require.EqualError(t, err, "Insufficient funds")
+}
+
+func TestTransferMoney_InterbankFee(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"a1":                 []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"bank_id":"b1"}`),
+		"a2":                 []byte(`{"ID":"a2","user_id":"u2","currency":"EUR","balance":0,"bank_id":"b2"}`),
+		"a3":                 []byte(`{"ID":"a3","user_id":"u2","currency":"EUR","balance":0,"bank_id":"b1"}`),
+		"u1":                 []byte(`{"ID":"u1"}`),
+		"currency~EUR":       eurDefinition,
+		"feeSchedule~b1~EUR": []byte(`{"docType":"feeSchedule","bank_id":"b1","currency":"EUR","interbank_transfer":{"type":"FLAT","amount":200}}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	transferred, err := smartContract.TransferMoney(transactionContext, "a1", "a2", "50")
+	require.NoError(t, err)
+	require.Equal(t, []model.AppliedFee{{Operation: model.FeeOnInterbankTransfer, BankID: "b1", Amount: 2_00, Currency: model.EUR}}, transferred.Fees)
+	require.Equal(t, int64(50_00), transferred.ConvertedAmount)
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "a1", key)
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &account))
+	require.Equal(t, int64(48_00), account.Balance)
+
+	key, value = chaincodeStub.PutStateArgsForCall(2)
+	require.Equal(t, "revenueAccount~b1~EUR", key)
+	require.JSONEq(t, `{"docType":"revenueAccount","ID":"revenueAccount~b1~EUR","bank_id":"b1","currency":"EUR","collected":200}`, string(value))
+
+	// Test Case: Transfers within the bank are free
+	transferred, err = smartContract.TransferMoney(transactionContext, "a1", "a3", "50")
+	require.NoError(t, err)
+	require.Empty(t, transferred.Fees)
+}
+
+func TestSetFeeSchedule(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"b1":           []byte(`{"docType":"bank","ID":"b1","msp_id":"Org1MSP"}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	_, err := smartContract.SetFeeSchedule(transactionContext, "b1", "eur", "1.50", "0.5%", "")
+	require.NoError(t, err)
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "feeSchedule~b1~EUR", key)
+	require.JSONEq(t, `{
+		"docType":"feeSchedule",
+		"ID":"feeSchedule~b1~EUR",
+		"bank_id":"b1",
+		"currency":"EUR",
+		"withdrawal":{"type":"FLAT","amount":150},
+		"interbank_transfer":{"type":"PERCENTAGE","rate":"0.00500000"}
+	}`, string(value))
+
+	// Test Case: Negative fee
+	_, err = smartContract.SetFeeSchedule(transactionContext, "b1", "EUR", "", "-1%", "")
+	require.EqualError(t, err, "fees cannot be negative")
+
+	// Test Case: Administrator of another organization
+	transactionContext.GetClientIdentityReturns(organizationAdminIdentity("Org2MSP"))
+	_, err = smartContract.SetFeeSchedule(transactionContext, "b1", "EUR", "1.50", "", "")
+	require.EqualError(t, err, "only administrators of the organization running bank b1 are allowed to perform this action")
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
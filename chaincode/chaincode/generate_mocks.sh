@@ -3,3 +3,5 @@
 go run github.com/maxbrunsfeld/counterfeiter/v6 -o mocks/transaction.go -fake-name TransactionContext . transactionContext
 go run github.com/maxbrunsfeld/counterfeiter/v6 -o mocks/chaincodestub.go -fake-name ChaincodeStub . chaincodeStub
 go run github.com/maxbrunsfeld/counterfeiter/v6 -o mocks/statequeryiterator.go -fake-name StateQueryIterator . stateQueryIterator
+go run github.com/maxbrunsfeld/counterfeiter/v6 -o mocks/historyqueryiterator.go -fake-name HistoryQueryIterator . historyQueryIterator
+go run github.com/maxbrunsfeld/counterfeiter/v6 -o mocks/clientidentity.go -fake-name ClientIdentity . clientIdentity
@@ -0,0 +1,456 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	hashTimeLockObjectType    = "hashTimeLock"
+	clearingAccountObjectType = "clearingAccount"
+)
+
+func hashTimeLockKey(ctx contractapi.TransactionContextInterface, hashLock string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(hashTimeLockObjectType, []string{hashLock})
+}
+
+func clearingAccountKey(ctx contractapi.TransactionContextInterface, currency model.Currency) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(clearingAccountObjectType, []string{string(currency)})
+}
+
+// LockOutgoing locks money of the caller's account for a transfer to another
+// channel. The money stays on hold until the lock is claimed with the secret
+// of the hash, or refunded once it times out.
+func (s *SmartContract) LockOutgoing(ctx contractapi.TransactionContextInterface, hashLock, accountID, amountStr, counterpartyChannel, counterpartyAccount, timeoutStr string) (*model.HashTimeLock, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+
+	currency, err := readCurrency(ctx, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(amountStr, *currency)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := newHashTimeLock(ctx, hashLock, timeoutStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := expireHolds(ctx, account, lock.LockedAt); err != nil {
+		return nil, err
+	}
+	if availableFunds(account) < amount {
+		return nil, fmt.Errorf("not enough money")
+	}
+	if err := chargeSpendingLimits(ctx, account, model.TransactionTransfer, amount, lock.LockedAt); err != nil {
+		return nil, err
+	}
+
+	account.Held += amount
+	account.LastOperation = model.OperationHold
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+
+	lock.Direction = model.LockOutgoing
+	lock.AccountID = account.ID
+	lock.Amount = amount
+	lock.Currency = account.Currency
+	lock.CounterpartyChannel = counterpartyChannel
+	lock.CounterpartyAccount = counterpartyAccount
+	if err := utils.PutDataToState(ctx, lock, lock.ID); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// LockIncoming promises money arriving from another channel to an account,
+// paid from the clearing account once the lock is claimed. Only the
+// administrators coordinating the transfer lock incoming money, after the
+// outgoing side is locked for longer than this side.
+func (s *SmartContract) LockIncoming(ctx contractapi.TransactionContextInterface, hashLock, accountID, amountStr, currencyCode, counterpartyChannel, counterpartyAccount, timeoutStr string) (*model.HashTimeLock, error) {
+	if err := assertAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+	currency, err := readCurrency(ctx, normalizeCurrencyCode(currencyCode))
+	if err != nil {
+		return nil, err
+	}
+	if currency.Code != account.Currency {
+		return nil, fmt.Errorf("bank account %s is not in %s", account.ID, currency.Code)
+	}
+	amount, err := parseAmount(amountStr, *currency)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := newHashTimeLock(ctx, hashLock, timeoutStr)
+	if err != nil {
+		return nil, err
+	}
+	lock.Direction = model.LockIncoming
+	lock.AccountID = account.ID
+	lock.Amount = amount
+	lock.Currency = currency.Code
+	lock.CounterpartyChannel = counterpartyChannel
+	lock.CounterpartyAccount = counterpartyAccount
+	if err := utils.PutDataToState(ctx, lock, lock.ID); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// ClaimLock completes the side of a transfer on this channel, for anyone who
+// knows the secret of the hash. The secret is kept on the lock, so the other
+// side can be claimed with it too.
+func (s *SmartContract) ClaimLock(ctx contractapi.TransactionContextInterface, hashLock, secret string) (*model.Transaction, error) {
+	lock, err := readHashTimeLock(ctx, hashLock)
+	if err != nil {
+		return nil, err
+	}
+	if lock.Status != model.LockLocked {
+		return nil, fmt.Errorf("lock %s is no longer locked", lock.HashLock)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	now := timestamp.AsTime()
+	if !now.Before(lock.Timeout) {
+		return nil, fmt.Errorf("lock %s timed out at %s", lock.HashLock, lock.Timeout.Format(time.RFC3339))
+	}
+
+	preimage, err := hex.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret: %v", err)
+	}
+	if hash := sha256.Sum256(preimage); hex.EncodeToString(hash[:]) != lock.HashLock {
+		return nil, fmt.Errorf("the secret does not match lock %s", lock.HashLock)
+	}
+
+	account, err := readBankAccount(ctx, lock.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := accrueInterest(ctx, account, now); err != nil {
+		return nil, err
+	}
+
+	clearingKey, err := clearingAccountKey(ctx, lock.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clearing account key: %v", err)
+	}
+	clearing, err := readClearingAccount(ctx, clearingKey)
+	if err != nil {
+		return nil, err
+	}
+	if clearing == nil {
+		clearing = &model.ClearingAccount{
+			DocType:  model.ClearingAccountDocType,
+			ID:       clearingKey,
+			Currency: lock.Currency,
+		}
+	}
+
+	transaction := model.Transaction{
+		Type:              model.TransactionCrossChannel,
+		Amount:            lock.Amount,
+		Currency:          lock.Currency,
+		ConvertedAmount:   lock.Amount,
+		ConvertedCurrency: lock.Currency,
+		Rate:              utils.SameCurrencyRate,
+		Lock:              lock.HashLock,
+	}
+	switch lock.Direction {
+	case model.LockOutgoing:
+		account.Balance -= lock.Amount
+		account.Held -= lock.Amount
+		account.LastOperation = model.OperationCrossChannelOut
+		clearing.Position += lock.Amount
+		transaction.SourceAccount = account.ID
+	case model.LockIncoming:
+		// Outgoing money was reserved when it was locked and is always
+		// claimed, incoming money only arrives to accounts still active
+		if err := assertAccountActive(account); err != nil {
+			return nil, err
+		}
+		account.Balance += lock.Amount
+		account.LastOperation = model.OperationCrossChannelIn
+		clearing.Position -= lock.Amount
+		transaction.DestinationAccount = account.ID
+	default:
+		return nil, fmt.Errorf("unknown lock direction %s", lock.Direction)
+	}
+
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+	if err := utils.PutDataToState(ctx, clearing, clearing.ID); err != nil {
+		return nil, err
+	}
+	recorded, err := recordTransaction(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	lock.Status = model.LockClaimed
+	lock.Secret = strings.ToLower(secret)
+	lock.ClosedAt = now.UTC().Truncate(time.Second)
+	lock.TransactionID = recorded.ID
+	if err := utils.PutDataToState(ctx, lock, lock.ID); err != nil {
+		return nil, err
+	}
+
+	event := model.EventCrossChannelReceived
+	if lock.Direction == model.LockOutgoing {
+		event = model.EventCrossChannelSent
+	}
+	err = emitEvent(ctx, event, model.AccountMovementEvent{
+		TxID:      recorded.ID,
+		Account:   account.ID,
+		UserID:    account.UserID,
+		Amount:    lock.Amount,
+		Currency:  lock.Currency,
+		Balance:   account.Balance,
+		Timestamp: recorded.Timestamp,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+// RefundLock gives up a lock that timed out without being claimed: outgoing
+// money is released to the account and its spending limits, incoming money is
+// no longer promised.
+// Anyone may refund, money only goes back where it came from.
+func (s *SmartContract) RefundLock(ctx contractapi.TransactionContextInterface, hashLock string) (*model.HashTimeLock, error) {
+	lock, err := readHashTimeLock(ctx, hashLock)
+	if err != nil {
+		return nil, err
+	}
+	if lock.Status != model.LockLocked {
+		return nil, fmt.Errorf("lock %s is no longer locked", lock.HashLock)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	now := timestamp.AsTime()
+	if now.Before(lock.Timeout) {
+		return nil, fmt.Errorf("lock %s cannot be refunded before %s", lock.HashLock, lock.Timeout.Format(time.RFC3339))
+	}
+
+	// Incoming money never reached the account
+	var account *model.BankAccount
+	if lock.Direction == model.LockOutgoing {
+		account, err = readBankAccount(ctx, lock.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		account.Held -= lock.Amount
+		refundSpendingLimits(account, lock.Amount, lock.LockedAt)
+		account.LastOperation = model.OperationHoldRelease
+		if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	lock.Status = model.LockRefunded
+	lock.ClosedAt = now.UTC().Truncate(time.Second)
+	if err := utils.PutDataToState(ctx, lock, lock.ID); err != nil {
+		return nil, err
+	}
+
+	if account != nil {
+		err = emitEvent(ctx, model.EventLockRefunded, model.AccountMovementEvent{
+			TxID:      ctx.GetStub().GetTxID(),
+			Account:   account.ID,
+			UserID:    account.UserID,
+			Amount:    lock.Amount,
+			Currency:  lock.Currency,
+			Balance:   account.Balance,
+			Timestamp: now,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lock, nil
+}
+
+// ReadLockSecret returns the secret a claimed lock was claimed with. It is on
+// the ledger already and only claims the other side of the transfer, so the
+// owner of the source account completes the transfer with it.
+func (s *SmartContract) ReadLockSecret(ctx contractapi.TransactionContextInterface, hashLock string) (string, error) {
+	lock, err := readHashTimeLock(ctx, hashLock)
+	if err != nil {
+		return "", err
+	}
+	if lock.Status != model.LockClaimed {
+		return "", fmt.Errorf("lock %s is not claimed", lock.HashLock)
+	}
+	return lock.Secret, nil
+}
+
+// ReadHashTimeLock returns a lock to administrators of the bank of its account
+// and the owner of the account, locks of other users are reported as missing.
+func (s *SmartContract) ReadHashTimeLock(ctx contractapi.TransactionContextInterface, hashLock string) (*model.HashTimeLock, error) {
+	lock, err := readHashTimeLock(ctx, hashLock)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := readBankAccount(ctx, lock.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if assertBankAdmin(ctx, account.BankID) == nil {
+		return lock, nil
+	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return nil, fmt.Errorf("the lock %s does not exist", lock.HashLock)
+	}
+	return lock, nil
+}
+
+func (s *SmartContract) GetClearingAccounts(ctx contractapi.TransactionContextInterface) ([]model.ClearingAccount, error) {
+	if err := assertAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	results, err := ctx.GetStub().GetStateByPartialCompositeKey(clearingAccountObjectType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clearing accounts: %v", err)
+	}
+	defer results.Close()
+
+	var accounts []model.ClearingAccount
+	for results.HasNext() {
+		result, err := results.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate clearing accounts: %v", err)
+		}
+
+		var account model.ClearingAccount
+		if err := json.Unmarshal(result.Value, &account); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal clearing account: %v", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+// newHashTimeLock starts a lock on a hash not used on this channel before,
+// timing out in the future.
+func newHashTimeLock(ctx contractapi.TransactionContextInterface, hashLock, timeoutStr string) (*model.HashTimeLock, error) {
+	hashLock = strings.ToLower(hashLock)
+	if hash, err := hex.DecodeString(hashLock); err != nil || len(hash) != sha256.Size {
+		return nil, fmt.Errorf("hash lock has to be a hex encoded SHA-256 hash")
+	}
+
+	key, err := hashTimeLockKey(ctx, hashLock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock key: %v", err)
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("lock %s already exists", hashLock)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	// Times are kept in whole seconds of UTC, so queries can compare them as strings
+	now := timestamp.AsTime().UTC().Truncate(time.Second)
+	timeout, err := time.Parse(time.RFC3339, timeoutStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %v", err)
+	}
+	timeout = timeout.UTC().Truncate(time.Second)
+	if !timeout.After(now) {
+		return nil, fmt.Errorf("timeout has to be in the future")
+	}
+
+	return &model.HashTimeLock{
+		DocType:  model.HashTimeLockDocType,
+		ID:       key,
+		HashLock: hashLock,
+		Status:   model.LockLocked,
+		LockedAt: now,
+		Timeout:  timeout,
+	}, nil
+}
+
+func readHashTimeLock(ctx contractapi.TransactionContextInterface, hashLock string) (*model.HashTimeLock, error) {
+	hashLock = strings.ToLower(hashLock)
+	key, err := hashTimeLockKey(ctx, hashLock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock key: %v", err)
+	}
+
+	lockJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if lockJSON == nil {
+		return nil, fmt.Errorf("the lock %s does not exist", hashLock)
+	}
+
+	var lock model.HashTimeLock
+	if err := json.Unmarshal(lockJSON, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func readClearingAccount(ctx contractapi.TransactionContextInterface, key string) (*model.ClearingAccount, error) {
+	accountJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if accountJSON == nil {
+		return nil, nil
+	}
+
+	var account model.ClearingAccount
+	if err := json.Unmarshal(accountJSON, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
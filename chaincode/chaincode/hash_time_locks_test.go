@@ -0,0 +1,146 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCrossChannelLocks(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	locked := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(locked), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"a1":           []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`),
+		"a2":           []byte(`{"ID":"a2","user_id":"u2","currency":"EUR","balance":0}`),
+		"u1":           []byte(`{"ID":"u1"}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		state[key] = value
+		return nil
+	}
+
+	secret := []byte("correct horse battery staple")
+	hash := sha256.Sum256(secret)
+	hashLock := hex.EncodeToString(hash[:])
+	timeout := locked.Add(time.Hour).Format(time.RFC3339)
+
+	lock, err := smartContract.LockOutgoing(transactionContext, hashLock, "a1", "40", "channel2", "b1", timeout)
+	require.NoError(t, err)
+	require.Equal(t, model.LockOutgoing, lock.Direction)
+	require.Equal(t, "hashTimeLock~"+hashLock, lock.ID)
+
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(state["a1"], &account))
+	require.Equal(t, int64(40_00), account.Held)
+
+	_, err = smartContract.LockOutgoing(transactionContext, hashLock, "a1", "40", "channel2", "b1", timeout)
+	require.EqualError(t, err, "lock "+hashLock+" already exists")
+
+	// Test Case: Only administrators lock incoming money
+	_, err = smartContract.LockIncoming(transactionContext, strings.Repeat("ab", 32), "a2", "40", "EUR", "channel2", "b1", timeout)
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+
+	// Test Case: Wrong secret
+	_, err = smartContract.ClaimLock(transactionContext, hashLock, hex.EncodeToString([]byte("wrong")))
+	require.EqualError(t, err, "the secret does not match lock "+hashLock)
+
+	_, err = smartContract.RefundLock(transactionContext, hashLock)
+	require.EqualError(t, err, "lock "+hashLock+" cannot be refunded before 2024-02-01T11:00:00Z")
+
+	claimed, err := smartContract.ClaimLock(transactionContext, hashLock, hex.EncodeToString(secret))
+	require.NoError(t, err)
+	require.Equal(t, model.TransactionCrossChannel, claimed.Type)
+	require.Equal(t, "a1", claimed.SourceAccount)
+
+	var claimedAccount model.BankAccount
+	require.NoError(t, json.Unmarshal(state["a1"], &claimedAccount))
+	require.Equal(t, int64(60_00), claimedAccount.Balance)
+	require.Equal(t, int64(0), claimedAccount.Held)
+	require.JSONEq(t, `{"docType":"clearingAccount","ID":"clearingAccount~EUR","currency":"EUR","position":4000}`, string(state["clearingAccount~EUR"]))
+
+	require.NoError(t, json.Unmarshal(state[lock.ID], lock))
+	require.Equal(t, model.LockClaimed, lock.Status)
+	require.Equal(t, hex.EncodeToString(secret), lock.Secret)
+
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "CrossChannelSent", name)
+	require.JSONEq(t, `{"tx_id":"`+claimed.ID+`","account":"a1","user_id":"u1","amount":4000,"currency":"EUR","balance":6000,"timestamp":"2024-02-01T10:00:00Z"}`, string(payload))
+
+	// The secret is revealed to complete the other side
+	revealed, err := smartContract.ReadLockSecret(transactionContext, hashLock)
+	require.NoError(t, err)
+	require.Equal(t, hex.EncodeToString(secret), revealed)
+
+	// Test Case: The incoming side is paid from the clearing account
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	chaincodeStub.GetTxIDReturns("tx2")
+	otherSecret := []byte("another secret")
+	otherHash := sha256.Sum256(otherSecret)
+	_, err = smartContract.LockIncoming(transactionContext, hex.EncodeToString(otherHash[:]), "a2", "25", "eur", "channel2", "b1", timeout)
+	require.NoError(t, err)
+	_, err = smartContract.ClaimLock(transactionContext, hex.EncodeToString(otherHash[:]), hex.EncodeToString(otherSecret))
+	require.NoError(t, err)
+
+	require.NoError(t, json.Unmarshal(state["a2"], &account))
+	require.Equal(t, int64(25_00), account.Balance)
+	require.JSONEq(t, `{"docType":"clearingAccount","ID":"clearingAccount~EUR","currency":"EUR","position":1500}`, string(state["clearingAccount~EUR"]))
+}
+
+func TestRefundLock(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u2"))
+	smartContract := chaincode.SmartContract{}
+
+	hashLock := strings.Repeat("ab", 32)
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 11, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"docType":"hashTimeLock","ID":"hashTimeLock~`+hashLock+`","hash_lock":"`+hashLock+`","direction":"OUTGOING","account_id":"a1","amount":4000,"currency":"EUR","status":"LOCKED","timeout":"2024-02-01T11:00:00Z"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"held":4000}`), nil)
+
+	// Anyone may refund once the lock timed out
+	lock, err := smartContract.RefundLock(transactionContext, hashLock)
+	require.NoError(t, err)
+	require.Equal(t, model.LockRefunded, lock.Status)
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "a1", key)
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &account))
+	require.Equal(t, int64(100_00), account.Balance)
+	require.Equal(t, int64(0), account.Held)
+
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "LockRefunded", name)
+	require.JSONEq(t, `{"tx_id":"","account":"a1","user_id":"u1","amount":4000,"currency":"EUR","balance":10000,"timestamp":"2024-02-01T11:00:00Z"}`, string(payload))
+
+	// Test Case: Secret of a lock never claimed
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"docType":"hashTimeLock","hash_lock":"`+hashLock+`","status":"REFUNDED"}`), nil)
+	_, err = smartContract.ReadLockSecret(transactionContext, hashLock)
+	require.EqualError(t, err, "lock "+hashLock+" is not claimed")
+}
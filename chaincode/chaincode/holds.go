@@ -0,0 +1,355 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultHoldValidity is how long holds placed without an expiry last.
+const defaultHoldValidity = 7 * 24 * time.Hour
+
+// PlaceHold reserves an amount of the account for the payee account, e.g. a
+// card payment authorized now and captured later. The money stays on the
+// account but no longer counts towards its available funds, until the hold
+// is captured, released or expires. Holds count towards the spending limits
+// when they are placed, what is not captured is given back.
+func (s *SmartContract) PlaceHold(ctx contractapi.TransactionContextInterface, accountID, payeeAccountID, amountStr, expiresAtStr string) (*model.Hold, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+
+	payeeAccount, err := readBankAccount(ctx, payeeAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(payeeAccount); err != nil {
+		return nil, err
+	}
+	if payeeAccount.ID == account.ID {
+		return nil, fmt.Errorf("money cannot be held for the same account")
+	}
+	// Captures are transfers at no quoted rate
+	if payeeAccount.Currency != account.Currency {
+		return nil, fmt.Errorf("holds from %s to %s accounts are not supported", account.Currency, payeeAccount.Currency)
+	}
+
+	currency, err := readCurrency(ctx, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(amountStr, *currency)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	// Times are kept in whole seconds of UTC, so queries can compare them as strings
+	now := timestamp.AsTime().UTC().Truncate(time.Second)
+	expiresAt := now.Add(defaultHoldValidity)
+	if expiresAtStr != "" {
+		expiresAt, err = time.Parse(time.RFC3339, expiresAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse expiry: %v", err)
+		}
+		expiresAt = expiresAt.UTC().Truncate(time.Second)
+		if !expiresAt.After(now) {
+			return nil, fmt.Errorf("expiry has to be in the future")
+		}
+	}
+
+	if err := expireHolds(ctx, account, now); err != nil {
+		return nil, err
+	}
+	if availableFunds(account) < amount {
+		return nil, fmt.Errorf("not enough money")
+	}
+	if err := chargeSpendingLimits(ctx, account, model.TransactionTransfer, amount, now); err != nil {
+		return nil, err
+	}
+
+	account.Held += amount
+	account.LastOperation = model.OperationHold
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+
+	hold := model.Hold{
+		DocType:      model.HoldDocType,
+		ID:           ctx.GetStub().GetTxID(),
+		AccountID:    account.ID,
+		PayeeAccount: payeeAccount.ID,
+		Amount:       amount,
+		Currency:     account.Currency,
+		Status:       model.HoldActive,
+		PlacedAt:     now,
+		ExpiresAt:    expiresAt,
+	}
+	if err := utils.PutDataToState(ctx, hold, hold.ID); err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// CaptureHold transfers the held money, or a part of it, to the payee account.
+// What is not captured is released. Fees are charged like on a transfer.
+func (s *SmartContract) CaptureHold(ctx contractapi.TransactionContextInterface, holdID, amountStr string) (*model.Transaction, error) {
+	hold, payeeAccount, err := readPayeeHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	now := timestamp.AsTime()
+	if !now.Before(hold.ExpiresAt) {
+		return nil, fmt.Errorf("hold %s expired at %s", holdID, hold.ExpiresAt.Format(time.RFC3339))
+	}
+
+	account, err := readBankAccount(ctx, hold.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(payeeAccount); err != nil {
+		return nil, err
+	}
+
+	currency, err := readCurrency(ctx, hold.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount := hold.Amount
+	if amountStr != "" {
+		if amount, err = parseAmount(amountStr, *currency); err != nil {
+			return nil, err
+		}
+		if amount > hold.Amount {
+			return nil, fmt.Errorf("amount exceeds hold %s", holdID)
+		}
+	}
+
+	account.Held -= hold.Amount
+	refundSpendingLimits(account, hold.Amount-amount, hold.PlacedAt)
+	fees, err := transferFees(ctx, account, payeeAccount, *currency, amount)
+	if err != nil {
+		return nil, err
+	}
+	if availableFunds(account) < amount+totalFees(fees) {
+		return nil, fmt.Errorf("not enough money")
+	}
+
+	recorded, err := executeTransfer(ctx, account, payeeAccount, model.Transaction{
+		Amount:            amount,
+		Currency:          hold.Currency,
+		ConvertedAmount:   amount,
+		ConvertedCurrency: payeeAccount.Currency,
+		Rate:              utils.SameCurrencyRate,
+		Fees:              fees,
+		Hold:              hold.ID,
+	}, now, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	hold.Status = model.HoldCaptured
+	hold.Captured = amount
+	hold.TransactionID = recorded.ID
+	hold.ClosedAt = now.UTC().Truncate(time.Second)
+	if err := utils.PutDataToState(ctx, hold, hold.ID); err != nil {
+		return nil, err
+	}
+	if err := emitTransferCompleted(ctx, recorded); err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+// ReleaseHold gives the held money back to the available funds of the account
+// and to its spending limits.
+func (s *SmartContract) ReleaseHold(ctx contractapi.TransactionContextInterface, holdID string) (*model.Hold, error) {
+	hold, _, err := readPayeeHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	account, err := readBankAccount(ctx, hold.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	account.Held -= hold.Amount
+	refundSpendingLimits(account, hold.Amount, hold.PlacedAt)
+	account.LastOperation = model.OperationHoldRelease
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+
+	hold.Status = model.HoldReleased
+	hold.ClosedAt = timestamp.AsTime().UTC().Truncate(time.Second)
+	if err := utils.PutDataToState(ctx, hold, hold.ID); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// ListHolds returns the holds on the account. Holds past their expiry are
+// reported as expired even before they are released from the account.
+func (s *SmartContract) ListHolds(ctx contractapi.TransactionContextInterface, accountID string) ([]model.Hold, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if assertBankAdmin(ctx, account.BankID) != nil {
+		if _, err := assertAccountOwner(ctx, account); err != nil {
+			return nil, err
+		}
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	holds, err := queryHolds(ctx, map[string]interface{}{
+		"docType":    model.HoldDocType,
+		"account_id": account.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range holds {
+		if holds[i].Status == model.HoldActive && !timestamp.AsTime().Before(holds[i].ExpiresAt) {
+			holds[i].Status = model.HoldExpired
+		}
+	}
+	return holds, nil
+}
+
+// expireHolds releases the holds on the account that expired by the time like
+// ReleaseHold, writing the account is left to the caller. It has to run before the
+// available funds of an account with holds are checked.
+func expireHolds(ctx contractapi.TransactionContextInterface, account *model.BankAccount, at time.Time) error {
+	if account.Held == 0 {
+		return nil
+	}
+
+	expired, err := queryHolds(ctx, map[string]interface{}{
+		"docType":    model.HoldDocType,
+		"account_id": account.ID,
+		"status":     model.HoldActive,
+		"expires_at": map[string]interface{}{"$lte": at.UTC().Truncate(time.Second)},
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range expired {
+		hold := &expired[i]
+		account.Held -= hold.Amount
+		refundSpendingLimits(account, hold.Amount, hold.PlacedAt)
+		hold.Status = model.HoldExpired
+		hold.ClosedAt = hold.ExpiresAt
+		if err := utils.PutDataToState(ctx, hold, hold.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readHold(ctx contractapi.TransactionContextInterface, id string) (*model.Hold, error) {
+	holdJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if holdJSON == nil {
+		return nil, fmt.Errorf("the hold with id %s does not exist", id)
+	}
+
+	var hold model.Hold
+	if err := json.Unmarshal(holdJSON, &hold); err != nil {
+		return nil, err
+	}
+	if hold.DocType != model.HoldDocType {
+		return nil, fmt.Errorf("the hold with id %s does not exist", id)
+	}
+
+	return &hold, nil
+}
+
+// readPayeeHold reads an active hold for the owner of its payee account or an
+// administrator, the only ones who may capture or release it. Holds for other
+// payees are reported as missing.
+func readPayeeHold(ctx contractapi.TransactionContextInterface, id string) (*model.Hold, *model.BankAccount, error) {
+	hold, err := readHold(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payeeAccount, err := readBankAccount(ctx, hold.PayeeAccount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if assertBankAdmin(ctx, payeeAccount.BankID) != nil {
+		if _, err := assertAccountOwner(ctx, payeeAccount); err != nil {
+			return nil, nil, fmt.Errorf("the hold with id %s does not exist", id)
+		}
+	}
+
+	if hold.Status != model.HoldActive {
+		return nil, nil, fmt.Errorf("hold %s is no longer active", id)
+	}
+	return hold, payeeAccount, nil
+}
+
+func queryHolds(ctx contractapi.TransactionContextInterface, selector map[string]interface{}) ([]model.Hold, error) {
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %v", err)
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	var holds []model.Hold
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var hold model.Hold
+		if err := json.Unmarshal(queryResult.Value, &hold); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal hold: %v", err)
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, nil
+}
@@ -0,0 +1,163 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPlaceAndCaptureHold(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	placed := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxIDReturns("hold1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(placed), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"a1":           []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"bank_id":"b1"}`),
+		"a2":           []byte(`{"ID":"a2","user_id":"u2","currency":"EUR","balance":0,"bank_id":"b1"}`),
+		"u1":           []byte(`{"ID":"u1"}`),
+		"u2":           []byte(`{"ID":"u2"}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		state[key] = value
+		return nil
+	}
+
+	hold, err := smartContract.PlaceHold(transactionContext, "a1", "a2", "60", "")
+	require.NoError(t, err)
+	require.Equal(t, model.HoldActive, hold.Status)
+	require.Equal(t, placed.AddDate(0, 0, 7), hold.ExpiresAt)
+
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(state["a1"], &account))
+	require.Equal(t, int64(100_00), account.Balance)
+	require.Equal(t, int64(60_00), account.Held)
+	require.Equal(t, int64(60_00), account.Spending.Transferred)
+
+	// Test Case: Held money cannot be withdrawn
+	chaincodeStub.GetQueryResultReturns(&mocks.StateQueryIterator{}, nil)
+	_, err = smartContract.MoneyWithdrawal(transactionContext, "a1", "50")
+	require.EqualError(t, err, "Insufficient funds")
+
+	// Test Case: Only the payee captures
+	_, err = smartContract.CaptureHold(transactionContext, "hold1", "")
+	require.EqualError(t, err, "the hold with id hold1 does not exist")
+
+	transactionContext.GetClientIdentityReturns(userIdentity("u2"))
+	chaincodeStub.GetTxIDReturns("tx1")
+	_, err = smartContract.CaptureHold(transactionContext, "hold1", "60.01")
+	require.EqualError(t, err, "amount exceeds hold hold1")
+
+	captured, err := smartContract.CaptureHold(transactionContext, "hold1", "45.50")
+	require.NoError(t, err)
+	require.Equal(t, "hold1", captured.Hold)
+	require.Equal(t, int64(45_50), captured.Amount)
+
+	var source, payee model.BankAccount
+	require.NoError(t, json.Unmarshal(state["a1"], &source))
+	require.Equal(t, int64(54_50), source.Balance)
+	require.Equal(t, int64(0), source.Held)
+	// The part not captured no longer counts towards the limits
+	require.Equal(t, int64(45_50), source.Spending.Transferred)
+	require.NoError(t, json.Unmarshal(state["a2"], &payee))
+	require.Equal(t, int64(45_50), payee.Balance)
+
+	require.NoError(t, json.Unmarshal(state["hold1"], hold))
+	require.Equal(t, model.HoldCaptured, hold.Status)
+	require.Equal(t, int64(45_50), hold.Captured)
+	require.Equal(t, "tx1", hold.TransactionID)
+
+	_, err = smartContract.ReleaseHold(transactionContext, "hold1")
+	require.EqualError(t, err, "hold hold1 is no longer active")
+}
+
+func TestReleaseHold(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u2"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)), nil)
+	state := map[string][]byte{
+		"a1":    []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"held":6000,"bank_id":"b1","spending":{"date":"2024-02-01","withdrawn":0,"transferred":8000}}`),
+		"a2":    []byte(`{"ID":"a2","user_id":"u2","currency":"EUR","balance":0,"bank_id":"b1"}`),
+		"u2":    []byte(`{"ID":"u2"}`),
+		"hold1": []byte(`{"docType":"hold","ID":"hold1","account_id":"a1","payee_account":"a2","amount":6000,"currency":"EUR","status":"ACTIVE","placed_at":"2024-02-01T10:00:00Z","expires_at":"2024-02-08T10:00:00Z"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		state[key] = value
+		return nil
+	}
+
+	hold, err := smartContract.ReleaseHold(transactionContext, "hold1")
+	require.NoError(t, err)
+	require.Equal(t, model.HoldReleased, hold.Status)
+
+	// The hold is given back to the limits of the day it was placed on
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(state["a1"], &account))
+	require.Equal(t, int64(0), account.Held)
+	require.Equal(t, &model.DailySpending{Date: "2024-02-01", Transferred: 20_00}, account.Spending)
+}
+
+func TestMoneyWithdrawal_ExpiredHold(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 8, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"held":6000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+
+	expired := &mocks.StateQueryIterator{}
+	expired.HasNextReturnsOnCall(0, true)
+	expired.NextReturnsOnCall(0, &queryresult.KV{Value: []byte(`{"docType":"hold","ID":"hold1","account_id":"a1","payee_account":"a2","amount":6000,"currency":"EUR","status":"ACTIVE","expires_at":"2024-02-08T10:00:00Z"}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(expired, nil)
+
+	_, err := smartContract.MoneyWithdrawal(transactionContext, "a1", "100")
+	require.NoError(t, err)
+	require.True(t, strings.Contains(chaincodeStub.GetQueryResultArgsForCall(0), `"expires_at":{"$lte":"2024-02-08T10:00:00Z"}`))
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "hold1", key)
+	var hold model.Hold
+	require.NoError(t, json.Unmarshal(value, &hold))
+	require.Equal(t, model.HoldExpired, hold.Status)
+
+	key, value = chaincodeStub.PutStateArgsForCall(1)
+	require.Equal(t, "a1", key)
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &account))
+	require.Equal(t, int64(0), account.Balance)
+	require.Equal(t, int64(0), account.Held)
+}
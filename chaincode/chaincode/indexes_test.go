@@ -0,0 +1,348 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const couchDBIndexes = "../META-INF/statedb/couchdb"
+
+// indexedQuery runs the code of a contract function that issues a rich query.
+type indexedQuery struct {
+	function string
+	run      func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext)
+	// Queries that are fine without an index say why
+	unindexed string
+}
+
+var indexedQueries = []indexedQuery{
+	{function: "ListBanks", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.ListBanks(ctx)
+	}},
+	{function: "adminBanks", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetLoansByStatus(ctx, "ACTIVE")
+	}},
+	{function: "bankByPIB", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.CreateBank(ctx, "b9", "Raiffeisen", "Beograd", 1990, 123456789)
+	}},
+	{function: "ListCards", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		ctx.GetClientIdentityReturns(userIdentity("u1"))
+		contract.ListCards(ctx, "a1")
+	}},
+	{function: "ListHolds", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		ctx.GetClientIdentityReturns(userIdentity("u1"))
+		contract.ListHolds(ctx, "a1")
+	}},
+	{function: "expireHolds", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		ctx.GetClientIdentityReturns(userIdentity("u1"))
+		contract.MoneyWithdrawal(ctx, "a1", "10")
+	}},
+	{function: "GetAccountsInOverdraft", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetAccountsInOverdraft(ctx, "b1")
+	}},
+	{function: "MarkLateLoans", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.MarkLateLoans(ctx)
+	}},
+	{function: "ListLoans", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		ctx.GetClientIdentityReturns(userIdentity("u1"))
+		contract.ListLoans(ctx)
+	}},
+	{function: "GetLoansByStatus", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetLoansByStatus(ctx, "ACTIVE")
+	}},
+	{function: "ListStandingOrders", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		ctx.GetClientIdentityReturns(userIdentity("u1"))
+		contract.ListStandingOrders(ctx, "a1")
+	}},
+	{function: "ExecuteDueStandingOrders", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.ExecuteDueStandingOrders(ctx)
+	}},
+	{function: "GetTransactions", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetTransactions(ctx, "a1", "", "", "")
+		contract.GetTransactions(ctx, "a1", "TRANSFER", "", "")
+		contract.GetTransactions(ctx, "a1", "", "2024-01-01T00:00:00Z", "")
+		contract.GetTransactions(ctx, "a1", "TRANSFER", "2024-01-01T00:00:00Z", "2024-02-01T00:00:00Z")
+	}},
+	{function: "GetAccountsByBankDesiredCurrencyAndBalance", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetAccountsByBankDesiredCurrencyAndBalance(ctx, "b1", "EUR", "100")
+	}},
+	{function: "queryAccountsWithPagination", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetAccountsByBankDesiredCurrencyAndBalanceWithPagination(ctx, "b1", "EUR", "100", 10, "")
+		contract.GetAccountsByBankWithPagination(ctx, "b1", 10, "")
+	}},
+	{function: "GetAccountByBankDesiredCurrencyAndMaxBalance", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetAccountByBankDesiredCurrencyAndMaxBalance(ctx, "b1", "EUR")
+	}},
+	// Filters of clients are not indexed, only the query every request starts from
+	{function: "QueryAccounts", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.QueryAccounts(ctx, `{"filters":[]}`)
+	}},
+	{function: "GetUsersByName", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetUsersByName(ctx, "Marko")
+	}},
+	{function: "GetUsersBySurname", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetUsersBySurname(ctx, "Markovic")
+	}},
+	{function: "GetUsersBySurnameAndEmail", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetUsersBySurnameAndEmail(ctx, "Markovic", "marko@gmail.com")
+	}},
+	{function: "GetUsersByNameWithPagination", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetUsersByNameWithPagination(ctx, "Marko", 10, "")
+		contract.GetUsersByNameWithPagination(ctx, "Marko", 10, "u1")
+	}},
+	{function: "GetUsersBySurnameWithPagination", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.GetUsersBySurnameWithPagination(ctx, "Markovic", 10, "u1")
+	}},
+	{function: "QueryUsers", run: func(contract *chaincode.SmartContract, ctx *mocks.TransactionContext) {
+		contract.QueryUsers(ctx, `{"filters":[]}`)
+	}},
+	{function: "MigrateBalancesToMinorUnits", unindexed: "migrations scan the ledger once"},
+	{function: "MigrateCurrenciesToCodes", unindexed: "migrations scan the ledger once"},
+	{function: "MigrateBankReferences", unindexed: "migrations scan the ledger once"},
+	{function: "MigrateCardsToAssets", unindexed: "migrations scan the ledger once"},
+	{function: "MigrateUserPII", unindexed: "migrations scan the ledger once"},
+}
+
+type recordedQuery struct {
+	collection string
+	query      string
+}
+
+func TestQueriesHaveIndexes(t *testing.T) {
+	indexes := readIndexes(t)
+
+	// The queries run on the collection of the caller, every organization needs the same indexes
+	content, err := os.ReadFile("../collections_config.json")
+	require.NoError(t, err)
+	var collections []struct {
+		Name string `json:"name"`
+	}
+	require.NoError(t, json.Unmarshal(content, &collections))
+	for _, collection := range collections {
+		require.ElementsMatch(t, indexes["Org1MSPPIICollection"], indexes[collection.Name], collection.Name)
+	}
+
+	for _, indexed := range indexedQueries {
+		if indexed.unindexed != "" {
+			continue
+		}
+
+		t.Run(indexed.function, func(t *testing.T) {
+			chaincodeStub, queries := recordingStub()
+			transactionContext := &mocks.TransactionContext{}
+			transactionContext.GetStubReturns(chaincodeStub)
+			transactionContext.GetClientIdentityReturns(adminIdentity())
+
+			indexed.run(&chaincode.SmartContract{}, transactionContext)
+			require.NotEmpty(t, *queries, "no query was issued")
+
+			for _, recorded := range *queries {
+				fields := queryFields(t, recorded.query)
+				require.Contains(t, indexes[recorded.collection], fields, "no index on %v for %s in %q", fields, recorded.query, recorded.collection)
+			}
+		})
+	}
+}
+
+// TestQueriesAreListed finds the functions issuing rich queries, directly or
+// through a helper taking the selector, so that a new one fails until it is
+// listed above with its index.
+func TestQueriesAreListed(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	fileSet := token.NewFileSet()
+	var functions []*ast.FuncDecl
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fileSet, file, nil, 0)
+		require.NoError(t, err)
+		for _, decl := range parsed.Decls {
+			if function, ok := decl.(*ast.FuncDecl); ok {
+				functions = append(functions, function)
+			}
+		}
+	}
+
+	helpers := map[string]bool{}
+	for _, function := range functions {
+		for _, param := range function.Type.Params.List {
+			for _, name := range param.Names {
+				if name.Name == "selector" || name.Name == "q" {
+					helpers[function.Name.Name] = true
+				}
+			}
+		}
+	}
+
+	var found []string
+	for _, function := range functions {
+		if helpers[function.Name.Name] || function.Body == nil {
+			continue
+		}
+		issuesQuery := false
+		ast.Inspect(function.Body, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			switch fun := call.Fun.(type) {
+			case *ast.SelectorExpr:
+				switch fun.Sel.Name {
+				case "GetQueryResult", "GetQueryResultWithPagination", "GetPrivateDataQueryResult":
+					issuesQuery = true
+				}
+			case *ast.Ident:
+				if helpers[fun.Name] {
+					issuesQuery = true
+				}
+			}
+			return true
+		})
+		if issuesQuery {
+			found = append(found, function.Name.Name)
+		}
+	}
+
+	var listed []string
+	for _, indexed := range indexedQueries {
+		listed = append(listed, indexed.function)
+	}
+	require.ElementsMatch(t, listed, found)
+}
+
+func recordingStub() (*mocks.ChaincodeStub, *[]recordedQuery) {
+	chaincodeStub := &mocks.ChaincodeStub{}
+	queries := &[]recordedQuery{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"a1":           []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"held":500,"bank_id":"b1"}`),
+		"b1":           []byte(`{"docType":"bank","ID":"b1","name":"UniCredit"}`),
+		"u1":           []byte(`{"ID":"u1","msp_id":"Org1MSP"}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.GetStateByPartialCompositeKeyStub = func(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+		return queryResults(`{"docType":"interestRate","bank_id":"b1","product":"SAVINGS","rate":"0.0365"}`), nil
+	}
+
+	chaincodeStub.GetQueryResultStub = func(query string) (shim.StateQueryIteratorInterface, error) {
+		*queries = append(*queries, recordedQuery{query: query})
+		// Searches of administrators are limited to the banks of their organization
+		if query == `{"selector":{"docType":"bank"}}` {
+			return queryResults(string(state["b1"])), nil
+		}
+		return queryResults(), nil
+	}
+	chaincodeStub.GetQueryResultWithPaginationStub = func(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+		*queries = append(*queries, recordedQuery{query: query})
+		return queryResults(), &peer.QueryResponseMetadata{}, nil
+	}
+	chaincodeStub.GetPrivateDataQueryResultStub = func(collection, query string) (shim.StateQueryIteratorInterface, error) {
+		*queries = append(*queries, recordedQuery{collection: collection, query: query})
+		return queryResults(), nil
+	}
+
+	return chaincodeStub, queries
+}
+
+// queryFields lists the fields a query selects and sorts on. Conditions
+// combined with $or or $and cannot use an index and are left out.
+func queryFields(t *testing.T, query string) string {
+	var parsed struct {
+		Selector map[string]json.RawMessage `json:"selector"`
+		Sort     []map[string]string        `json:"sort"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(query), &parsed), query)
+
+	fields := map[string]bool{}
+	for field := range parsed.Selector {
+		if !strings.HasPrefix(field, "$") {
+			fields[field] = true
+		}
+	}
+	for _, sortField := range parsed.Sort {
+		for field := range sortField {
+			fields[field] = true
+		}
+	}
+	return fieldSet(fields)
+}
+
+// readIndexes returns the fields of the indexes of the world state, under "",
+// and of each private data collection.
+func readIndexes(t *testing.T) map[string][]string {
+	indexes := map[string][]string{}
+
+	files, err := filepath.Glob(filepath.Join(couchDBIndexes, "indexes", "*.json"))
+	require.NoError(t, err)
+	collectionFiles, err := filepath.Glob(filepath.Join(couchDBIndexes, "collections", "*", "indexes", "*.json"))
+	require.NoError(t, err)
+
+	for _, file := range append(files, collectionFiles...) {
+		collection := ""
+		if relative, _ := filepath.Rel(filepath.Join(couchDBIndexes, "collections"), file); !strings.HasPrefix(relative, "..") {
+			collection = strings.Split(relative, string(filepath.Separator))[0]
+		}
+
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		var index struct {
+			Index struct {
+				Fields []interface{} `json:"fields"`
+			} `json:"index"`
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}
+		require.NoError(t, json.Unmarshal(content, &index), file)
+		require.Equal(t, "json", index.Type, file)
+		require.NotEmpty(t, index.Name, file)
+
+		fields := map[string]bool{}
+		for _, field := range index.Index.Fields {
+			switch field := field.(type) {
+			case string:
+				fields[field] = true
+			case map[string]interface{}:
+				for name := range field {
+					fields[name] = true
+				}
+			}
+		}
+		indexes[collection] = append(indexes[collection], fieldSet(fields))
+	}
+	return indexes
+}
+
+func fieldSet(fields map[string]bool) string {
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,265 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const interestRateObjectType = "interestRate"
+
+// accountProduct treats accounts opened before products were introduced as
+// current accounts.
+func accountProduct(account *model.BankAccount) model.AccountProduct {
+	if account.Product == "" {
+		return model.ProductCurrent
+	}
+	return account.Product
+}
+
+// parseAccountProduct reads a product name, accounts are current accounts
+// unless asked otherwise.
+func parseAccountProduct(product string) (model.AccountProduct, error) {
+	switch parsed := model.AccountProduct(strings.ToUpper(strings.TrimSpace(product))); parsed {
+	case "":
+		return model.ProductCurrent, nil
+	case model.ProductCurrent, model.ProductSavings:
+		return parsed, nil
+	default:
+		return "", fmt.Errorf("invalid account product: %s", product)
+	}
+}
+
+func interestRateKey(ctx contractapi.TransactionContextInterface, bankID string, product model.AccountProduct) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(interestRateObjectType, []string{bankID, string(product)})
+}
+
+// SetInterestRate sets the yearly interest the bank pays on accounts of the
+// product, a zero rate stops paying it. The rate applies from the next accrual
+// on, to the whole period since the previous one.
+func (s *SmartContract) SetInterestRate(ctx contractapi.TransactionContextInterface, bankID, productStr, rateStr string) (*model.InterestRate, error) {
+	if err := assertBankAdmin(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	bank, err := readBank(ctx, bankID)
+	if err != nil {
+		return nil, err
+	}
+	product, err := parseAccountProduct(productStr)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := utils.ParseInterestRate(rateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := interestRateKey(ctx, bank.ID, product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create interest rate key: %v", err)
+	}
+	interestRate := model.InterestRate{
+		DocType: model.InterestRateDocType,
+		ID:      key,
+		BankID:  bank.ID,
+		Product: product,
+		Rate:    rate.FloatString(utils.RateDecimals),
+	}
+	if err := utils.PutDataToState(ctx, interestRate, key); err != nil {
+		return nil, err
+	}
+	return &interestRate, nil
+}
+
+func (s *SmartContract) GetInterestRates(ctx contractapi.TransactionContextInterface, bankID string) ([]model.InterestRate, error) {
+	results, err := ctx.GetStub().GetStateByPartialCompositeKey(interestRateObjectType, []string{bankID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interest rates: %v", err)
+	}
+	defer results.Close()
+
+	var rates []model.InterestRate
+	for results.HasNext() {
+		result, err := results.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate interest rates: %v", err)
+		}
+
+		var rate model.InterestRate
+		if err := json.Unmarshal(result.Value, &rate); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal interest rate: %v", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// maxInterestAccountsPerRun bounds the accounts AccrueInterest pays in one
+// transaction, so that a run neither grows with the bank nor conflicts with
+// every payment made while it is endorsed.
+const maxInterestAccountsPerRun = 100
+
+// AccrueInterest pays the interest the accounts earned on their positive
+// balances since their previous accrual, at the rate the bank pays on their
+// product. The accounts are named by ID, at most maxInterestAccountsPerRun of
+// them, and have to belong to the bank; GetAccountsByBankWithPagination lists
+// them page by page. Whole minor units are credited, each credit recorded as
+// a transaction, and fractions are carried over to the next accrual.
+func (s *SmartContract) AccrueInterest(ctx contractapi.TransactionContextInterface, bankID string, accountIDs []string) ([]model.Transaction, error) {
+	if err := assertBankAdmin(ctx, bankID); err != nil {
+		return nil, err
+	}
+	if len(accountIDs) > maxInterestAccountsPerRun {
+		return nil, fmt.Errorf("interest is accrued for at most %d accounts at a time", maxInterestAccountsPerRun)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	now := timestamp.AsTime()
+
+	var credits []model.Transaction
+	var movements []model.AccountMovementEvent
+	seen := map[string]bool{}
+	for _, accountID := range accountIDs {
+		// Writes of this transaction cannot be read back, an account named twice would be paid twice
+		if seen[accountID] {
+			return nil, fmt.Errorf("bank account %s is named more than once", accountID)
+		}
+		seen[accountID] = true
+
+		account, err := readBankAccount(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if account.BankID != bankID {
+			return nil, fmt.Errorf("bank account %s is not an account of bank %s", accountID, bankID)
+		}
+		if accountStatus(account) == model.AccountClosed {
+			continue
+		}
+
+		if err := accrueInterest(ctx, account, now); err != nil {
+			return nil, err
+		}
+		accrued, err := utils.ParseAccruedInterest(account.AccruedInterest)
+		if err != nil {
+			return nil, err
+		}
+		credit, remainder := utils.WholeMinorUnits(accrued)
+
+		account.Balance += credit
+		account.AccruedInterest = remainder.FloatString(utils.InterestDecimals)
+		account.LastOperation = model.OperationUpdate
+		if credit > 0 {
+			account.LastOperation = model.OperationInterest
+		}
+		if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+			return nil, err
+		}
+		if credit <= 0 {
+			continue
+		}
+
+		recorded, err := recordTransaction(ctx, model.Transaction{
+			ID:                 fmt.Sprintf("%s-%d", ctx.GetStub().GetTxID(), len(credits)),
+			Type:               model.TransactionInterest,
+			DestinationAccount: account.ID,
+			Amount:             credit,
+			Currency:           account.Currency,
+			ConvertedAmount:    credit,
+			ConvertedCurrency:  account.Currency,
+			Rate:               utils.SameCurrencyRate,
+		})
+		if err != nil {
+			return nil, err
+		}
+		credits = append(credits, *recorded)
+		movements = append(movements, model.AccountMovementEvent{
+			TxID:      recorded.ID,
+			Account:   account.ID,
+			UserID:    account.UserID,
+			Amount:    credit,
+			Currency:  account.Currency,
+			Balance:   account.Balance,
+			Timestamp: now,
+		})
+	}
+
+	if len(movements) > 0 {
+		if err := emitEvent(ctx, model.EventInterestCredited, model.InterestCreditedEvent{
+			TxID:      ctx.GetStub().GetTxID(),
+			Credits:   movements,
+			Timestamp: now,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return credits, nil
+}
+
+// accrueInterest brings the interest on the balance of the account up to the
+// time the balance changes: savings interest on a positive balance and
+// overdraft interest on a negative one.
+func accrueInterest(ctx contractapi.TransactionContextInterface, account *model.BankAccount, at time.Time) error {
+	if err := accrueSavingsInterest(ctx, account, at); err != nil {
+		return err
+	}
+	return accrueOverdraftInterest(account, at)
+}
+
+// accrueSavingsInterest adds the interest on a positive balance since the
+// previous accrual, at the rate the bank pays on the product of the account
+// now. It is paid out by AccrueInterest.
+func accrueSavingsInterest(ctx contractapi.TransactionContextInterface, account *model.BankAccount, at time.Time) error {
+	if account.Balance > 0 && !account.InterestAccruedAt.IsZero() {
+		rate, err := interestRate(ctx, account.BankID, accountProduct(account))
+		if err != nil {
+			return err
+		}
+		if rate.Sign() > 0 {
+			accrued, err := utils.ParseAccruedInterest(account.AccruedInterest)
+			if err != nil {
+				return err
+			}
+
+			accrued.Add(accrued, utils.AccrueInterest(account.Balance, rate, account.InterestAccruedAt, at))
+			account.AccruedInterest = accrued.FloatString(utils.InterestDecimals)
+		}
+	}
+
+	account.InterestAccruedAt = at
+	return nil
+}
+
+// interestRate returns the yearly rate the bank pays on the product, zero
+// when it pays none.
+func interestRate(ctx contractapi.TransactionContextInterface, bankID string, product model.AccountProduct) (*big.Rat, error) {
+	key, err := interestRateKey(ctx, bankID, product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create interest rate key: %v", err)
+	}
+
+	rateJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if rateJSON == nil {
+		return new(big.Rat), nil
+	}
+
+	var rate model.InterestRate
+	if err := json.Unmarshal(rateJSON, &rate); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal interest rate: %v", err)
+	}
+	return utils.ParseInterestRate(rate.Rate)
+}
@@ -0,0 +1,163 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAccrueInterest(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	opened := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(opened.AddDate(0, 0, 11)), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	// Current accounts earn nothing without a rate of their own
+	state := map[string][]byte{
+		"b1":                      []byte(`{"docType":"bank","ID":"b1"}`),
+		"interestRate~b1~SAVINGS": []byte(`{"docType":"interestRate","bank_id":"b1","product":"SAVINGS","rate":"0.0365"}`),
+		"a1":                      []byte(`{"ID":"a1","currency":"EUR","balance":100001,"bank_id":"b1","product":"SAVINGS","interest_accrued_at":"2024-01-02T00:00:00Z"}`),
+		"a2":                      []byte(`{"ID":"a2","currency":"EUR","balance":100000,"bank_id":"b1","interest_accrued_at":"2024-01-02T00:00:00Z"}`),
+		"a3":                      []byte(`{"ID":"a3","currency":"EUR","balance":100000,"bank_id":"b2","product":"SAVINGS"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	credits, err := smartContract.AccrueInterest(transactionContext, "b1", []string{"a1", "a2"})
+	require.NoError(t, err)
+	require.Len(t, credits, 1)
+	require.Equal(t, "tx1-0", credits[0].ID)
+	require.Equal(t, model.TransactionInterest, credits[0].Type)
+	require.Equal(t, "a1", credits[0].DestinationAccount)
+	require.Equal(t, int64(100), credits[0].Amount)
+
+	// Balances are neither queried nor read from the history
+	require.Equal(t, 0, chaincodeStub.GetQueryResultCallCount())
+	require.Equal(t, 0, chaincodeStub.GetHistoryForKeyCallCount())
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "a1", key)
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &account))
+	require.Equal(t, int64(100101), account.Balance)
+	require.Equal(t, "0.00100000", account.AccruedInterest)
+	require.Equal(t, opened.AddDate(0, 0, 11), account.InterestAccruedAt)
+	require.Equal(t, model.OperationInterest, account.LastOperation)
+
+	key, value = chaincodeStub.PutStateArgsForCall(2)
+	require.Equal(t, "a2", key)
+	require.NoError(t, json.Unmarshal(value, &account))
+	require.Equal(t, int64(100000), account.Balance)
+	require.Equal(t, opened.AddDate(0, 0, 11), account.InterestAccruedAt)
+	require.Equal(t, 3, chaincodeStub.PutStateCallCount())
+
+	require.Equal(t, 1, chaincodeStub.SetEventCallCount())
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, model.EventInterestCredited, name)
+	var event model.InterestCreditedEvent
+	require.NoError(t, json.Unmarshal(payload, &event))
+	require.Equal(t, "tx1", event.TxID)
+	require.Equal(t, []model.AccountMovementEvent{{
+		TxID:      "tx1-0",
+		Account:   "a1",
+		Amount:    100,
+		Currency:  model.EUR,
+		Balance:   100101,
+		Timestamp: opened.AddDate(0, 0, 11),
+	}}, event.Credits)
+
+	// Test Case: Account of another bank
+	_, err = smartContract.AccrueInterest(transactionContext, "b1", []string{"a3"})
+	require.EqualError(t, err, "bank account a3 is not an account of bank b1")
+
+	// Test Case: Account named twice
+	_, err = smartContract.AccrueInterest(transactionContext, "b1", []string{"a2", "a2"})
+	require.EqualError(t, err, "bank account a2 is named more than once")
+
+	// Test Case: Batch too large
+	_, err = smartContract.AccrueInterest(transactionContext, "b1", make([]string, 101))
+	require.EqualError(t, err, "interest is accrued for at most 100 accounts at a time")
+
+	// Test Case: Not an administrator
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	_, err = smartContract.AccrueInterest(transactionContext, "b1", []string{"a1"})
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+}
+
+func TestMoneyWithdrawal_AccruesSavingsInterest(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"u1":                      []byte(`{"ID":"u1"}`),
+		"currency~EUR":            eurDefinition,
+		"interestRate~b1~SAVINGS": []byte(`{"docType":"interestRate","bank_id":"b1","product":"SAVINGS","rate":"0.0365"}`),
+		"a1":                      []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":100000,"bank_id":"b1","product":"SAVINGS","interest_accrued_at":"2024-01-01T00:00:00Z"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.GetQueryResultReturns(&mocks.StateQueryIterator{}, nil)
+
+	// The balance held before the withdrawal earned interest up to it
+	_, err := smartContract.MoneyWithdrawal(transactionContext, "a1", "500")
+	require.NoError(t, err)
+
+	_, value := chaincodeStub.PutStateArgsForCall(0)
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &account))
+	require.Equal(t, int64(50000), account.Balance)
+	require.Equal(t, "100.00000000", account.AccruedInterest)
+	require.Equal(t, time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC), account.InterestAccruedAt)
+}
+
+func TestSetInterestRate(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	chaincodeStub.GetStateReturns([]byte(`{"docType":"bank","ID":"b1"}`), nil)
+
+	rate, err := smartContract.SetInterestRate(transactionContext, "b1", "savings", "0.025")
+	require.NoError(t, err)
+	require.Equal(t, model.ProductSavings, rate.Product)
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "interestRate~b1~SAVINGS", key)
+	require.JSONEq(t, `{"docType":"interestRate","ID":"interestRate~b1~SAVINGS","bank_id":"b1","product":"SAVINGS","rate":"0.02500000"}`, string(value))
+
+	// Test Case: Unknown product
+	_, err = smartContract.SetInterestRate(transactionContext, "b1", "deposit", "0.025")
+	require.EqualError(t, err, "invalid account product: deposit")
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
@@ -0,0 +1,292 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const bankLimitsObjectType = "bankLimits"
+
+const spendingDateLayout = "2006-01-02"
+
+func bankLimitsKey(ctx contractapi.TransactionContextInterface, bankID string, currency model.Currency) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(bankLimitsObjectType, []string{bankID, string(currency)})
+}
+
+// readBankLimits returns nothing when the bank has no defaults for the currency.
+func readBankLimits(ctx contractapi.TransactionContextInterface, bankID string, currency model.Currency) (*model.BankLimits, error) {
+	key, err := bankLimitsKey(ctx, bankID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bank limits key: %v", err)
+	}
+
+	limitsJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if limitsJSON == nil {
+		return nil, nil
+	}
+
+	var limits model.BankLimits
+	if err := json.Unmarshal(limitsJSON, &limits); err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// effectiveLimits returns the limits of the account, or the defaults of its
+// bank for the account currency when it has none.
+func effectiveLimits(ctx contractapi.TransactionContextInterface, account *model.BankAccount) (model.SpendingLimits, model.LimitsSource, error) {
+	if account.Limits != nil {
+		return *account.Limits, model.LimitsFromAccount, nil
+	}
+	if account.BankID == "" {
+		return model.SpendingLimits{}, model.LimitsNone, nil
+	}
+
+	bankLimits, err := readBankLimits(ctx, account.BankID, account.Currency)
+	if err != nil {
+		return model.SpendingLimits{}, "", err
+	}
+	if bankLimits == nil {
+		return model.SpendingLimits{}, model.LimitsNone, nil
+	}
+	return bankLimits.SpendingLimits, model.LimitsFromBank, nil
+}
+
+// spendingOn returns the running total of the day, totals of earlier days no
+// longer count.
+func spendingOn(account *model.BankAccount, at time.Time) model.DailySpending {
+	date := at.UTC().Format(spendingDateLayout)
+	if account.Spending == nil || account.Spending.Date != date {
+		return model.DailySpending{Date: date}
+	}
+	return *account.Spending
+}
+
+// chargeSpendingLimits checks a withdrawal or an outgoing transfer against the
+// limits in force and adds it to the running total of the day.
+func chargeSpendingLimits(ctx contractapi.TransactionContextInterface, account *model.BankAccount, transactionType model.TransactionType, amount int64, at time.Time) error {
+	limits, _, err := effectiveLimits(ctx, account)
+	if err != nil {
+		return err
+	}
+	if limits.PerTransaction > 0 && amount > limits.PerTransaction {
+		return fmt.Errorf("amount exceeds the per-transaction limit of bank account %s", account.ID)
+	}
+
+	spending := spendingOn(account, at)
+	switch transactionType {
+	case model.TransactionWithdrawal:
+		if limits.DailyWithdrawal > 0 && spending.Withdrawn+amount > limits.DailyWithdrawal {
+			return fmt.Errorf("amount exceeds the daily withdrawal limit of bank account %s", account.ID)
+		}
+		spending.Withdrawn += amount
+	case model.TransactionTransfer:
+		if limits.DailyTransfer > 0 && spending.Transferred+amount > limits.DailyTransfer {
+			return fmt.Errorf("amount exceeds the daily transfer limit of bank account %s", account.ID)
+		}
+		spending.Transferred += amount
+	default:
+		return fmt.Errorf("spending limits do not apply to %s transactions", transactionType)
+	}
+
+	account.Spending = &spending
+	return nil
+}
+
+// refundSpendingLimits takes an outgoing transfer charged at the time back
+// out of the running total, e.g. when money held for it is released. Totals
+// of earlier days no longer count and are left as they are.
+func refundSpendingLimits(account *model.BankAccount, amount int64, chargedAt time.Time) {
+	if account.Spending == nil || account.Spending.Date != chargedAt.UTC().Format(spendingDateLayout) {
+		return
+	}
+
+	spending := *account.Spending
+	spending.Transferred -= amount
+	if spending.Transferred < 0 {
+		spending.Transferred = 0
+	}
+	account.Spending = &spending
+}
+
+// parseSpendingLimits reads limits given in major units of the currency, an
+// empty limit does not restrict anything.
+func parseSpendingLimits(currency model.CurrencyDefinition, perTransaction, dailyWithdrawal, dailyTransfer string) (model.SpendingLimits, error) {
+	var limits model.SpendingLimits
+	for _, limit := range []struct {
+		value string
+		dest  *int64
+	}{
+		{perTransaction, &limits.PerTransaction},
+		{dailyWithdrawal, &limits.DailyWithdrawal},
+		{dailyTransfer, &limits.DailyTransfer},
+	} {
+		if strings.TrimSpace(limit.value) == "" {
+			continue
+		}
+		amount, err := utils.ParseAmount(limit.value, currency)
+		if err != nil {
+			return model.SpendingLimits{}, err
+		}
+		if amount < 0 {
+			return model.SpendingLimits{}, fmt.Errorf("spending limits cannot be negative")
+		}
+		*limit.dest = amount
+	}
+	return limits, nil
+}
+
+func (s *SmartContract) GetAccountLimits(ctx contractapi.TransactionContextInterface, accountID string) (*model.AccountLimits, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, account.BankID); err != nil {
+		return nil, err
+	}
+	return accountLimits(ctx, account)
+}
+
+// SetAccountLimits gives the account limits of its own in place of the
+// defaults of its bank. Empty limits do not restrict anything.
+func (s *SmartContract) SetAccountLimits(ctx contractapi.TransactionContextInterface, accountID, perTransaction, dailyWithdrawal, dailyTransfer string) (*model.AccountLimits, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, account.BankID); err != nil {
+		return nil, err
+	}
+	if accountStatus(account) == model.AccountClosed {
+		return nil, fmt.Errorf("bank account %s is closed", accountID)
+	}
+
+	currency, err := readCurrency(ctx, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := parseSpendingLimits(*currency, perTransaction, dailyWithdrawal, dailyTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Limits = &limits
+	account.LastOperation = model.OperationUpdate
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+	return accountLimits(ctx, account)
+}
+
+// ResetAccountLimits drops the limits of the account, the defaults of its bank
+// apply again.
+func (s *SmartContract) ResetAccountLimits(ctx contractapi.TransactionContextInterface, accountID string) (*model.AccountLimits, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, account.BankID); err != nil {
+		return nil, err
+	}
+
+	account.Limits = nil
+	account.LastOperation = model.OperationUpdate
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+	return accountLimits(ctx, account)
+}
+
+func accountLimits(ctx contractapi.TransactionContextInterface, account *model.BankAccount) (*model.AccountLimits, error) {
+	limits, source, err := effectiveLimits(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	return &model.AccountLimits{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		Source:         source,
+		SpendingLimits: limits,
+		Spending:       spendingOn(account, timestamp.AsTime()),
+	}, nil
+}
+
+func (s *SmartContract) GetBankLimits(ctx contractapi.TransactionContextInterface, bankID string) ([]model.BankLimits, error) {
+	if err := assertBankAdmin(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	results, err := ctx.GetStub().GetStateByPartialCompositeKey(bankLimitsObjectType, []string{bankID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bank limits: %v", err)
+	}
+	defer results.Close()
+
+	var bankLimits []model.BankLimits
+	for results.HasNext() {
+		result, err := results.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate bank limits: %v", err)
+		}
+
+		var limits model.BankLimits
+		if err := json.Unmarshal(result.Value, &limits); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bank limits: %v", err)
+		}
+		bankLimits = append(bankLimits, limits)
+	}
+
+	return bankLimits, nil
+}
+
+// SetBankLimits sets the defaults for accounts of the bank in the currency.
+// Empty limits do not restrict anything.
+func (s *SmartContract) SetBankLimits(ctx contractapi.TransactionContextInterface, bankID, currencyCode, perTransaction, dailyWithdrawal, dailyTransfer string) (*model.BankLimits, error) {
+	if err := assertBankAdmin(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	bank, err := readBank(ctx, bankID)
+	if err != nil {
+		return nil, err
+	}
+	currency, err := readCurrency(ctx, normalizeCurrencyCode(currencyCode))
+	if err != nil {
+		return nil, err
+	}
+	limits, err := parseSpendingLimits(*currency, perTransaction, dailyWithdrawal, dailyTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := bankLimitsKey(ctx, bank.ID, currency.Code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bank limits key: %v", err)
+	}
+	bankLimits := model.BankLimits{
+		DocType:        model.BankLimitsDocType,
+		ID:             key,
+		BankID:         bank.ID,
+		Currency:       currency.Code,
+		SpendingLimits: limits,
+	}
+	if err := utils.PutDataToState(ctx, bankLimits, key); err != nil {
+		return nil, err
+	}
+	return &bankLimits, nil
+}
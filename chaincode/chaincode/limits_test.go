@@ -0,0 +1,148 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestMoneyWithdrawal_DailyLimit(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	account := `{"ID":"a1","user_id":"u1","currency":"EUR","balance":100000,"limits":{"daily_withdrawal":50000},"spending":{"date":"2024-02-01","withdrawn":30000,"transferred":0}}`
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(account), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+
+	_, err := smartContract.MoneyWithdrawal(transactionContext, "a1", "200")
+	require.NoError(t, err)
+
+	_, value := chaincodeStub.PutStateArgsForCall(0)
+	var updated model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &updated))
+	require.Equal(t, &model.DailySpending{Date: "2024-02-01", Withdrawn: 500_00}, updated.Spending)
+
+	// Test Case: Limit of the day used up
+	chaincodeStub.GetStateReturnsOnCall(3, value, nil)
+	chaincodeStub.GetStateReturnsOnCall(4, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(5, eurDefinition, nil)
+	_, err = smartContract.MoneyWithdrawal(transactionContext, "a1", "0.01")
+	require.EqualError(t, err, "amount exceeds the daily withdrawal limit of bank account a1")
+	require.Equal(t, 2, chaincodeStub.PutStateCallCount())
+
+	// Test Case: The total starts over on the next day
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 2, 0, 0, 1, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(6, value, nil)
+	chaincodeStub.GetStateReturnsOnCall(7, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(8, eurDefinition, nil)
+	_, err = smartContract.MoneyWithdrawal(transactionContext, "a1", "100")
+	require.NoError(t, err)
+
+	_, value = chaincodeStub.PutStateArgsForCall(2)
+	require.NoError(t, json.Unmarshal(value, &updated))
+	require.Equal(t, &model.DailySpending{Date: "2024-02-02", Withdrawn: 100_00}, updated.Spending)
+}
+
+func TestTransferMoney_BankDefaultLimits(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":100000,"bank_id":"b1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"docType":"bankLimits","bank_id":"b1","currency":"EUR","per_transaction":25000}`), nil)
+
+	_, err := smartContract.TransferMoney(transactionContext, "a1", "a2", "250.01")
+	require.EqualError(t, err, "amount exceeds the per-transaction limit of bank account a1")
+	require.Equal(t, "bankLimits~b1~EUR", chaincodeStub.GetStateArgsForCall(3))
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestSetBankLimits(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"b1":           []byte(`{"docType":"bank","ID":"b1","msp_id":"Org1MSP"}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	limits, err := smartContract.SetBankLimits(transactionContext, "b1", "eur", "500", "1000", "")
+	require.NoError(t, err)
+	require.Equal(t, model.SpendingLimits{PerTransaction: 500_00, DailyWithdrawal: 1000_00}, limits.SpendingLimits)
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "bankLimits~b1~EUR", key)
+	require.JSONEq(t, `{"docType":"bankLimits","ID":"bankLimits~b1~EUR","bank_id":"b1","currency":"EUR","per_transaction":50000,"daily_withdrawal":100000}`, string(value))
+
+	// Test Case: Negative limit
+	_, err = smartContract.SetBankLimits(transactionContext, "b1", "EUR", "-1", "", "")
+	require.EqualError(t, err, "spending limits cannot be negative")
+
+	// Test Case: Administrator of another organization
+	transactionContext.GetClientIdentityReturns(organizationAdminIdentity("Org2MSP"))
+	_, err = smartContract.SetBankLimits(transactionContext, "b1", "EUR", "500", "", "")
+	require.EqualError(t, err, "only administrators of the organization running bank b1 are allowed to perform this action")
+
+	// Test Case: Not an administrator
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	_, err = smartContract.SetBankLimits(transactionContext, "b1", "EUR", "500", "", "")
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
+
+func TestSetAccountLimits(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":100000,"bank_id":"b1","spending":{"date":"2024-02-01","withdrawn":0,"transferred":20000}}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"docType":"bank","ID":"b1","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+
+	limits, err := smartContract.SetAccountLimits(transactionContext, "a1", "", "", "300")
+	require.NoError(t, err)
+	require.Equal(t, &model.AccountLimits{
+		AccountID:      "a1",
+		Currency:       model.EUR,
+		Source:         model.LimitsFromAccount,
+		SpendingLimits: model.SpendingLimits{DailyTransfer: 300_00},
+		Spending:       model.DailySpending{Date: "2024-02-01", Transferred: 200_00},
+	}, limits)
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
@@ -0,0 +1,596 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const maxLoanTermMonths = 360
+
+const loanFundingAccountObjectType = "loanFundingAccount"
+
+func loanFundingAccountKey(ctx contractapi.TransactionContextInterface, bankID string, currency model.Currency) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(loanFundingAccountObjectType, []string{bankID, string(currency)})
+}
+
+// ApplyForLoan asks for a loan paid out into and repaid from one of the
+// caller's accounts. The bank of the account sets the rate when approving it.
+func (s *SmartContract) ApplyForLoan(ctx contractapi.TransactionContextInterface, accountID, amountStr string, termMonths int) (*model.Loan, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := assertAccountOwner(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+
+	currency, err := readCurrency(ctx, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(amountStr, *currency)
+	if err != nil {
+		return nil, err
+	}
+	if termMonths < 1 || termMonths > maxLoanTermMonths {
+		return nil, fmt.Errorf("loan term must be between 1 and %d months", maxLoanTermMonths)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	loan := model.Loan{
+		DocType:    model.LoanDocType,
+		ID:         ctx.GetStub().GetTxID(),
+		UserID:     identity.UserID,
+		AccountID:  account.ID,
+		BankID:     account.BankID,
+		Principal:  amount,
+		Currency:   account.Currency,
+		TermMonths: termMonths,
+		Status:     model.LoanPending,
+		AppliedAt:  timestamp.AsTime(),
+	}
+	if err := utils.PutDataToState(ctx, loan, loan.ID); err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+// ApproveLoan accepts a pending loan at a yearly interest rate.
+func (s *SmartContract) ApproveLoan(ctx contractapi.TransactionContextInterface, loanID, rateStr string) (*model.Loan, error) {
+	loan, err := readLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, loan.BankID); err != nil {
+		return nil, err
+	}
+	if loan.Status != model.LoanPending {
+		return nil, fmt.Errorf("loan %s is not pending approval", loanID)
+	}
+	rate, err := utils.ParseInterestRate(rateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	loan.Rate = rate.FloatString(utils.RateDecimals)
+	loan.ApprovedAt = timestamp.AsTime()
+	loan.Status = model.LoanApproved
+	if err := utils.PutDataToState(ctx, loan, loan.ID); err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+// RejectLoan turns down a loan that has not been disbursed yet.
+func (s *SmartContract) RejectLoan(ctx contractapi.TransactionContextInterface, loanID string) (*model.Loan, error) {
+	loan, err := readLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, loan.BankID); err != nil {
+		return nil, err
+	}
+	if loan.Status != model.LoanPending && loan.Status != model.LoanApproved {
+		return nil, fmt.Errorf("loan %s can no longer be rejected", loanID)
+	}
+
+	loan.Status = model.LoanRejected
+	if err := utils.PutDataToState(ctx, loan, loan.ID); err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+// DisburseLoan pays an approved loan out into its account from the loan
+// funding account of the bank and draws up the schedule of monthly
+// installments, the first one due a month from now.
+func (s *SmartContract) DisburseLoan(ctx contractapi.TransactionContextInterface, loanID string) (*model.Loan, error) {
+	loan, err := readLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, loan.BankID); err != nil {
+		return nil, err
+	}
+	if loan.Status != model.LoanApproved {
+		return nil, fmt.Errorf("loan %s is not approved", loanID)
+	}
+
+	account, err := readBankAccount(ctx, loan.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+	currency, err := readCurrency(ctx, loan.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	// Times are kept in whole seconds of UTC, so queries can compare them as strings
+	now := timestamp.AsTime().UTC().Truncate(time.Second)
+
+	installments, err := amortizationSchedule(loan.Principal, loan.Rate, loan.TermMonths, *currency, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := accrueInterest(ctx, account, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+	account.Balance += loan.Principal
+	account.LastOperation = model.OperationLoanDisbursement
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+	if err := postLoanFunding(ctx, loan, -loan.Principal); err != nil {
+		return nil, err
+	}
+
+	loan.DisbursedAt = now
+	loan.Installments = installments
+	loan.NextDueDate = installments[0].DueDate
+	for _, installment := range installments {
+		loan.Outstanding += installment.Amount
+	}
+	loan.Status = model.LoanActive
+	if err := utils.PutDataToState(ctx, loan, loan.ID); err != nil {
+		return nil, err
+	}
+
+	recorded, err := recordTransaction(ctx, model.Transaction{
+		Type:               model.TransactionLoanDisbursement,
+		DestinationAccount: account.ID,
+		Amount:             loan.Principal,
+		Currency:           loan.Currency,
+		ConvertedAmount:    loan.Principal,
+		ConvertedCurrency:  loan.Currency,
+		Rate:               utils.SameCurrencyRate,
+		Loan:               loan.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitEvent(ctx, model.EventLoanDisbursed, model.AccountMovementEvent{
+		TxID:      recorded.ID,
+		Account:   account.ID,
+		UserID:    account.UserID,
+		Amount:    loan.Principal,
+		Currency:  loan.Currency,
+		Balance:   account.Balance,
+		Timestamp: recorded.Timestamp,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+// RepayLoan pays the amount from the loan account towards its installments,
+// the oldest unpaid one first, back into the loan funding account of the bank.
+func (s *SmartContract) RepayLoan(ctx contractapi.TransactionContextInterface, loanID, amountStr string) (*model.Loan, error) {
+	loan, err := readOwnLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if loan.Status != model.LoanActive && loan.Status != model.LoanLate {
+		return nil, fmt.Errorf("loan %s is not being repaid", loanID)
+	}
+
+	account, err := readBankAccount(ctx, loan.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+	currency, err := readCurrency(ctx, loan.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(amountStr, *currency)
+	if err != nil {
+		return nil, err
+	}
+	if amount > loan.Outstanding {
+		return nil, fmt.Errorf("amount exceeds the outstanding balance of loan %s", loanID)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if err := expireHolds(ctx, account, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+	if availableFunds(account) < amount {
+		return nil, fmt.Errorf("not enough money")
+	}
+	if err := accrueInterest(ctx, account, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+	account.Balance -= amount
+	account.LastOperation = model.OperationLoanRepayment
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+	if err := postLoanFunding(ctx, loan, amount); err != nil {
+		return nil, err
+	}
+
+	remaining := amount
+	for i := range loan.Installments {
+		installment := &loan.Installments[i]
+		payment := installment.Amount - installment.Paid
+		if payment > remaining {
+			payment = remaining
+		}
+		installment.Paid += payment
+		remaining -= payment
+	}
+	loan.Repaid += amount
+	loan.Outstanding -= amount
+	updateLoanStatus(loan, timestamp.AsTime())
+	if err := utils.PutDataToState(ctx, loan, loan.ID); err != nil {
+		return nil, err
+	}
+
+	recorded, err := recordTransaction(ctx, model.Transaction{
+		Type:              model.TransactionLoanRepayment,
+		SourceAccount:     account.ID,
+		Amount:            amount,
+		Currency:          loan.Currency,
+		ConvertedAmount:   amount,
+		ConvertedCurrency: loan.Currency,
+		Rate:              utils.SameCurrencyRate,
+		Loan:              loan.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitEvent(ctx, model.EventLoanRepaid, model.AccountMovementEvent{
+		TxID:      recorded.ID,
+		Account:   account.ID,
+		UserID:    account.UserID,
+		Amount:    amount,
+		Currency:  loan.Currency,
+		Balance:   account.Balance,
+		Timestamp: recorded.Timestamp,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+// MarkLateLoans flags active loans of the banks run by the organization of
+// the caller with an installment past its due date that has not been paid in
+// full.
+func (s *SmartContract) MarkLateLoans(ctx contractapi.TransactionContextInterface) ([]model.Loan, error) {
+	banks, err := adminBanks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	loans, err := queryLoans(ctx, map[string]interface{}{
+		"docType":       model.LoanDocType,
+		"status":        model.LoanActive,
+		"next_due_date": map[string]interface{}{"$lt": timestamp.AsTime().UTC().Truncate(time.Second)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	late := []model.Loan{}
+	for _, loan := range loans {
+		if !banks[loan.BankID] {
+			continue
+		}
+		loan.Status = model.LoanLate
+		if err := utils.PutDataToState(ctx, loan, loan.ID); err != nil {
+			return nil, err
+		}
+		late = append(late, loan)
+	}
+	return late, nil
+}
+
+func (s *SmartContract) ReadLoan(ctx contractapi.TransactionContextInterface, id string) (*model.Loan, error) {
+	loan, err := readLoan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if assertBankAdmin(ctx, loan.BankID) == nil {
+		return loan, nil
+	}
+	return readOwnLoan(ctx, id)
+}
+
+// ListLoans returns the loans of the caller.
+func (s *SmartContract) ListLoans(ctx contractapi.TransactionContextInterface) ([]model.Loan, error) {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryLoans(ctx, map[string]interface{}{
+		"docType": model.LoanDocType,
+		"user_id": identity.UserID,
+	})
+}
+
+// GetLoansByStatus returns the loans in the status of the banks run by the
+// organization of the caller.
+func (s *SmartContract) GetLoansByStatus(ctx contractapi.TransactionContextInterface, status string) ([]model.Loan, error) {
+	banks, err := adminBanks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loans, err := queryLoans(ctx, map[string]interface{}{
+		"docType": model.LoanDocType,
+		"status":  status,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []model.Loan{}
+	for _, loan := range loans {
+		if banks[loan.BankID] {
+			result = append(result, loan)
+		}
+	}
+	return result, nil
+}
+
+// updateLoanStatus moves the next due date to the first installment not paid
+// in full, the loan is late while that date has passed.
+func updateLoanStatus(loan *model.Loan, at time.Time) {
+	loan.NextDueDate = time.Time{}
+	for _, installment := range loan.Installments {
+		if installment.Paid < installment.Amount {
+			loan.NextDueDate = installment.DueDate
+			break
+		}
+	}
+
+	switch {
+	case loan.NextDueDate.IsZero():
+		loan.Status = model.LoanRepaid
+	case loan.NextDueDate.Before(at):
+		loan.Status = model.LoanLate
+	default:
+		loan.Status = model.LoanActive
+	}
+}
+
+// amortizationSchedule splits a loan into equal monthly installments of
+// principal and interest, the last one settling what rounding left over.
+func amortizationSchedule(principal int64, rateStr string, termMonths int, currency model.CurrencyDefinition, disbursedAt time.Time) ([]model.LoanInstallment, error) {
+	yearlyRate, err := utils.ParseInterestRate(rateStr)
+	if err != nil {
+		return nil, err
+	}
+	monthlyRate := new(big.Rat).Quo(yearlyRate, big.NewRat(12, 1))
+
+	// principal * r / (1 - (1 + r)^-n), or principal / n without interest
+	payment := new(big.Rat).SetFrac64(principal, int64(termMonths))
+	if monthlyRate.Sign() > 0 {
+		growth := big.NewRat(1, 1)
+		factor := new(big.Rat).Add(big.NewRat(1, 1), monthlyRate)
+		for i := 0; i < termMonths; i++ {
+			growth.Mul(growth, factor)
+		}
+		payment.SetInt64(principal)
+		payment.Mul(payment, monthlyRate)
+		payment.Mul(payment, growth)
+		payment.Quo(payment, new(big.Rat).Sub(growth, big.NewRat(1, 1)))
+	}
+	installmentAmount, err := utils.Round(payment, currency.Rounding)
+	if err != nil {
+		return nil, err
+	}
+
+	installments := make([]model.LoanInstallment, 0, termMonths)
+	balance := principal
+	for number := 1; number <= termMonths; number++ {
+		interest, err := utils.Round(new(big.Rat).Mul(new(big.Rat).SetInt64(balance), monthlyRate), currency.Rounding)
+		if err != nil {
+			return nil, err
+		}
+		repaid := installmentAmount - interest
+		if number == termMonths || repaid > balance {
+			repaid = balance
+		}
+		balance -= repaid
+
+		installments = append(installments, model.LoanInstallment{
+			Number:    number,
+			DueDate:   scheduledRun(disbursedAt, model.ScheduleMonthly, number),
+			Principal: repaid,
+			Interest:  interest,
+			Amount:    repaid + interest,
+		})
+	}
+	return installments, nil
+}
+
+func readLoan(ctx contractapi.TransactionContextInterface, id string) (*model.Loan, error) {
+	loanJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if loanJSON == nil {
+		return nil, fmt.Errorf("the loan with id %s does not exist", id)
+	}
+
+	var loan model.Loan
+	if err := json.Unmarshal(loanJSON, &loan); err != nil {
+		return nil, err
+	}
+	if loan.DocType != model.LoanDocType {
+		return nil, fmt.Errorf("the loan with id %s does not exist", id)
+	}
+
+	return &loan, nil
+}
+
+// readOwnLoan reports loans of other users as missing, like their accounts.
+func readOwnLoan(ctx contractapi.TransactionContextInterface, id string) (*model.Loan, error) {
+	loan, err := readLoan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if loan.UserID != identity.UserID {
+		return nil, fmt.Errorf("the loan with id %s does not exist", id)
+	}
+
+	return loan, nil
+}
+
+func queryLoans(ctx contractapi.TransactionContextInterface, selector map[string]interface{}) ([]model.Loan, error) {
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %v", err)
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	var loans []model.Loan
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var loan model.Loan
+		if err := json.Unmarshal(queryResult.Value, &loan); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal loan: %v", err)
+		}
+		loans = append(loans, loan)
+	}
+
+	return loans, nil
+}
+
+// postLoanFunding adds the amount to the position of the account the bank
+// lends from in the currency of the loan, disbursements post negative amounts.
+func postLoanFunding(ctx contractapi.TransactionContextInterface, loan *model.Loan, amount int64) error {
+	key, err := loanFundingAccountKey(ctx, loan.BankID, loan.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to create loan funding account key: %v", err)
+	}
+
+	accountJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	account := model.LoanFundingAccount{
+		DocType:  model.LoanFundingAccountDocType,
+		ID:       key,
+		BankID:   loan.BankID,
+		Currency: loan.Currency,
+	}
+	if accountJSON != nil {
+		if err := json.Unmarshal(accountJSON, &account); err != nil {
+			return err
+		}
+	}
+
+	account.Position += amount
+	return utils.PutDataToState(ctx, account, key)
+}
+
+func (s *SmartContract) GetLoanFundingAccounts(ctx contractapi.TransactionContextInterface, bankID string) ([]model.LoanFundingAccount, error) {
+	if err := assertBankAdmin(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	results, err := ctx.GetStub().GetStateByPartialCompositeKey(loanFundingAccountObjectType, []string{bankID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read loan funding accounts: %v", err)
+	}
+	defer results.Close()
+
+	var accounts []model.LoanFundingAccount
+	for results.HasNext() {
+		result, err := results.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate loan funding accounts: %v", err)
+		}
+
+		var account model.LoanFundingAccount
+		if err := json.Unmarshal(result.Value, &account); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal loan funding account: %v", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
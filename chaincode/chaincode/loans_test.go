@@ -0,0 +1,120 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestLoanLifecycle(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	applied := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxIDReturns("loan1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(applied), nil)
+	state := map[string][]byte{
+		"a1":           []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":0,"bank_id":"b1"}`),
+		"u1":           []byte(`{"ID":"u1"}`),
+		"b1":           []byte(`{"docType":"bank","ID":"b1","name":"UniCredit","msp_id":"Org1MSP"}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		state[key] = value
+		return nil
+	}
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + attributes[0], nil
+	}
+
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	loan, err := smartContract.ApplyForLoan(transactionContext, "a1", "1200", 12)
+	require.NoError(t, err)
+	require.Equal(t, model.LoanPending, loan.Status)
+
+	// Test Case: Users cannot approve their own loans
+	_, err = smartContract.ApproveLoan(transactionContext, "loan1", "0.12")
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+
+	// Test Case: Administrators of other organizations cannot approve loans of the bank
+	transactionContext.GetClientIdentityReturns(organizationAdminIdentity("Org2MSP"))
+	_, err = smartContract.ApproveLoan(transactionContext, "loan1", "0.12")
+	require.EqualError(t, err, "only administrators of the organization running bank b1 are allowed to perform this action")
+
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	_, err = smartContract.DisburseLoan(transactionContext, "loan1")
+	require.EqualError(t, err, "loan loan1 is not approved")
+	_, err = smartContract.ApproveLoan(transactionContext, "loan1", "0.12")
+	require.NoError(t, err)
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	loan, err = smartContract.DisburseLoan(transactionContext, "loan1")
+	require.NoError(t, err)
+	require.Equal(t, model.LoanActive, loan.Status)
+	require.Len(t, loan.Installments, 12)
+
+	// 1% a month on 1200.00 comes to 106.62 a month
+	require.Equal(t, model.LoanInstallment{Number: 1, DueDate: time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC), Principal: 94_62, Interest: 12_00, Amount: 106_62}, loan.Installments[0])
+	var principal int64
+	for _, installment := range loan.Installments {
+		principal += installment.Principal
+	}
+	require.Equal(t, int64(1200_00), principal)
+	require.Equal(t, time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC), loan.Installments[11].DueDate)
+
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(state["a1"], &account))
+	require.Equal(t, int64(1200_00), account.Balance)
+
+	var disbursement model.Transaction
+	require.NoError(t, json.Unmarshal(state["tx1"], &disbursement))
+	require.Equal(t, model.TransactionLoanDisbursement, disbursement.Type)
+	require.Equal(t, "loan1", disbursement.Loan)
+
+	// The principal is lent from the bank, not created
+	require.JSONEq(t, `{"docType":"loanFundingAccount","ID":"loanFundingAccount~b1","bank_id":"b1","currency":"EUR","position":-120000}`, string(state["loanFundingAccount~b1"]))
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "LoanDisbursed", name)
+	require.JSONEq(t, `{"tx_id":"tx1","account":"a1","user_id":"u1","amount":120000,"currency":"EUR","balance":120000,"timestamp":"2024-01-31T09:00:00Z"}`, string(payload))
+
+	// Test Case: The first installment is paid and the second one is missed
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	chaincodeStub.GetTxIDReturns("tx2")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)), nil)
+	loan, err = smartContract.RepayLoan(transactionContext, "loan1", "150")
+	require.NoError(t, err)
+	require.Equal(t, model.LoanLate, loan.Status)
+	require.Equal(t, int64(106_62), loan.Installments[0].Paid)
+	require.Equal(t, int64(43_38), loan.Installments[1].Paid)
+	require.Equal(t, time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC), loan.NextDueDate)
+	require.Equal(t, loan.Outstanding+150_00, sumInstallments(loan))
+
+	require.JSONEq(t, `{"docType":"loanFundingAccount","ID":"loanFundingAccount~b1","bank_id":"b1","currency":"EUR","position":-105000}`, string(state["loanFundingAccount~b1"]))
+	name, _ = chaincodeStub.SetEventArgsForCall(1)
+	require.Equal(t, "LoanRepaid", name)
+
+	// Test Case: Loans of other users are not found
+	transactionContext.GetClientIdentityReturns(userIdentity("u2"))
+	_, err = smartContract.RepayLoan(transactionContext, "loan1", "10")
+	require.EqualError(t, err, "the loan with id loan1 does not exist")
+}
+
+func sumInstallments(loan *model.Loan) int64 {
+	var total int64
+	for _, installment := range loan.Installments {
+		total += installment.Amount
+	}
+	return total
+}
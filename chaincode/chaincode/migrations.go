@@ -0,0 +1,569 @@
+package chaincode
+
+import (
+	"bytes"
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const migrationObjectType = "migration"
+
+// Migrations are run by each organization, the MSP ID is appended
+const (
+	minorUnitsMigration     = "minor_units"
+	currencyCodesMigration  = "currency_codes"
+	bankReferencesMigration = "bank_references"
+	cardAssetsMigration     = "card_assets"
+	userPIIMigration        = "user_pii"
+)
+
+// MigrateUserPII derives the salts of users from a secret in the transient map
+const (
+	saltTransientKey    = "salt"
+	minSaltSecretLength = 16
+)
+
+// Currencies used to be stored as an enum, its values in declaration order
+var legacyCurrencies = []model.Currency{model.EUR, model.RSD}
+
+type migrationRecord struct {
+	Name string `json:"name"`
+	TxID string `json:"tx_id"`
+}
+
+func migrationCompleted(ctx contractapi.TransactionContextInterface, name string) (bool, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(migrationObjectType, []string{name})
+	if err != nil {
+		return false, fmt.Errorf("failed to create migration key: %v", err)
+	}
+
+	recordJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	return recordJSON != nil, nil
+}
+
+func markMigrationCompleted(ctx contractapi.TransactionContextInterface, name string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(migrationObjectType, []string{name})
+	if err != nil {
+		return fmt.Errorf("failed to create migration key: %v", err)
+	}
+
+	return utils.PutDataToState(ctx, migrationRecord{Name: name, TxID: ctx.GetStub().GetTxID()}, key)
+}
+
+// organizationMigration lets only administrators run migrations and returns
+// the MSP ID of their organization with the name of its run of the migration.
+func organizationMigration(ctx contractapi.TransactionContextInterface, name string) (string, string, error) {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if !identity.Admin {
+		return "", "", fmt.Errorf("only administrators are allowed to perform this action")
+	}
+	return identity.MSPID, name + "_" + identity.MSPID, nil
+}
+
+// migratesDocument tells whether the organization migrates the document: a
+// bank it runs, an account of such a bank or a transaction of such an
+// account. Documents of no bank, like exchange rates, are migrated by the
+// organization that runs the migration first.
+func migratesDocument(ctx contractapi.TransactionContextInterface, mspID, key string, document map[string]interface{}) (bool, error) {
+	if _, ok := document["pib"]; ok {
+		return runsBank(ctx, mspID, key)
+	}
+	if bankID := documentBank(document); bankID != "" {
+		return runsBank(ctx, mspID, bankID)
+	}
+
+	accountIDs := []string{}
+	for _, field := range []string{"source_account", "destination_account"} {
+		if accountID, _ := document[field].(string); accountID != "" {
+			accountIDs = append(accountIDs, accountID)
+		}
+	}
+	if len(accountIDs) == 0 {
+		return true, nil
+	}
+	for _, accountID := range accountIDs {
+		accountJSON, err := ctx.GetStub().GetState(accountID)
+		if err != nil {
+			return false, fmt.Errorf("failed to read from world state: %v", err)
+		}
+		if accountJSON == nil {
+			continue
+		}
+		var account map[string]interface{}
+		if err := json.Unmarshal(accountJSON, &account); err != nil {
+			return false, fmt.Errorf("failed to unmarshal bank account %s: %v", accountID, err)
+		}
+		runs, err := runsBank(ctx, mspID, documentBank(account))
+		if err != nil || runs {
+			return runs, err
+		}
+	}
+	return false, nil
+}
+
+func runsBank(ctx contractapi.TransactionContextInterface, mspID, bankID string) (bool, error) {
+	organization, err := bankOrganization(ctx, bankID)
+	if err != nil {
+		return false, err
+	}
+	return organization == mspID, nil
+}
+
+// documentBank returns the bank of an account, accounts stored before
+// MigrateBankReferences embed a copy of it.
+func documentBank(document map[string]interface{}) string {
+	if bankID, ok := document["bank_id"].(string); ok {
+		return bankID
+	}
+	bank, _ := document["bank"].(map[string]interface{})
+	bankID, _ := bank["ID"].(string)
+	return bankID
+}
+
+// MigrateBalancesToMinorUnits rewrites account balances stored as floating point
+// major units into integer minor units. Every organization runs it once for
+// the accounts of its banks.
+func (s *SmartContract) MigrateBalancesToMinorUnits(ctx contractapi.TransactionContextInterface) (int, error) {
+	mspID, migration, err := organizationMigration(ctx, minorUnitsMigration)
+	if err != nil {
+		return 0, err
+	}
+
+	completed, err := migrationCompleted(ctx, migration)
+	if err != nil {
+		return 0, err
+	}
+	if completed {
+		return 0, nil
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(`{"selector":{"user_id":{"$exists":true},"balance":{"$exists":true}}}`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	migrated := 0
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return 0, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		// Decoded generically so fields this version does not know about survive the rewrite
+		var account map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(queryResult.Value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&account); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal bank account %s: %v", queryResult.Key, err)
+		}
+		migrates, err := migratesDocument(ctx, mspID, queryResult.Key, account)
+		if err != nil {
+			return 0, err
+		}
+		if !migrates {
+			continue
+		}
+
+		balance, _ := account["balance"].(json.Number)
+		currencyCode, err := legacyCurrencyCode(account["currency"])
+		if err != nil {
+			return 0, fmt.Errorf("invalid currency of bank account %s: %v", queryResult.Key, err)
+		}
+		currency, err := seedCurrency(currencyCode)
+		if err != nil {
+			return 0, fmt.Errorf("invalid currency of bank account %s: %v", queryResult.Key, err)
+		}
+
+		majorBalance, ok := new(big.Rat).SetString(balance.String())
+		if !ok {
+			return 0, fmt.Errorf("invalid balance of bank account %s: %s", queryResult.Key, balance)
+		}
+
+		minorBalance, err := utils.ToMinorUnits(majorBalance, currency)
+		if err != nil {
+			return 0, fmt.Errorf("failed to migrate bank account %s: %v", queryResult.Key, err)
+		}
+
+		account["balance"] = minorBalance
+		if err := utils.PutDataToState(ctx, account, queryResult.Key); err != nil {
+			return 0, err
+		}
+		migrated++
+	}
+
+	if err := markMigrationCompleted(ctx, migration); err != nil {
+		return 0, err
+	}
+
+	return migrated, nil
+}
+
+// MigrateCurrenciesToCodes replaces the numeric currencies of accounts,
+// transactions and exchange rates with ISO 4217 codes and registers the
+// currencies they use. Every organization runs it once for the documents of
+// its banks.
+func (s *SmartContract) MigrateCurrenciesToCodes(ctx contractapi.TransactionContextInterface) (int, error) {
+	mspID, migration, err := organizationMigration(ctx, currencyCodesMigration)
+	if err != nil {
+		return 0, err
+	}
+
+	completed, err := migrationCompleted(ctx, migration)
+	if err != nil {
+		return 0, err
+	}
+	if completed {
+		return 0, nil
+	}
+
+	if err := seedCurrencies(ctx); err != nil {
+		return 0, err
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(`{"selector":{"$or":[{"currency":{"$type":"number"}},{"from":{"$type":"number"}}]}}`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	migrated := 0
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return 0, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var document map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(queryResult.Value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal %s: %v", queryResult.Key, err)
+		}
+		migrates, err := migratesDocument(ctx, mspID, queryResult.Key, document)
+		if err != nil {
+			return 0, err
+		}
+		if !migrates {
+			continue
+		}
+
+		for _, field := range []string{"currency", "converted_currency", "from", "to"} {
+			value, ok := document[field]
+			if !ok {
+				continue
+			}
+			code, err := legacyCurrencyCode(value)
+			if err != nil {
+				return 0, fmt.Errorf("invalid %s of %s: %v", field, queryResult.Key, err)
+			}
+			document[field] = code
+		}
+
+		if err := utils.PutDataToState(ctx, document, queryResult.Key); err != nil {
+			return 0, err
+		}
+		migrated++
+	}
+
+	if err := markMigrationCompleted(ctx, migration); err != nil {
+		return 0, err
+	}
+
+	return migrated, nil
+}
+
+// MigrateBankReferences replaces the copy of the bank embedded in every account
+// with a reference to it, and gives banks created before they had a document
+// type one. Every organization runs it once for its banks and their accounts.
+func (s *SmartContract) MigrateBankReferences(ctx contractapi.TransactionContextInterface) (int, error) {
+	mspID, migration, err := organizationMigration(ctx, bankReferencesMigration)
+	if err != nil {
+		return 0, err
+	}
+
+	completed, err := migrationCompleted(ctx, migration)
+	if err != nil {
+		return 0, err
+	}
+	if completed {
+		return 0, nil
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(`{"selector":{"$or":[{"bank":{"$exists":true}},{"pib":{"$exists":true},"docType":{"$exists":false}}]}}`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	migrated := 0
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return 0, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var document map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(queryResult.Value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal %s: %v", queryResult.Key, err)
+		}
+		migrates, err := migratesDocument(ctx, mspID, queryResult.Key, document)
+		if err != nil {
+			return 0, err
+		}
+		if !migrates {
+			continue
+		}
+
+		if embedded, ok := document["bank"]; ok {
+			bank, _ := embedded.(map[string]interface{})
+			bankID, _ := bank["ID"].(string)
+			if bankID == "" {
+				return 0, fmt.Errorf("bank account %s has no bank ID", queryResult.Key)
+			}
+			document["bank_id"] = bankID
+			delete(document, "bank")
+		} else {
+			document["docType"] = model.BankDocType
+		}
+
+		if err := utils.PutDataToState(ctx, document, queryResult.Key); err != nil {
+			return 0, err
+		}
+		migrated++
+	}
+
+	if err := markMigrationCompleted(ctx, migration); err != nil {
+		return 0, err
+	}
+
+	return migrated, nil
+}
+
+// MigrateCardsToAssets issues a card asset for every card network listed on
+// an account and removes the list from the account. Card currencies are taken
+// from the account, so currencies have to be migrated to codes first. Every
+// organization runs it once for the accounts of its banks.
+func (s *SmartContract) MigrateCardsToAssets(ctx contractapi.TransactionContextInterface) (int, error) {
+	mspID, migration, err := organizationMigration(ctx, cardAssetsMigration)
+	if err != nil {
+		return 0, err
+	}
+
+	completed, err := migrationCompleted(ctx, migration)
+	if err != nil {
+		return 0, err
+	}
+	if completed {
+		return 0, nil
+	}
+
+	currenciesMigrated, err := migrationCompleted(ctx, currencyCodesMigration+"_"+mspID)
+	if err != nil {
+		return 0, err
+	}
+	if !currenciesMigrated {
+		return 0, fmt.Errorf("currencies have to be migrated with MigrateCurrenciesToCodes first")
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(`{"selector":{"user_id":{"$exists":true},"cards":{"$exists":true}}}`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	migrated := 0
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return 0, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var document map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(queryResult.Value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal bank account %s: %v", queryResult.Key, err)
+		}
+		migrates, err := migratesDocument(ctx, mspID, queryResult.Key, document)
+		if err != nil {
+			return 0, err
+		}
+		if !migrates {
+			continue
+		}
+
+		account := model.BankAccount{ID: queryResult.Key}
+		account.UserID, _ = document["user_id"].(string)
+		currency, _ := document["currency"].(string)
+		account.Currency = model.Currency(currency)
+
+		cards, _ := document["cards"].([]interface{})
+		for i, card := range cards {
+			name, _ := card.(string)
+			if name == "" {
+				continue
+			}
+			network, err := utils.ParseCardNetwork(name)
+			if err != nil {
+				return 0, fmt.Errorf("invalid card of bank account %s: %v", queryResult.Key, err)
+			}
+			// Older ledgers kept no card numbers, the cards get one when replaced
+			if _, err := issueCard(ctx, &account, network, "", i); err != nil {
+				return 0, err
+			}
+		}
+
+		delete(document, "cards")
+		if err := utils.PutDataToState(ctx, document, queryResult.Key); err != nil {
+			return 0, err
+		}
+		migrated++
+	}
+
+	if err := markMigrationCompleted(ctx, migration); err != nil {
+		return 0, err
+	}
+
+	return migrated, nil
+}
+
+// MigrateUserPII moves the personal data of users registered by the
+// organization of the caller from the world state to the private data
+// collection of the organization. Every organization runs it once for its own
+// users. Each salt is derived from a secret passed in the transient map under
+// "salt", so neither the secret nor the salts are ever public. Users recorded
+// without an organization are left as they are and reported, an
+// administrator has to decide which organization keeps their data.
+func (s *SmartContract) MigrateUserPII(ctx contractapi.TransactionContextInterface) (*model.PIIMigration, error) {
+	mspID, migration, err := organizationMigration(ctx, userPIIMigration)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := migrationCompleted(ctx, migration)
+	if err != nil {
+		return nil, err
+	}
+	if completed {
+		return &model.PIIMigration{Unassigned: []string{}}, nil
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient map: %v", err)
+	}
+	secret := transient[saltTransientKey]
+	if len(secret) < minSaltSecretLength {
+		return nil, fmt.Errorf("a random secret of at least %d bytes has to be passed in the transient map under %q", minSaltSecretLength, saltTransientKey)
+	}
+
+	// Only users carried an email
+	queryResults, err := ctx.GetStub().GetQueryResult(`{"selector":{"email":{"$exists":true}}}`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	result := &model.PIIMigration{Unassigned: []string{}}
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var user model.UserDetails
+		if err := json.Unmarshal(queryResult.Value, &user); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal user %s: %v", queryResult.Key, err)
+		}
+		if user.MSPID == "" {
+			result.Unassigned = append(result.Unassigned, user.ID)
+			continue
+		}
+		if user.MSPID != mspID {
+			continue
+		}
+
+		if err := putUser(ctx, user, piiCollection(mspID), userSalt(secret, user.ID)); err != nil {
+			return nil, err
+		}
+		result.Migrated++
+	}
+
+	if err := markMigrationCompleted(ctx, migration); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func userSalt(secret []byte, userID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// seedCurrencies registers the initial currencies and base currency, keeping
+// whatever an administrator has already set up.
+func seedCurrencies(ctx contractapi.TransactionContextInterface) error {
+	currencies, settings := utils.InitializeCurrencies()
+	for _, currency := range currencies {
+		if _, err := readCurrency(ctx, currency.Code); err == nil {
+			continue
+		}
+		if err := putCurrency(ctx, currency); err != nil {
+			return err
+		}
+	}
+
+	current, err := readCurrencySettings(ctx)
+	if err != nil {
+		return err
+	}
+	if current.BaseCurrency != "" {
+		return nil
+	}
+	return putCurrencySettings(ctx, settings)
+}
+
+func seedCurrency(code model.Currency) (model.CurrencyDefinition, error) {
+	currencies, _ := utils.InitializeCurrencies()
+	for _, currency := range currencies {
+		if currency.Code == code {
+			return currency, nil
+		}
+	}
+	return model.CurrencyDefinition{}, fmt.Errorf("unknown currency %s", code)
+}
+
+func legacyCurrencyCode(value interface{}) (model.Currency, error) {
+	switch currency := value.(type) {
+	case string:
+		return model.Currency(currency), nil
+	case json.Number:
+		index, err := currency.Int64()
+		if err != nil || index < 0 || index >= int64(len(legacyCurrencies)) {
+			return "", fmt.Errorf("unknown currency %s", currency)
+		}
+		return legacyCurrencies[index], nil
+	default:
+		return "", fmt.Errorf("unknown currency %v", value)
+	}
+}
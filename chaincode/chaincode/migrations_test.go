@@ -0,0 +1,301 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateBalancesToMinorUnits(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyReturns("migration~minor_units_Org1MSP", nil)
+	// Seeded before banks had a document type or an organization
+	state := map[string][]byte{
+		"b1": []byte(`{"ID":"b1","name":"UniCredit","pib":138429230}`),
+		"b2": []byte(`{"ID":"b2","name":"Raiffeisen Bank","pib":537891234}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.HasNextReturnsOnCall(1, true)
+	iterator.HasNextReturnsOnCall(2, true)
+	iterator.HasNextReturnsOnCall(3, false)
+	iterator.NextReturnsOnCall(0, &queryresult.KV{Key: "a1", Value: []byte(`{"ID":"a1","balance":1500.255,"currency":1,"user_id":"u1","bank":{"ID":"b1"},"cards":["Visa"]}`)}, nil)
+	iterator.NextReturnsOnCall(1, &queryresult.KV{Key: "a2", Value: []byte(`{"ID":"a2","balance":0.125,"currency":0,"user_id":"u2","bank":{"ID":"b1"}}`)}, nil)
+	// Migrated by the organization running the bank
+	iterator.NextReturnsOnCall(2, &queryresult.KV{Key: "a3", Value: []byte(`{"ID":"a3","balance":10,"currency":0,"user_id":"u3","bank":{"ID":"b2"}}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	migrated, err := smartContract.MigrateBalancesToMinorUnits(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+
+	require.Equal(t, 3, chaincodeStub.PutStateCallCount())
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "a1", key)
+	require.JSONEq(t, `{"ID":"a1","balance":150026,"currency":1,"user_id":"u1","bank":{"ID":"b1"},"cards":["Visa"]}`, string(value))
+
+	// EUR rounds half to even
+	key, value = chaincodeStub.PutStateArgsForCall(1)
+	require.Equal(t, "a2", key)
+	require.JSONEq(t, `{"ID":"a2","balance":12,"currency":0,"user_id":"u2","bank":{"ID":"b1"}}`, string(value))
+
+	key, _ = chaincodeStub.PutStateArgsForCall(2)
+	require.Equal(t, "migration~minor_units_Org1MSP", key)
+}
+
+func TestMigrateBalancesToMinorUnits_AlreadyCompleted(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturns([]byte(`{"name":"minor_units"}`), nil)
+
+	migrated, err := smartContract.MigrateBalancesToMinorUnits(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 0, migrated)
+	require.Equal(t, 0, chaincodeStub.GetQueryResultCallCount())
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestMigrateCurrenciesToCodes(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	// EUR is registered already, everything else is missing
+	state := map[string][]byte{
+		"currency~EUR": eurDefinition,
+		"a1":           []byte(`{"ID":"a1","balance":150026,"currency":1,"user_id":"u1","bank_id":"b1"}`),
+		"a3":           []byte(`{"ID":"a3","balance":100,"currency":0,"user_id":"u3","bank_id":"b2"}`),
+		"b1":           []byte(`{"docType":"bank","ID":"b1","name":"UniCredit"}`),
+		"b2":           []byte(`{"docType":"bank","ID":"b2","name":"Raiffeisen Bank"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	iterator := &mocks.StateQueryIterator{}
+	for i := 0; i < 5; i++ {
+		iterator.HasNextReturnsOnCall(i, true)
+	}
+	iterator.NextReturnsOnCall(0, &queryresult.KV{Key: "a1", Value: state["a1"]}, nil)
+	iterator.NextReturnsOnCall(1, &queryresult.KV{Key: "tx1", Value: []byte(`{"docType":"transaction","source_account":"a1","currency":0,"converted_currency":1,"amount":100}`)}, nil)
+	iterator.NextReturnsOnCall(2, &queryresult.KV{Key: "rate1", Value: []byte(`{"docType":"exchangeRate","from":0,"to":1,"rate":"117.00000000"}`)}, nil)
+	// Migrated by the organization running the bank
+	iterator.NextReturnsOnCall(3, &queryresult.KV{Key: "a3", Value: state["a3"]}, nil)
+	iterator.NextReturnsOnCall(4, &queryresult.KV{Key: "tx2", Value: []byte(`{"docType":"transaction","destination_account":"a3","currency":0,"converted_currency":0,"amount":100}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	migrated, err := smartContract.MigrateCurrenciesToCodes(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 3, migrated)
+
+	written := map[string]string{}
+	for i := 0; i < chaincodeStub.PutStateCallCount(); i++ {
+		key, value := chaincodeStub.PutStateArgsForCall(i)
+		written[key] = string(value)
+	}
+	require.NotContains(t, written, "currency~EUR")
+	require.Contains(t, written, "currency~RSD")
+	require.Contains(t, written, "currency~USD")
+	require.JSONEq(t, `{"base_currency":"EUR"}`, written["currencySettings~"])
+	require.JSONEq(t, `{"ID":"a1","balance":150026,"currency":"RSD","user_id":"u1","bank_id":"b1"}`, written["a1"])
+	require.JSONEq(t, `{"docType":"transaction","source_account":"a1","currency":"EUR","converted_currency":"RSD","amount":100}`, written["tx1"])
+	require.JSONEq(t, `{"docType":"exchangeRate","from":"EUR","to":"RSD","rate":"117.00000000"}`, written["rate1"])
+	require.NotContains(t, written, "a3")
+	require.NotContains(t, written, "tx2")
+	require.Contains(t, written, "migration~currency_codes_Org1MSP")
+}
+
+func TestMigrateCurrenciesToCodes_UnknownCurrency(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.NextReturns(&queryresult.KV{Key: "a1", Value: []byte(`{"ID":"a1","currency":7}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	_, err := smartContract.MigrateCurrenciesToCodes(transactionContext)
+	require.EqualError(t, err, "invalid currency of a1: unknown currency 7")
+}
+
+func TestMigrateBankReferences(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyReturns("migration~bank_references_Org1MSP", nil)
+	legacyBank := []byte(`{"ID":"b1","name":"Banca Intesa","pib":123456789}`)
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		if key == "b1" {
+			return legacyBank, nil
+		}
+		return nil, nil
+	}
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.HasNextReturnsOnCall(1, true)
+	iterator.HasNextReturnsOnCall(2, false)
+	iterator.NextReturnsOnCall(0, &queryresult.KV{Key: "a1", Value: []byte(`{"ID":"a1","balance":150000,"currency":"RSD","bank":{"ID":"b1","name":"Banca Intesa","pib":123456789},"user_id":"u1"}`)}, nil)
+	iterator.NextReturnsOnCall(1, &queryresult.KV{Key: "b1", Value: legacyBank}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	migrated, err := smartContract.MigrateBankReferences(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+
+	require.Equal(t, 3, chaincodeStub.PutStateCallCount())
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "a1", key)
+	require.JSONEq(t, `{"ID":"a1","balance":150000,"currency":"RSD","bank_id":"b1","user_id":"u1"}`, string(value))
+
+	key, value = chaincodeStub.PutStateArgsForCall(1)
+	require.Equal(t, "b1", key)
+	require.JSONEq(t, `{"docType":"bank","ID":"b1","name":"Banca Intesa","pib":123456789}`, string(value))
+
+	key, _ = chaincodeStub.PutStateArgsForCall(2)
+	require.Equal(t, "migration~bank_references_Org1MSP", key)
+}
+
+func TestMigrateCardsToAssets(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	// Currencies are migrated already
+	state := map[string][]byte{
+		"migration~currency_codes_Org1MSP": []byte(`{"name":"currency_codes_Org1MSP"}`),
+		"b1":                               []byte(`{"docType":"bank","ID":"b1","name":"UniCredit"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.NextReturns(&queryresult.KV{Key: "a2", Value: []byte(`{"ID":"a2","balance":8000000,"currency":"EUR","bank_id":"b1","cards":["MasterCard","American Express"],"user_id":"u2"}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	migrated, err := smartContract.MigrateCardsToAssets(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 1, migrated)
+
+	require.Equal(t, 4, chaincodeStub.PutStateCallCount())
+	_, value := chaincodeStub.PutStateArgsForCall(1)
+	require.Contains(t, string(value), `"network":"AMEX"`)
+	require.Contains(t, string(value), `"user_id":"u2"`)
+
+	key, value := chaincodeStub.PutStateArgsForCall(2)
+	require.Equal(t, "a2", key)
+	require.JSONEq(t, `{"ID":"a2","balance":8000000,"currency":"EUR","bank_id":"b1","user_id":"u2"}`, string(value))
+
+	key, _ = chaincodeStub.PutStateArgsForCall(3)
+	require.Equal(t, "migration~card_assets_Org1MSP", key)
+}
+
+func TestMigrateCardsToAssets_CurrenciesFirst(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	_, err := smartContract.MigrateCardsToAssets(transactionContext)
+	require.EqualError(t, err, "currencies have to be migrated with MigrateCurrenciesToCodes first")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestMigrateUserPII(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		state[key] = value
+		return nil
+	}
+	chaincodeStub.GetQueryResultReturns(queryResults(
+		`{"ID":"u1","name":"Aleksandar","surname":"Stojanovic","email":"aleksandar@gmail.com","msp_id":"Org1MSP"}`,
+		`{"ID":"u2","name":"Marko","surname":"Markovic","email":"marko@gmail.com","msp_id":"Org2MSP"}`,
+		`{"ID":"u3","name":"Jovan","surname":"Jovanovic","email":"jovan@gmail.com"}`,
+	), nil)
+
+	// Test Case: The salts need a secret
+	_, err := smartContract.MigrateUserPII(transactionContext)
+	require.EqualError(t, err, `a random secret of at least 16 bytes has to be passed in the transient map under "salt"`)
+
+	// Test Case: Only users of the organization are moved, users without one are reported
+	chaincodeStub.GetTransientReturns(map[string][]byte{"salt": []byte("0123456789abcdef")}, nil)
+	migration, err := smartContract.MigrateUserPII(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 1, migration.Migrated)
+	require.Equal(t, []string{"u3"}, migration.Unassigned)
+
+	require.Equal(t, 1, chaincodeStub.PutPrivateDataCallCount())
+	collection, key, piiJSON := chaincodeStub.PutPrivateDataArgsForCall(0)
+	require.Equal(t, "Org1MSPPIICollection", collection)
+	require.Equal(t, "u1", key)
+	var pii model.UserPII
+	require.NoError(t, json.Unmarshal(piiJSON, &pii))
+	require.Len(t, pii.Salt, 64)
+	require.NotContains(t, pii.Salt, "tx1")
+	hash := sha256.Sum256(piiJSON)
+	require.JSONEq(t, `{"ID":"u1","pii_hash":"`+hex.EncodeToString(hash[:])+`","pii_collection":"Org1MSPPIICollection","msp_id":"Org1MSP"}`, string(state["u1"]))
+	require.Contains(t, state, "migration~user_pii_Org1MSP")
+
+	// Test Case: Running it again changes nothing
+	migration, err = smartContract.MigrateUserPII(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 0, migration.Migrated)
+	require.Equal(t, 1, chaincodeStub.PutPrivateDataCallCount())
+}
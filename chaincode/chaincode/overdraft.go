@@ -0,0 +1,209 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// availableFunds is what can leave the account, the balance plus the approved
+// overdraft less the money on hold.
+func availableFunds(account *model.BankAccount) int64 {
+	return account.Balance + account.OverdraftLimit - account.Held
+}
+
+// accrueOverdraftInterest brings the interest on a negative balance up to
+// date. accrueInterest runs it before every balance change, so the interest
+// of each period is computed on the balance in force during it.
+func accrueOverdraftInterest(account *model.BankAccount, at time.Time) error {
+	if account.OverdraftRate == "" {
+		return nil
+	}
+
+	if account.Balance < 0 && !account.OverdraftAccruedAt.IsZero() {
+		rate, err := utils.ParseInterestRate(account.OverdraftRate)
+		if err != nil {
+			return err
+		}
+		accrued, err := utils.ParseAccruedInterest(account.OverdraftInterest)
+		if err != nil {
+			return err
+		}
+
+		accrued.Add(accrued, utils.AccrueInterest(-account.Balance, rate, account.OverdraftAccruedAt, at))
+		account.OverdraftInterest = accrued.FloatString(utils.InterestDecimals)
+	}
+
+	account.OverdraftAccruedAt = at
+	return nil
+}
+
+// SetOverdraftLimit approves an overdraft for the account, a zero limit
+// cancels it. The yearly rate applies to negative balances from now on,
+// interest accrued under the previous rate is kept.
+func (s *SmartContract) SetOverdraftLimit(ctx contractapi.TransactionContextInterface, accountID, limitStr, rateStr string) (*model.BankAccount, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, account.BankID); err != nil {
+		return nil, err
+	}
+	if accountStatus(account) == model.AccountClosed {
+		return nil, fmt.Errorf("bank account %s is closed", accountID)
+	}
+
+	currency, err := readCurrency(ctx, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := utils.ParseAmount(limitStr, *currency)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("overdraft limit cannot be negative")
+	}
+	if account.Balance < -limit {
+		return nil, fmt.Errorf("balance of bank account %s is below the requested overdraft limit", accountID)
+	}
+
+	rate := strings.TrimSpace(rateStr)
+	if rate != "" {
+		parsed, err := utils.ParseInterestRate(rate)
+		if err != nil {
+			return nil, err
+		}
+		rate = parsed.FloatString(utils.RateDecimals)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if err := accrueOverdraftInterest(account, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+
+	account.OverdraftLimit = limit
+	account.OverdraftRate = rate
+	// Without a rate nothing accrued, the new rate applies from now on only
+	account.OverdraftAccruedAt = timestamp.AsTime()
+	account.LastOperation = model.OperationUpdate
+
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ChargeOverdraftInterest debits the whole minor units of the overdraft
+// interest accrued so far, fractions are carried over to the next charge.
+// Frozen accounts are charged as well, closed accounts were charged on close.
+func (s *SmartContract) ChargeOverdraftInterest(ctx contractapi.TransactionContextInterface, accountID string) (*model.BankAccount, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBankAdmin(ctx, account.BankID); err != nil {
+		return nil, err
+	}
+	if accountStatus(account) == model.AccountClosed {
+		return nil, fmt.Errorf("bank account %s is closed", accountID)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if err := accrueInterest(ctx, account, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+
+	accrued, err := utils.ParseAccruedInterest(account.OverdraftInterest)
+	if err != nil {
+		return nil, err
+	}
+	charge, remainder := utils.WholeMinorUnits(accrued)
+	if charge <= 0 {
+		return account, nil
+	}
+
+	account.Balance -= charge
+	account.OverdraftInterest = remainder.FloatString(utils.InterestDecimals)
+	account.LastOperation = model.OperationOverdraftInterest
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
+	}
+
+	recorded, err := recordTransaction(ctx, model.Transaction{
+		Type:              model.TransactionOverdraftInterest,
+		SourceAccount:     account.ID,
+		Amount:            charge,
+		Currency:          account.Currency,
+		ConvertedAmount:   charge,
+		ConvertedCurrency: account.Currency,
+		Rate:              utils.SameCurrencyRate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitEvent(ctx, model.EventOverdraftInterestCharged, model.AccountMovementEvent{
+		TxID:      recorded.ID,
+		Account:   account.ID,
+		UserID:    account.UserID,
+		Amount:    charge,
+		Currency:  account.Currency,
+		Balance:   account.Balance,
+		Timestamp: recorded.Timestamp,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (s *SmartContract) GetAccountsInOverdraft(ctx contractapi.TransactionContextInterface, bankID string) ([]model.BankAccountDetails, error) {
+	if err := assertBankAdmin(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	queryJSON, err := json.Marshal(map[string]interface{}{
+		"selector": map[string]interface{}{
+			"bank_id": bankID,
+			"balance": map[string]interface{}{"$lt": 0},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %v", err)
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	var accounts []model.BankAccount
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var account model.BankAccount
+		if err := json.Unmarshal(queryResult.Value, &account); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bank account: %v", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	return joinBanks(ctx, accounts)
+}
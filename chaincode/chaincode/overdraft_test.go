@@ -0,0 +1,174 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSetOverdraftLimit(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	now := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(now), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"bank_id":"b1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"docType":"bank","ID":"b1","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+
+	account, err := smartContract.SetOverdraftLimit(transactionContext, "a1", "500", "0.12")
+	require.NoError(t, err)
+	require.Equal(t, int64(500_00), account.OverdraftLimit)
+	require.Equal(t, "0.12000000", account.OverdraftRate)
+	require.Equal(t, now, account.OverdraftAccruedAt)
+
+	// Test Case: Balance already below the new limit
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":-30000,"overdraft_limit":50000,"bank_id":"b1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(4, []byte(`{"docType":"bank","ID":"b1","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(5, eurDefinition, nil)
+	_, err = smartContract.SetOverdraftLimit(transactionContext, "a1", "200", "")
+	require.EqualError(t, err, "balance of bank account a1 is below the requested overdraft limit")
+
+	// Test Case: Not an administrator
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	chaincodeStub.GetStateReturnsOnCall(6, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"bank_id":"b1"}`), nil)
+	_, err = smartContract.SetOverdraftLimit(transactionContext, "a1", "500", "")
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
+
+func TestMoneyWithdrawal_IntoOverdraft(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"overdraft_limit":50000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+
+	_, err := smartContract.MoneyWithdrawal(transactionContext, "a1", "550")
+	require.NoError(t, err)
+
+	_, value := chaincodeStub.PutStateArgsForCall(0)
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &account))
+	require.Equal(t, int64(-450_00), account.Balance)
+
+	// Test Case: Beyond the limit
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":-45000,"overdraft_limit":50000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(4, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(5, eurDefinition, nil)
+	_, err = smartContract.MoneyWithdrawal(transactionContext, "a1", "50.01")
+	require.EqualError(t, err, "Insufficient funds")
+}
+
+func TestMoneyDepositToAccount_AccruesOverdraftInterest(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	// Overdrawn by 1000.00 at 12.5% for 73 days
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":-100000,"overdraft_limit":200000,"overdraft_rate":"0.125","overdraft_interest":"0.5","overdraft_accrued_at":"2024-01-01T00:00:00Z"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+
+	_, err := smartContract.MoneyDepositToAccount(transactionContext, "a1", "1500")
+	require.NoError(t, err)
+
+	_, value := chaincodeStub.PutStateArgsForCall(0)
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &account))
+	require.Equal(t, int64(500_00), account.Balance)
+	require.Equal(t, "2500.50000000", account.OverdraftInterest)
+	require.Equal(t, time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC), account.OverdraftAccruedAt)
+}
+
+func TestChargeOverdraftInterest(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":-100000,"overdraft_limit":200000,"overdraft_rate":"0.125","overdraft_interest":"0.5","overdraft_accrued_at":"2024-01-01T00:00:00Z","bank_id":"b1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"docType":"bank","ID":"b1","msp_id":"Org1MSP"}`), nil)
+
+	account, err := smartContract.ChargeOverdraftInterest(transactionContext, "a1")
+	require.NoError(t, err)
+	require.Equal(t, int64(-1025_00), account.Balance)
+	require.Equal(t, "0.50000000", account.OverdraftInterest)
+	require.Equal(t, model.OperationOverdraftInterest, account.LastOperation)
+
+	require.Equal(t, 2, chaincodeStub.PutStateCallCount())
+	_, value := chaincodeStub.PutStateArgsForCall(1)
+	var transaction model.Transaction
+	require.NoError(t, json.Unmarshal(value, &transaction))
+	require.Equal(t, model.TransactionOverdraftInterest, transaction.Type)
+	require.Equal(t, int64(25_00), transaction.Amount)
+
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, model.EventOverdraftInterestCharged, name)
+	var event model.AccountMovementEvent
+	require.NoError(t, json.Unmarshal(payload, &event))
+	require.Equal(t, "tx1", event.TxID)
+	require.Equal(t, "u1", event.UserID)
+	require.Equal(t, int64(25_00), event.Amount)
+	require.Equal(t, int64(-1025_00), event.Balance)
+
+	// Test Case: Closed account
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":0,"status":"CLOSED","bank_id":"b1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"docType":"bank","ID":"b1","msp_id":"Org1MSP"}`), nil)
+	_, err = smartContract.ChargeOverdraftInterest(transactionContext, "a1")
+	require.EqualError(t, err, "bank account a1 is closed")
+	require.Equal(t, 2, chaincodeStub.PutStateCallCount())
+	require.Equal(t, 1, chaincodeStub.SetEventCallCount())
+}
+
+func TestGetAccountsInOverdraft(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturns([]byte(`{"docType":"bank","ID":"b1","name":"UniCredit"}`), nil)
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.NextReturns(&queryresult.KV{Value: []byte(`{"ID":"a1","currency":"EUR","balance":-100,"bank_id":"b1"}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	accounts, err := smartContract.GetAccountsInOverdraft(transactionContext, "b1")
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, "UniCredit", accounts[0].Bank.Name)
+	require.JSONEq(t, `{"selector":{"bank_id":"b1","balance":{"$lt":0}}}`, chaincodeStub.GetQueryResultArgsForCall(0))
+
+	// Test Case: Users cannot list the accounts of a bank
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	_, err = smartContract.GetAccountsInOverdraft(transactionContext, "b1")
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+}
@@ -0,0 +1,260 @@
+// Package query builds CouchDB rich queries. Selectors are marshalled as JSON
+// instead of formatted into strings, so values passed by clients can never
+// change the structure of the query.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type Operator string
+
+const (
+	Eq    Operator = "$eq"
+	Gt    Operator = "$gt"
+	Gte   Operator = "$gte"
+	Lte   Operator = "$lte"
+	Regex Operator = "$regex"
+	In    Operator = "$in"
+	// Exists is only used by the chaincode to tell documents apart
+	Exists Operator = "$exists"
+)
+
+// requestOperators are the operators clients may use in a Request.
+var requestOperators = []Operator{Eq, Gte, Lte, Regex, In}
+
+type Order string
+
+const (
+	Asc  Order = "asc"
+	Desc Order = "desc"
+)
+
+// Builder collects the conditions of a query, the first invalid one is
+// reported by Build.
+type Builder struct {
+	selector map[string]map[Operator]interface{}
+	sort     []map[string]Order
+	limit    int
+	err      error
+}
+
+func New() *Builder {
+	return &Builder{selector: map[string]map[Operator]interface{}{}}
+}
+
+// Where requires the field to satisfy the operator, conditions on the same
+// field all have to hold.
+func (b *Builder) Where(field string, operator Operator, value interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if err := validateField(field); err != nil {
+		b.err = err
+		return b
+	}
+	if err := validateValue(operator, value); err != nil {
+		b.err = fmt.Errorf("invalid condition on %s: %v", field, err)
+		return b
+	}
+
+	conditions, ok := b.selector[field]
+	if !ok {
+		conditions = map[Operator]interface{}{}
+		b.selector[field] = conditions
+	}
+	if _, ok := conditions[operator]; ok {
+		b.err = fmt.Errorf("condition %s on %s is given twice", operator, field)
+		return b
+	}
+	conditions[operator] = value
+	return b
+}
+
+func (b *Builder) Sort(field string, order Order) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if err := validateField(field); err != nil {
+		b.err = err
+		return b
+	}
+	if order != Asc && order != Desc {
+		b.err = fmt.Errorf("invalid sort order %q of %s", order, field)
+		return b
+	}
+	b.sort = append(b.sort, map[string]Order{field: order})
+	return b
+}
+
+func (b *Builder) Limit(limit int) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if limit <= 0 {
+		b.err = fmt.Errorf("limit must be positive")
+		return b
+	}
+	b.limit = limit
+	return b
+}
+
+func (b *Builder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+
+	// A lone $eq is written as the plain value, the way CouchDB shows it
+	selector := map[string]interface{}{}
+	for field, conditions := range b.selector {
+		if value, ok := conditions[Eq]; ok && len(conditions) == 1 {
+			selector[field] = value
+		} else {
+			selector[field] = conditions
+		}
+	}
+
+	query := map[string]interface{}{"selector": selector}
+	if len(b.sort) > 0 {
+		query["sort"] = b.sort
+	}
+	if b.limit > 0 {
+		query["limit"] = b.limit
+	}
+
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return "", fmt.Errorf("failed to build query: %v", err)
+	}
+	return string(queryJSON), nil
+}
+
+// Request is a query sent by a client, it may only use the fields the entry
+// point allows and the $eq, $gte, $lte, $regex and $in operators.
+type Request struct {
+	Filters []Filter    `json:"filters"`
+	Sort    []SortField `json:"sort,omitempty"`
+	Limit   int         `json:"limit,omitempty"`
+}
+
+type Filter struct {
+	Field    string      `json:"field"`
+	Operator Operator    `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+type SortField struct {
+	Field string `json:"field"`
+	Order Order  `json:"order"`
+}
+
+// Parse turns a JSON Request into a Builder, rejecting fields that are not in
+// the whitelist.
+func Parse(requestJSON string, fields ...string) (*Builder, error) {
+	// Numbers are kept as written, amounts in minor units may not fit a float64
+	decoder := json.NewDecoder(strings.NewReader(requestJSON))
+	decoder.UseNumber()
+	var request Request
+	if err := decoder.Decode(&request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal query: %v", err)
+	}
+
+	allowed := map[string]bool{}
+	for _, field := range fields {
+		allowed[field] = true
+	}
+
+	builder := New()
+	for _, filter := range request.Filters {
+		if !allowed[filter.Field] {
+			return nil, fmt.Errorf("field %q cannot be queried", filter.Field)
+		}
+		if !isRequestOperator(filter.Operator) {
+			return nil, fmt.Errorf("operator %q is not supported", filter.Operator)
+		}
+		builder.Where(filter.Field, filter.Operator, filter.Value)
+	}
+	for _, sort := range request.Sort {
+		if !allowed[sort.Field] {
+			return nil, fmt.Errorf("field %q cannot be sorted on", sort.Field)
+		}
+		builder.Sort(sort.Field, sort.Order)
+	}
+	if request.Limit != 0 {
+		builder.Limit(request.Limit)
+	}
+
+	if builder.err != nil {
+		return nil, builder.err
+	}
+	return builder, nil
+}
+
+func isRequestOperator(operator Operator) bool {
+	for _, requestOperator := range requestOperators {
+		if operator == requestOperator {
+			return true
+		}
+	}
+	return false
+}
+
+func validateField(field string) error {
+	if field == "" || strings.HasPrefix(field, "$") {
+		return fmt.Errorf("invalid field %q", field)
+	}
+	return nil
+}
+
+func validateValue(operator Operator, value interface{}) error {
+	switch operator {
+	case Eq, Gt, Gte, Lte:
+		if !isScalar(value) {
+			return fmt.Errorf("%s needs a string, number or boolean", operator)
+		}
+	case Regex:
+		pattern, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s needs a string", operator)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regular expression: %v", err)
+		}
+	case In:
+		values, ok := value.([]interface{})
+		if !ok {
+			if list, ok := value.([]string); ok {
+				values = make([]interface{}, len(list))
+				for i, s := range list {
+					values[i] = s
+				}
+			}
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("%s needs a list of values", operator)
+		}
+		for _, v := range values {
+			if !isScalar(v) {
+				return fmt.Errorf("%s needs strings, numbers or booleans", operator)
+			}
+		}
+	case Exists:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s needs a boolean", operator)
+		}
+	default:
+		return fmt.Errorf("unsupported operator %q", operator)
+	}
+	return nil
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case string, bool, int, int32, int64, float64, json.Number:
+		return true
+	}
+	return false
+}
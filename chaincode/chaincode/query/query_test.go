@@ -0,0 +1,78 @@
+package query_test
+
+import (
+	"chaincode/chaincode/query"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	queryString, err := query.New().
+		Where("bank_id", query.Eq, "b1").
+		Where("balance", query.Gte, int64(100)).
+		Where("balance", query.Lte, int64(500)).
+		Sort("balance", query.Desc).
+		Limit(1).
+		Build()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"selector":{"bank_id":"b1","balance":{"$gte":100,"$lte":500}},"sort":[{"balance":"desc"}],"limit":1}`, queryString)
+}
+
+func TestBuild_ValuesCannotChangeTheSelector(t *testing.T) {
+	queryString, err := query.New().Where("name", query.Eq, `Marko","balance":{"$gte":0},"x":"`).Build()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"selector":{"name":"Marko\",\"balance\":{\"$gte\":0},\"x\":\""}}`, queryString)
+
+	_, err = query.New().Where("name", query.Eq, map[string]interface{}{"$ne": ""}).Build()
+	require.EqualError(t, err, "invalid condition on name: $eq needs a string, number or boolean")
+
+	_, err = query.New().Where("$or", query.Eq, "x").Build()
+	require.EqualError(t, err, `invalid field "$or"`)
+}
+
+func TestBuild_InvalidConditions(t *testing.T) {
+	_, err := query.New().Where("name", query.Regex, "(").Build()
+	require.ErrorContains(t, err, "invalid regular expression")
+
+	_, err = query.New().Where("currency", query.In, []interface{}{}).Build()
+	require.EqualError(t, err, "invalid condition on currency: $in needs a list of values")
+
+	_, err = query.New().Where("name", query.Eq, "a").Where("name", query.Eq, "b").Build()
+	require.EqualError(t, err, "condition $eq on name is given twice")
+
+	_, err = query.New().Sort("balance", "up").Build()
+	require.EqualError(t, err, `invalid sort order "up" of balance`)
+
+	_, err = query.New().Limit(0).Build()
+	require.EqualError(t, err, "limit must be positive")
+}
+
+func TestParse(t *testing.T) {
+	q, err := query.Parse(`{
+		"filters": [
+			{"field": "currency", "operator": "$in", "value": ["EUR", "CHF"]},
+			{"field": "balance", "operator": "$gte", "value": 9007199254740993}
+		],
+		"sort": [{"field": "balance", "order": "desc"}],
+		"limit": 10
+	}`, "currency", "balance")
+	require.NoError(t, err)
+	queryString, err := q.Build()
+	require.NoError(t, err)
+	require.Equal(t, `{"limit":10,"selector":{"balance":{"$gte":9007199254740993},"currency":{"$in":["EUR","CHF"]}},"sort":[{"balance":"desc"}]}`, queryString)
+
+	// Test Case: Fields outside of the whitelist
+	_, err = query.Parse(`{"filters":[{"field":"docType","operator":"$eq","value":"hold"}]}`, "currency")
+	require.EqualError(t, err, `field "docType" cannot be queried`)
+	_, err = query.Parse(`{"sort":[{"field":"user_id","order":"asc"}]}`, "currency")
+	require.EqualError(t, err, `field "user_id" cannot be sorted on`)
+
+	// Test Case: Operators only the chaincode uses
+	_, err = query.Parse(`{"filters":[{"field":"currency","operator":"$exists","value":true}]}`, "currency")
+	require.EqualError(t, err, `operator "$exists" is not supported`)
+
+	// Test Case: Invalid values
+	_, err = query.Parse(`{"filters":[{"field":"currency","operator":"$eq","value":{"$gt":null}}]}`, "currency")
+	require.EqualError(t, err, "invalid condition on currency: $eq needs a string, number or boolean")
+}
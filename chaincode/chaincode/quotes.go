@@ -0,0 +1,215 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// How long the terms of a quote hold, a rate published in the meantime does
+// not change them.
+const quoteValidity = 5 * time.Minute
+
+// QuoteTransfer fixes the rate, the converted amount and the fee of a
+// transfer for a short while. The transfer itself is made by ExecuteQuote.
+func (s *SmartContract) QuoteTransfer(ctx contractapi.TransactionContextInterface, srcAccount, dstAccount, amountStr string) (*model.TransferQuote, error) {
+	sourceAccount, err := readBankAccount(ctx, srcAccount)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, sourceAccount); err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(sourceAccount); err != nil {
+		return nil, err
+	}
+
+	sourceCurrency, err := readCurrency(ctx, sourceAccount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(amountStr, *sourceCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	destAccount, err := readBankAccount(ctx, dstAccount)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(destAccount); err != nil {
+		return nil, err
+	}
+	if destAccount.ID == sourceAccount.ID {
+		return nil, fmt.Errorf("money cannot be transferred to the same account")
+	}
+
+	destCurrency := sourceCurrency
+	if destAccount.Currency != sourceAccount.Currency {
+		destCurrency, err = readCurrency(ctx, destAccount.Currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	appliedRates, rate, err := effectiveExchangeRate(ctx, sourceAccount.Currency, destAccount.Currency, timestamp.AsTime())
+	if err != nil {
+		return nil, err
+	}
+	convertedAmount, err := utils.ConvertAmount(amount, *sourceCurrency, *destCurrency, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := transferFees(ctx, sourceAccount, destAccount, *sourceCurrency, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := model.TransferQuote{
+		DocType:            model.TransferQuoteDocType,
+		ID:                 ctx.GetStub().GetTxID(),
+		SourceAccount:      sourceAccount.ID,
+		DestinationAccount: destAccount.ID,
+		Amount:             amount,
+		Currency:           sourceAccount.Currency,
+		ConvertedAmount:    convertedAmount,
+		ConvertedCurrency:  destAccount.Currency,
+		Rate:               rate.FloatString(utils.RateDecimals),
+		RateIDs:            exchangeRateIDs(appliedRates),
+		Fee:                totalFees(fees),
+		Fees:               fees,
+		CreatedAt:          timestamp.AsTime(),
+		ExpiresAt:          timestamp.AsTime().Add(quoteValidity),
+		Status:             model.QuoteOpen,
+	}
+	if availableFunds(sourceAccount) < quote.Amount+quote.Fee {
+		return nil, fmt.Errorf("not enough money")
+	}
+
+	if err := utils.PutDataToState(ctx, quote, quote.ID); err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+// ExecuteQuote makes the quoted transfer, at the quoted terms only. Balances,
+// limits and account statuses are checked again as they may have changed
+// since the quote was made.
+func (s *SmartContract) ExecuteQuote(ctx contractapi.TransactionContextInterface, quoteID string) (*model.Transaction, error) {
+	quote, err := readQuote(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceAccount, err := readBankAccount(ctx, quote.SourceAccount)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, sourceAccount); err != nil {
+		// Quotes of other users are reported as missing, like their accounts
+		return nil, fmt.Errorf("the quote with id %s does not exist", quoteID)
+	}
+
+	if quote.Status != model.QuoteOpen {
+		return nil, fmt.Errorf("quote %s was already executed", quoteID)
+	}
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if timestamp.AsTime().After(quote.ExpiresAt) {
+		return nil, fmt.Errorf("quote %s expired at %s", quoteID, quote.ExpiresAt.Format(time.RFC3339))
+	}
+
+	if err := assertAccountActive(sourceAccount); err != nil {
+		return nil, err
+	}
+	destAccount, err := readBankAccount(ctx, quote.DestinationAccount)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(destAccount); err != nil {
+		return nil, err
+	}
+
+	if err := expireHolds(ctx, sourceAccount, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+	if availableFunds(sourceAccount) < quote.Amount+quote.Fee {
+		return nil, fmt.Errorf("not enough money")
+	}
+	if err := chargeSpendingLimits(ctx, sourceAccount, model.TransactionTransfer, quote.Amount, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+
+	recorded, err := executeTransfer(ctx, sourceAccount, destAccount, model.Transaction{
+		Amount:            quote.Amount,
+		Currency:          quote.Currency,
+		ConvertedAmount:   quote.ConvertedAmount,
+		ConvertedCurrency: quote.ConvertedCurrency,
+		Rate:              quote.Rate,
+		RateIDs:           quote.RateIDs,
+		Fees:              quote.Fees,
+	}, timestamp.AsTime(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	quote.Status = model.QuoteExecuted
+	quote.TransactionID = recorded.ID
+	if err := utils.PutDataToState(ctx, quote, quote.ID); err != nil {
+		return nil, err
+	}
+	if err := emitTransferCompleted(ctx, recorded); err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+func (s *SmartContract) ReadQuote(ctx contractapi.TransactionContextInterface, id string) (*model.TransferQuote, error) {
+	quote, err := readQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceAccount, err := readBankAccount(ctx, quote.SourceAccount)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, sourceAccount); err != nil {
+		return nil, fmt.Errorf("the quote with id %s does not exist", id)
+	}
+
+	return quote, nil
+}
+
+func readQuote(ctx contractapi.TransactionContextInterface, id string) (*model.TransferQuote, error) {
+	quoteJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if quoteJSON == nil {
+		return nil, fmt.Errorf("the quote with id %s does not exist", id)
+	}
+
+	var quote model.TransferQuote
+	if err := json.Unmarshal(quoteJSON, &quote); err != nil {
+		return nil, err
+	}
+	if quote.DocType != model.TransferQuoteDocType {
+		return nil, fmt.Errorf("the quote with id %s does not exist", id)
+	}
+
+	return &quote, nil
+}
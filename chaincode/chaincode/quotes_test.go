@@ -0,0 +1,158 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const eurToRsdQuote = `{
+	"docType":"transferQuote",
+	"ID":"quote1",
+	"source_account":"srcAccount",
+	"destination_account":"dstAccount",
+	"amount":1000,
+	"currency":"EUR",
+	"converted_amount":125000,
+	"converted_currency":"RSD",
+	"rate":"125.00000000",
+	"rate_ids":["rate1"],
+	"fee":0,
+	"created_at":"2024-02-01T10:00:00Z",
+	"expires_at":"2024-02-01T10:05:00Z",
+	"status":"OPEN"
+}`
+
+func TestQuoteTransfer(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	timestamp := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxIDReturns("quote1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(timestamp), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"dstAccount","currency":"RSD","balance":0}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(4, rsdDefinition, nil)
+	// Only the inverse RSD to EUR rate is published
+	chaincodeStub.GetStateByPartialCompositeKeyReturnsOnCall(0, &mocks.StateQueryIterator{}, nil)
+	rates := &mocks.StateQueryIterator{}
+	rates.HasNextReturnsOnCall(0, true)
+	rates.NextReturns(&queryresult.KV{Value: []byte(`{"ID":"rate1","from":"RSD","to":"EUR","rate":"0.00800000","effective_from":"2024-01-01T00:00:00Z"}`)}, nil)
+	chaincodeStub.GetStateByPartialCompositeKeyReturnsOnCall(1, rates, nil)
+
+	quote, err := smartContract.QuoteTransfer(transactionContext, "srcAccount", "dstAccount", "10")
+	require.NoError(t, err)
+
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "quote1", key)
+	require.JSONEq(t, eurToRsdQuote, string(value))
+	require.Equal(t, timestamp.Add(5*time.Minute), quote.ExpiresAt)
+
+	// Test Case: Not enough money for the quoted amount
+	chaincodeStub.GetStateReturnsOnCall(5, []byte(`{"ID":"srcAccount","user_id":"u1","currency":"EUR","balance":999}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(6, []byte(`{"ID":"u1","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(7, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(8, []byte(`{"ID":"dstAccount","currency":"EUR","balance":0}`), nil)
+	_, err = smartContract.QuoteTransfer(transactionContext, "srcAccount", "dstAccount", "10")
+	require.EqualError(t, err, "not enough money")
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
+
+func TestExecuteQuote_RecordsTransaction(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	// The rate changed since the quote was made, the quoted one still applies
+	timestamp := time.Date(2024, 2, 1, 10, 3, 0, 0, time.UTC)
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(timestamp), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(eurToRsdQuote), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"srcAccount","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"ID":"u1","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"dstAccount","currency":"RSD","balance":0}`), nil)
+
+	transaction, err := smartContract.ExecuteQuote(transactionContext, "quote1")
+	require.NoError(t, err)
+	require.Equal(t, &model.Transaction{
+		DocType:            model.TransactionDocType,
+		ID:                 "tx1",
+		Type:               model.TransactionTransfer,
+		SourceAccount:      "srcAccount",
+		DestinationAccount: "dstAccount",
+		Amount:             10_00,
+		Currency:           model.EUR,
+		ConvertedAmount:    1250_00,
+		ConvertedCurrency:  model.RSD,
+		Rate:               "125.00000000",
+		RateIDs:            []string{"rate1"},
+		Timestamp:          timestamp,
+		Initiator:          "x509::CN=u1",
+	}, transaction)
+	require.Equal(t, 0, chaincodeStub.GetStateByPartialCompositeKeyCallCount())
+
+	require.Equal(t, 4, chaincodeStub.PutStateCallCount())
+	_, value := chaincodeStub.PutStateArgsForCall(1)
+	var destAccount model.BankAccount
+	require.NoError(t, json.Unmarshal(value, &destAccount))
+	require.Equal(t, int64(1250_00), destAccount.Balance)
+
+	key, value := chaincodeStub.PutStateArgsForCall(3)
+	require.Equal(t, "quote1", key)
+	var quote model.TransferQuote
+	require.NoError(t, json.Unmarshal(value, &quote))
+	require.Equal(t, model.QuoteExecuted, quote.Status)
+	require.Equal(t, "tx1", quote.TransactionID)
+}
+
+func TestExecuteQuote_Rejected(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 5, 1, 0, time.UTC)), nil)
+
+	// Test Case: Expired quote
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(eurToRsdQuote), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"srcAccount","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"ID":"u1"}`), nil)
+	_, err := smartContract.ExecuteQuote(transactionContext, "quote1")
+	require.EqualError(t, err, "quote quote1 expired at 2024-02-01T10:05:00Z")
+
+	// Test Case: Quote of another user
+	transactionContext.GetClientIdentityReturns(userIdentity("u2"))
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(eurToRsdQuote), nil)
+	chaincodeStub.GetStateReturnsOnCall(4, []byte(`{"ID":"srcAccount","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	_, err = smartContract.ExecuteQuote(transactionContext, "quote1")
+	require.EqualError(t, err, "the quote with id quote1 does not exist")
+
+	// Test Case: Quote executed before
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	chaincodeStub.GetStateReturnsOnCall(5, []byte(`{"docType":"transferQuote","ID":"quote1","source_account":"srcAccount","status":"EXECUTED"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(6, []byte(`{"ID":"srcAccount","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(7, []byte(`{"ID":"u1"}`), nil)
+	_, err = smartContract.ExecuteQuote(transactionContext, "quote1")
+	require.EqualError(t, err, "quote quote1 was already executed")
+
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
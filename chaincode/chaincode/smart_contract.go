@@ -1,13 +1,16 @@
 package chaincode
 
 import (
+	"chaincode/chaincode/query"
 	"chaincode/chaincode/utils"
 	"chaincode/model"
 	"encoding/json"
 	"fmt"
-	"strconv"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
@@ -16,30 +19,94 @@ type SmartContract struct {
 }
 
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	if err := assertAdmin(ctx); err != nil {
+		return err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
 	banks, users, bankAccounts := utils.InitializeData()
+	cards := utils.InitializeCards()
 
 	for _, bank := range banks {
+		bank.DocType = model.BankDocType
 		if err := utils.PutDataToState(ctx, bank, bank.ID); err != nil {
 			return err
 		}
 	}
 
+	// The salts are no secret, but the seeded users are no real people
 	for _, user := range users {
-		if err := utils.PutDataToState(ctx, user, user.ID); err != nil {
+		if err := putUser(ctx, user, piiCollection(user.MSPID), ctx.GetStub().GetTxID()+user.ID); err != nil {
 			return err
 		}
 	}
 
 	for _, bankAcc := range bankAccounts {
+		bankAcc.Status = model.AccountActive
+		bankAcc.LastOperation = model.OperationCreate
+		bankAcc.InterestAccruedAt = timestamp.AsTime()
 		if err := utils.PutDataToState(ctx, bankAcc, bankAcc.ID); err != nil {
 			return err
 		}
+
+		// Seeded cards have no number, they get one when replaced
+		for i, network := range cards[bankAcc.ID] {
+			if _, err := issueCard(ctx, &bankAcc, network, "", i); err != nil {
+				return err
+			}
+		}
 	}
 
+	currencies, currencySettings := utils.InitializeCurrencies()
+	for _, currency := range currencies {
+		if err := putCurrency(ctx, currency); err != nil {
+			return err
+		}
+	}
+	if err := putCurrencySettings(ctx, currencySettings); err != nil {
+		return err
+	}
+
+	for _, exchangeRate := range utils.InitializeExchangeRates() {
+		if _, err := putExchangeRate(ctx, exchangeRate); err != nil {
+			return err
+		}
+	}
+
+	for _, schedule := range utils.InitializeFeeSchedules() {
+		key, err := feeScheduleKey(ctx, schedule.BankID, schedule.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to create fee schedule key: %v", err)
+		}
+		schedule.DocType = model.FeeScheduleDocType
+		schedule.ID = key
+		if err := utils.PutDataToState(ctx, schedule, key); err != nil {
+			return err
+		}
+	}
+
+	// Balances are created in minor units, currencies as codes, banks as references and cards as assets already
+	for _, migration := range []string{minorUnitsMigration, currencyCodesMigration, bankReferencesMigration, cardAssetsMigration} {
+		if err := markMigrationCompleted(ctx, migration); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s *SmartContract) CreateBankAccount(ctx contractapi.TransactionContextInterface, id string, currency string, cards string, bankId string, userID string) error {
+func (s *SmartContract) CreateBankAccount(ctx contractapi.TransactionContextInterface, id string, currency string, cards string, bankId string, userID string, product string) error {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !identity.Admin && identity.UserID != userID {
+		return fmt.Errorf("users can only open bank accounts for themselves")
+	}
+
 	accountExists, err := s.AssetExists(ctx, id)
 	if err != nil {
 		return err
@@ -61,15 +128,43 @@ func (s *SmartContract) CreateBankAccount(ctx contractapi.TransactionContextInte
 		return err
 	}
 
-	currency_converted, _ := StringToCurrency(currency)
+	accountCurrency, err := readEnabledCurrency(ctx, normalizeCurrencyCode(currency))
+	if err != nil {
+		return err
+	}
+
+	accountProduct, err := parseAccountProduct(product)
+	if err != nil {
+		return err
+	}
+
+	// Cards are requested as a comma separated list of card networks
+	var cardNetworks []model.CardNetwork
+	for _, network := range strings.Split(cards, ",") {
+		if strings.TrimSpace(network) == "" {
+			continue
+		}
+		cardNetwork, err := utils.ParseCardNetwork(network)
+		if err != nil {
+			return err
+		}
+		cardNetworks = append(cardNetworks, cardNetwork)
+	}
+	cardNumbers, err := transientCardNumbers(ctx, cardNetworks)
+	if err != nil {
+		return err
+	}
 
 	bankAccount := model.BankAccount{
 		ID:       id,
-		Currency: currency_converted,
+		Currency: accountCurrency.Code,
 		Balance:  0.0,
-		Cards:    strings.Split(cards, ","),
-		Bank:     *bank,
+		BankID:   bank.ID,
 		UserID:   userID,
+		Status:   model.AccountActive,
+		Product:  accountProduct,
+
+		LastOperation: model.OperationCreate,
 	}
 
 	bankAccountJSON, err := json.Marshal(bankAccount)
@@ -77,25 +172,29 @@ func (s *SmartContract) CreateBankAccount(ctx contractapi.TransactionContextInte
 		return err
 	}
 
-	return ctx.GetStub().PutState(id, bankAccountJSON)
-}
-
-func (s *SmartContract) ReadBank(ctx contractapi.TransactionContextInterface, id string) (*model.Bank, error) {
-	bankJSON, err := ctx.GetStub().GetState(id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read from world state: %v", err)
-	}
-	if bankJSON == nil {
-		return nil, fmt.Errorf("the bank with id %s does not exist", id)
+	if err := ctx.GetStub().PutState(id, bankAccountJSON); err != nil {
+		return err
 	}
 
-	var bank model.Bank
-	err = json.Unmarshal(bankJSON, &bank)
+	for i, network := range cardNetworks {
+		if _, err := issueCard(ctx, &bankAccount, network, cardNumbers[i], i); err != nil {
+			return err
+		}
+	}
 
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to read transaction timestamp: %v", err)
 	}
-	return &bank, nil
+
+	return emitEvent(ctx, model.EventAccountCreated, model.AccountCreatedEvent{
+		TxID:      ctx.GetStub().GetTxID(),
+		Account:   bankAccount.ID,
+		UserID:    bankAccount.UserID,
+		BankID:    bank.ID,
+		Currency:  bankAccount.Currency,
+		Timestamp: timestamp.AsTime(),
+	})
 }
 
 func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
@@ -107,109 +206,281 @@ func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface,
 	return assetJSON != nil, nil
 }
 
-func (s *SmartContract) TransferMoney(ctx contractapi.TransactionContextInterface, srcAccount string, dstAccount string, amountStr string, confirmationStr string) (bool, error) {
-	sourceAccount, err := s.ReadBankAccount(ctx, srcAccount)
+// TransferMoney moves money between accounts in the same currency, transfers
+// between currencies go through QuoteTransfer and ExecuteQuote.
+func (s *SmartContract) TransferMoney(ctx contractapi.TransactionContextInterface, srcAccount string, dstAccount string, amountStr string) (*model.Transaction, error) {
+	sourceAccount, err := readBankAccount(ctx, srcAccount)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, sourceAccount); err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(sourceAccount); err != nil {
+		return nil, err
 	}
 
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	sourceCurrency, err := readCurrency(ctx, sourceAccount.Currency)
 	if err != nil {
-		return false, fmt.Errorf("failed to convert amount to float64: %v", err)
+		return nil, err
+	}
+
+	amount, err := parseAmount(amountStr, *sourceCurrency)
+	if err != nil {
+		return nil, err
 	}
 
-	confirmation, err := strconv.ParseBool(confirmationStr)
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return false, fmt.Errorf("failed to convert confirmation to boolean: %v", err)
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if err := expireHolds(ctx, sourceAccount, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+	if availableFunds(sourceAccount) < amount {
+		return nil, fmt.Errorf("not enough money")
 	}
 
-	if sourceAccount.Balance < amount {
-		return false, fmt.Errorf("not enough money")
+	if err := chargeSpendingLimits(ctx, sourceAccount, model.TransactionTransfer, amount, timestamp.AsTime()); err != nil {
+		return nil, err
 	}
 
-	destAccount, err := s.ReadBankAccount(ctx, dstAccount)
+	destAccount, err := readBankAccount(ctx, dstAccount)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	if err := assertAccountActive(destAccount); err != nil {
+		return nil, err
 	}
 
-	if sourceAccount.Currency != destAccount.Currency && !confirmation {
-		return false, nil
-	} else if sourceAccount.Currency != destAccount.Currency && confirmation {
+	if sourceAccount.Currency != destAccount.Currency {
+		return nil, fmt.Errorf("transfers from %s to %s need a quote", sourceAccount.Currency, destAccount.Currency)
+	}
 
-		var convertedAmount float64
+	fees, err := transferFees(ctx, sourceAccount, destAccount, *sourceCurrency, amount)
+	if err != nil {
+		return nil, err
+	}
+	if availableFunds(sourceAccount) < amount+totalFees(fees) {
+		return nil, fmt.Errorf("not enough money")
+	}
 
-		switch sourceAccount.Currency {
-		case model.EUR:
-			convertedAmount = utils.EurToDin(amount)
-		case model.RSD:
-			convertedAmount = utils.DinToEur(amount)
-		}
+	recorded, err := executeTransfer(ctx, sourceAccount, destAccount, model.Transaction{
+		Amount:            amount,
+		Currency:          sourceAccount.Currency,
+		ConvertedAmount:   amount,
+		ConvertedCurrency: destAccount.Currency,
+		Rate:              utils.SameCurrencyRate,
+		Fees:              fees,
+	}, timestamp.AsTime(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := emitTransferCompleted(ctx, recorded); err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+// executeTransfer moves the amounts of the transaction between the accounts,
+// credits its fees to the revenue accounts and records the transfer.
+// Balances, limits and statuses have to be checked by the caller.
+func executeTransfer(ctx contractapi.TransactionContextInterface, sourceAccount, destAccount *model.BankAccount, transaction model.Transaction, at time.Time, revenue map[string]*model.RevenueAccount) (*model.Transaction, error) {
+	// Both sides would be written from separate copies of the same account
+	if sourceAccount.ID == destAccount.ID {
+		return nil, fmt.Errorf("money cannot be transferred to the same account")
+	}
+
+	if err := accrueInterest(ctx, sourceAccount, at); err != nil {
+		return nil, err
+	}
+	if err := accrueInterest(ctx, destAccount, at); err != nil {
+		return nil, err
+	}
 
-		sourceAccount.Balance -= amount
-		destAccount.Balance += convertedAmount
+	sourceAccount.Balance -= transaction.Amount + totalFees(transaction.Fees)
+	destAccount.Balance += transaction.ConvertedAmount
+	sourceAccount.LastOperation = model.OperationTransferOut
+	destAccount.LastOperation = model.OperationTransferIn
 
-	} else {
-		destAccount.Balance += amount
-		sourceAccount.Balance -= amount
+	if err := utils.PutDataToState(ctx, sourceAccount, sourceAccount.ID); err != nil {
+		return nil, err
+	}
+	if err := utils.PutDataToState(ctx, destAccount, destAccount.ID); err != nil {
+		return nil, err
+	}
+	if err := creditFees(ctx, revenue, transaction.Fees); err != nil {
+		return nil, err
 	}
 
-	sourceAccountJSON, err := json.Marshal(sourceAccount)
+	transaction.Type = model.TransactionTransfer
+	transaction.SourceAccount = sourceAccount.ID
+	transaction.DestinationAccount = destAccount.ID
+	return recordTransaction(ctx, transaction)
+}
+
+func emitTransferCompleted(ctx contractapi.TransactionContextInterface, transaction *model.Transaction) error {
+	return emitEvent(ctx, model.EventTransferCompleted, model.TransferCompletedEvent{
+		TxID:               transaction.ID,
+		SourceAccount:      transaction.SourceAccount,
+		DestinationAccount: transaction.DestinationAccount,
+		Amount:             transaction.Amount,
+		Currency:           transaction.Currency,
+		ConvertedAmount:    transaction.ConvertedAmount,
+		ConvertedCurrency:  transaction.ConvertedCurrency,
+		Rate:               transaction.Rate,
+		Timestamp:          transaction.Timestamp,
+	})
+}
+func (s *SmartContract) MoneyWithdrawal(ctx contractapi.TransactionContextInterface, bankAccount string, amountStr string) (*model.Transaction, error) {
+	account, err := readBankAccount(ctx, bankAccount)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return nil, err
 	}
-	destAccountJSON, err := json.Marshal(destAccount)
+	if err := assertAccountActive(account); err != nil {
+		return nil, err
+	}
+
+	currency, err := readCurrency(ctx, account.Currency)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	ctx.GetStub().PutState(sourceAccount.ID, sourceAccountJSON)
-	ctx.GetStub().PutState(destAccount.ID, destAccountJSON)
+	amount, err := parseAmount(amountStr, *currency)
+	if err != nil {
+		return nil, err
+	}
 
-	return true, nil
-}
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if err := expireHolds(ctx, account, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+
+	fees, err := operationFees(ctx, account, *currency, amount, model.FeeOnWithdrawal)
+	if err != nil {
+		return nil, err
+	}
+	if availableFunds(account) < amount+totalFees(fees) {
+		return nil, fmt.Errorf("Insufficient funds")
+	}
+
+	if err := chargeSpendingLimits(ctx, account, model.TransactionWithdrawal, amount, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+	if err := accrueInterest(ctx, account, timestamp.AsTime()); err != nil {
+		return nil, err
+	}
+
+	account.Balance = account.Balance - amount - totalFees(fees)
+	account.LastOperation = model.OperationWithdrawal
 
-func (s *SmartContract) MoneyWithdrawal(ctx contractapi.TransactionContextInterface, usrID string, bankAccount string, amount float64) (bool, error) {
-	account, err := s.ReadBankAccount(ctx, bankAccount)
-	if account.UserID != usrID {
-		return false, fmt.Errorf("bank account with ID %s not found for user %s", bankAccount, usrID)
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
+		return nil, err
 	}
+
+	if err := creditFees(ctx, nil, fees); err != nil {
+		return nil, err
+	}
+
+	recorded, err := recordTransaction(ctx, model.Transaction{
+		Type:              model.TransactionWithdrawal,
+		SourceAccount:     account.ID,
+		Amount:            amount,
+		Currency:          account.Currency,
+		ConvertedAmount:   amount,
+		ConvertedCurrency: account.Currency,
+		Rate:              utils.SameCurrencyRate,
+		Fees:              fees,
+	})
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	if account.Balance < amount {
-		return false, fmt.Errorf("Insufficient funds")
+	err = emitEvent(ctx, model.EventWithdrawalCompleted, model.AccountMovementEvent{
+		TxID:      recorded.ID,
+		Account:   account.ID,
+		UserID:    account.UserID,
+		Amount:    amount,
+		Currency:  account.Currency,
+		Balance:   account.Balance,
+		Timestamp: recorded.Timestamp,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	account.Balance = account.Balance - amount
+	return recorded, nil
+}
 
-	accountJSON, err := json.Marshal(account)
+func (s *SmartContract) MoneyDepositToAccount(ctx contractapi.TransactionContextInterface, bankAccountID string, amountStr string) (bool, error) {
+	account, err := readBankAccount(ctx, bankAccountID)
 	if err != nil {
 		return false, err
 	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return false, err
+	}
+	if err := assertAccountActive(account); err != nil {
+		return false, err
+	}
 
-	ctx.GetStub().PutState(account.ID, accountJSON)
+	currency, err := readCurrency(ctx, account.Currency)
+	if err != nil {
+		return false, err
+	}
 
-	return true, nil
-}
+	amount, err := parseAmount(amountStr, *currency)
+	if err != nil {
+		return false, err
+	}
 
-func (s *SmartContract) MoneyDepositToAccount(ctx contractapi.TransactionContextInterface, usrID string, bankAccountID string, amount float64) (bool, error) {
-	account, err := s.ReadBankAccount(ctx, bankAccountID)
-	if account.UserID != usrID {
-		return false, fmt.Errorf("bank account with ID %s not found for user %s", bankAccountID, usrID)
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return false, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if err := accrueInterest(ctx, account, timestamp.AsTime()); err != nil {
+		return false, err
 	}
+
 	account.Balance = account.Balance + amount
+	account.LastOperation = model.OperationDeposit
 
-	if err != nil {
+	if err := utils.PutDataToState(ctx, account, account.ID); err != nil {
 		return false, err
 	}
 
-	accountJSON, err := json.Marshal(account)
+	recorded, err := recordTransaction(ctx, model.Transaction{
+		Type:               model.TransactionDeposit,
+		DestinationAccount: account.ID,
+		Amount:             amount,
+		Currency:           account.Currency,
+		ConvertedAmount:    amount,
+		ConvertedCurrency:  account.Currency,
+		Rate:               utils.SameCurrencyRate,
+	})
 	if err != nil {
 		return false, err
 	}
 
-	ctx.GetStub().PutState(account.ID, accountJSON)
+	err = emitEvent(ctx, model.EventDepositCompleted, model.AccountMovementEvent{
+		TxID:      recorded.ID,
+		Account:   account.ID,
+		UserID:    account.UserID,
+		Amount:    amount,
+		Currency:  account.Currency,
+		Balance:   account.Balance,
+		Timestamp: recorded.Timestamp,
+	})
+	if err != nil {
+		return false, err
+	}
 
 	return true, nil
 }
@@ -223,7 +494,25 @@ func (s *SmartContract) Exists(ctx contractapi.TransactionContextInterface, id s
 	return someJSON != nil, nil
 }
 
-func (s *SmartContract) ReadBankAccount(ctx contractapi.TransactionContextInterface, id string) (*model.BankAccount, error) {
+func (s *SmartContract) ReadBankAccount(ctx contractapi.TransactionContextInterface, id string) (*model.BankAccountDetails, error) {
+	account, err := readBankAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if assertBankAdmin(ctx, account.BankID) != nil {
+		if _, err := assertAccountOwner(ctx, account); err != nil {
+			return nil, err
+		}
+	}
+
+	details, err := joinBanks(ctx, []model.BankAccount{*account})
+	if err != nil {
+		return nil, err
+	}
+	return &details[0], nil
+}
+
+func readBankAccount(ctx contractapi.TransactionContextInterface, id string) (*model.BankAccount, error) {
 	accJSON, err := ctx.GetStub().GetState(id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
@@ -241,7 +530,95 @@ func (s *SmartContract) ReadBankAccount(ctx contractapi.TransactionContextInterf
 	return &bankAccount, nil
 }
 
-func (s *SmartContract) AddUser(ctx contractapi.TransactionContextInterface, id, name, surname, email string) error {
+func (s *SmartContract) GetAccountHistory(ctx contractapi.TransactionContextInterface, id string) ([]model.AccountHistoryEntry, error) {
+	account, err := readBankAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if assertBankAdmin(ctx, account.BankID) != nil {
+		if _, err := assertAccountOwner(ctx, account); err != nil {
+			return nil, err
+		}
+	}
+	return accountHistory(ctx, id)
+}
+
+// accountHistory returns the versions of the account oldest first.
+func accountHistory(ctx contractapi.TransactionContextInterface, id string) ([]model.AccountHistoryEntry, error) {
+	historyResults, err := ctx.GetStub().GetHistoryForKey(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history from world state: %v", err)
+	}
+	defer historyResults.Close()
+
+	var history []model.AccountHistoryEntry
+	for historyResults.HasNext() {
+		modification, err := historyResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate history results: %v", err)
+		}
+
+		entry := model.AccountHistoryEntry{
+			TxID:      modification.TxId,
+			Timestamp: modification.Timestamp.AsTime(),
+			Operation: model.OperationDelete,
+		}
+		if !modification.IsDelete {
+			var account model.BankAccount
+			if err := json.Unmarshal(modification.Value, &account); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal bank account: %v", err)
+			}
+			entry.Currency = account.Currency
+			entry.Balance = account.Balance
+			entry.Operation = account.LastOperation
+		}
+
+		history = append(history, entry)
+	}
+
+	// Fabric does not guarantee the order of key history, deltas need it oldest first
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Timestamp.Before(history[j].Timestamp)
+	})
+
+	for i := range history {
+		var previousBalance int64
+		if i > 0 {
+			previousBalance = history[i-1].Balance
+			if history[i].Operation == model.OperationDelete {
+				history[i].Currency = history[i-1].Currency
+			}
+		}
+		history[i].Delta = history[i].Balance - previousBalance
+
+		// Versions written before operations were tracked on the account
+		if history[i].Operation == "" {
+			if i == 0 {
+				history[i].Operation = model.OperationCreate
+			} else {
+				history[i].Operation = model.OperationUpdate
+			}
+		}
+	}
+
+	return history, nil
+}
+
+// AddUser registers a user with the organization of the administrator. The
+// name, surname, email and a random salt are passed as JSON under "user" in
+// the transient map and kept in the private data collection of the
+// organization.
+func (s *SmartContract) AddUser(ctx contractapi.TransactionContextInterface, id string) error {
+	if err := assertAdmin(ctx); err != nil {
+		return err
+	}
+
+	// Users belong to the organization of the administrator registering them
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to read client identity: %v", err)
+	}
+
 	exists, err := s.AssetExists(ctx, id)
 	if err != nil {
 		return err
@@ -249,68 +626,109 @@ func (s *SmartContract) AddUser(ctx contractapi.TransactionContextInterface, id,
 	if exists {
 		return fmt.Errorf("the user %s already exists", id)
 	}
-	user := model.User{
-		ID:      id,
-		Name:    name,
-		Surname: surname,
-		Email:   email,
-	}
-	userJson, err := json.Marshal(user)
+
+	transient, err := ctx.GetStub().GetTransient()
 	if err != nil {
+		return fmt.Errorf("failed to read transient map: %v", err)
+	}
+	detailsJSON, ok := transient[userTransientKey]
+	if !ok {
+		return fmt.Errorf("the user details have to be passed in the transient map under %q", userTransientKey)
+	}
+	var details struct {
+		Name    string `json:"name"`
+		Surname string `json:"surname"`
+		Email   string `json:"email"`
+		Salt    string `json:"salt"`
+	}
+	if err := json.Unmarshal(detailsJSON, &details); err != nil {
+		return fmt.Errorf("failed to unmarshal user details: %v", err)
+	}
+	if details.Salt == "" {
+		return fmt.Errorf("the user details need a salt")
+	}
+
+	user := model.UserDetails{
+		User:    model.User{ID: id, MSPID: mspID},
+		Name:    details.Name,
+		Surname: details.Surname,
+		Email:   details.Email,
+	}
+	if err := putUser(ctx, user, piiCollection(mspID), details.Salt); err != nil {
 		return err
 	}
 
-	return ctx.GetStub().PutState(id, userJson)
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	return emitEvent(ctx, model.EventUserAdded, model.UserAddedEvent{
+		TxID:      ctx.GetStub().GetTxID(),
+		UserID:    user.ID,
+		Timestamp: timestamp.AsTime(),
+	})
 }
 
-func StringToCurrency(currencyStr string) (model.Currency, error) {
-	switch currencyStr {
-	case "EUR":
-		return model.EUR, nil
-	case "RSD":
-		return model.RSD, nil
-	default:
-		return 0, fmt.Errorf("invalid currency string: %s", currencyStr)
+func parseAmount(amountStr string, currency model.CurrencyDefinition) (int64, error) {
+	amount, err := utils.ParseAmount(amountStr, currency)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse amount: %v", err)
 	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("amount must be positive")
+	}
+	return amount, nil
 }
 
-func (s *SmartContract) GetUsersByName(ctx contractapi.TransactionContextInterface, name string) ([]model.User, error) {
-	queryString := fmt.Sprintf(`{
-		"selector": {
-			"name": "%s"
-		}
-	}`, name)
+func (s *SmartContract) GetUsersByName(ctx contractapi.TransactionContextInterface, name string) ([]model.UserPII, error) {
+	return queryUserPII(ctx, query.New().Where("name", query.Eq, name))
+}
 
-	queryResults, err := ctx.GetStub().GetQueryResult(queryString)
+func (s *SmartContract) GetUsersBySurname(ctx contractapi.TransactionContextInterface, surname string) ([]model.UserPII, error) {
+	return queryUserPII(ctx, query.New().Where("surname", query.Eq, surname))
+}
+
+func (s *SmartContract) GetUsersByNameWithPagination(ctx contractapi.TransactionContextInterface, name string, pageSize int, bookmark string) (*model.UserPIIPage, error) {
+	return queryUserPIIWithPagination(ctx, query.New().Where("name", query.Eq, name), pageSize, bookmark)
+}
+
+func (s *SmartContract) GetUsersBySurnameWithPagination(ctx contractapi.TransactionContextInterface, surname string, pageSize int, bookmark string) (*model.UserPIIPage, error) {
+	return queryUserPIIWithPagination(ctx, query.New().Where("surname", query.Eq, surname), pageSize, bookmark)
+}
+
+func (s *SmartContract) GetUserByBankAccountId(ctx contractapi.TransactionContextInterface, accId string) (*model.UserPII, error) {
+	account, err := readBankAccount(ctx, accId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %v", err)
+		return nil, err
 	}
-	defer queryResults.Close()
 
-	var users []model.User
-	for queryResults.HasNext() {
-		queryResult, err := queryResults.Next()
-		if err != nil {
-			return nil, fmt.Errorf("failed to iterate query results: %v", err)
-		}
+	return readUserPII(ctx, account.UserID)
+}
 
-		var user model.User
-		if err := json.Unmarshal(queryResult.Value, &user); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal user: %v", err)
-		}
+func (s *SmartContract) GetUsersBySurnameAndEmail(ctx contractapi.TransactionContextInterface, surname, email string) ([]model.UserPII, error) {
+	return queryUserPII(ctx, query.New().Where("surname", query.Eq, surname).Where("email", query.Eq, email))
+}
 
-		users = append(users, user)
+// QueryUsers searches the personal data of users with a query.Request, e.g.
+// {"filters":[{"field":"surname","operator":"$regex","value":"^Stojan"}],"limit":10}.
+func (s *SmartContract) QueryUsers(ctx contractapi.TransactionContextInterface, request string) ([]model.UserPII, error) {
+	q, err := query.Parse(request, userQueryFields...)
+	if err != nil {
+		return nil, err
 	}
-
-	return users, nil
+	return queryUserPII(ctx, q)
 }
 
-func (s *SmartContract) GetUsersBySurname(ctx contractapi.TransactionContextInterface, surname string) ([]model.User, error) {
-	queryString := fmt.Sprintf(`{
-		"selector": {
-			"surname": "%s"
-		}
-	}`, surname)
+func (s *SmartContract) GetAccountsByBankDesiredCurrencyAndBalance(ctx contractapi.TransactionContextInterface, bankId, currency, balanceThreshold string) ([]model.BankAccountDetails, error) {
+	if err := assertBankAdmin(ctx, bankId); err != nil {
+		return nil, err
+	}
+
+	queryString, err := accountsByBankCurrencyAndBalanceQuery(ctx, bankId, currency, balanceThreshold)
+	if err != nil {
+		return nil, err
+	}
 
 	queryResults, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
@@ -318,108 +736,113 @@ func (s *SmartContract) GetUsersBySurname(ctx contractapi.TransactionContextInte
 	}
 	defer queryResults.Close()
 
-	var users []model.User
-	for queryResults.HasNext() {
-		queryResult, err := queryResults.Next()
-		if err != nil {
-			return nil, fmt.Errorf("failed to iterate query results: %v", err)
-		}
-
-		var user model.User
-		if err := json.Unmarshal(queryResult.Value, &user); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal user: %v", err)
-		}
-
-		users = append(users, user)
+	bankAccounts, err := bankAccountsFromResults(queryResults)
+	if err != nil {
+		return nil, err
 	}
 
-	return users, nil
+	return joinBanks(ctx, bankAccounts)
 }
 
-func (s *SmartContract) GetUserByBankAccountId(ctx contractapi.TransactionContextInterface, accId string) (model.User, error) {
-	accountJSON, err := ctx.GetStub().GetState(accId)
-	if err != nil {
-		return model.User{}, fmt.Errorf("failed to read from world state: %v", err)
+func (s *SmartContract) GetAccountsByBankDesiredCurrencyAndBalanceWithPagination(ctx contractapi.TransactionContextInterface, bankId, currency, balanceThreshold string, pageSize int, bookmark string) (*model.BankAccountDetailsPage, error) {
+	if err := assertBankAdmin(ctx, bankId); err != nil {
+		return nil, err
 	}
-
-	var account model.BankAccount
-	err = json.Unmarshal(accountJSON, &account)
-
-	if err != nil {
-		return model.User{}, err
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive")
 	}
 
-	userJSON, err := ctx.GetStub().GetState(account.UserID)
+	queryString, err := accountsByBankCurrencyAndBalanceQuery(ctx, bankId, currency, balanceThreshold)
 	if err != nil {
-		return model.User{}, fmt.Errorf("failed to read from world state: %v", err)
+		return nil, err
 	}
 
-	var user model.User
-	err = json.Unmarshal(userJSON, &user)
+	return queryAccountsWithPagination(ctx, queryString, pageSize, bookmark)
+}
+
+// GetAccountsByBankWithPagination pages through all accounts of the bank,
+// e.g. to name them to AccrueInterest in batches.
+func (s *SmartContract) GetAccountsByBankWithPagination(ctx contractapi.TransactionContextInterface, bankId string, pageSize int, bookmark string) (*model.BankAccountDetailsPage, error) {
+	if err := assertBankAdmin(ctx, bankId); err != nil {
+		return nil, err
+	}
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive")
+	}
 
+	// Other assets of the bank carry its id too, only accounts have a balance
+	queryJSON, err := json.Marshal(map[string]interface{}{
+		"selector": map[string]interface{}{
+			"bank_id": bankId,
+			"balance": map[string]interface{}{"$exists": true},
+		},
+	})
 	if err != nil {
-		return model.User{}, err
+		return nil, fmt.Errorf("failed to build query: %v", err)
 	}
 
-	return user, nil
+	return queryAccountsWithPagination(ctx, string(queryJSON), pageSize, bookmark)
 }
 
-func (s *SmartContract) GetUsersBySurnameAndEmail(ctx contractapi.TransactionContextInterface, surname, email string) ([]model.User, error) {
-	queryString := fmt.Sprintf(`{
-		"selector": {
-			"surname": "%s",
-			"email": "%s"
-		}
-	}`, surname, email)
-
-	queryResults, err := ctx.GetStub().GetQueryResult(queryString)
+func queryAccountsWithPagination(ctx contractapi.TransactionContextInterface, queryString string, pageSize int, bookmark string) (*model.BankAccountDetailsPage, error) {
+	queryResults, metadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, int32(pageSize), bookmark)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %v", err)
 	}
 	defer queryResults.Close()
 
-	var users []model.User
-	for queryResults.HasNext() {
-		queryResult, err := queryResults.Next()
-		if err != nil {
-			return nil, fmt.Errorf("failed to iterate query results: %v", err)
-		}
-
-		var user model.User
-		if err := json.Unmarshal(queryResult.Value, &user); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal user: %v", err)
-		}
+	bankAccounts, err := bankAccountsFromResults(queryResults)
+	if err != nil {
+		return nil, err
+	}
 
-		users = append(users, user)
+	details, err := joinBanks(ctx, bankAccounts)
+	if err != nil {
+		return nil, err
 	}
 
-	return users, nil
+	page := &model.BankAccountDetailsPage{
+		Records:             details,
+		FetchedRecordsCount: metadata.GetFetchedRecordsCount(),
+	}
+	// CouchDB returns a bookmark after the last page too, a short page is the last one
+	if page.FetchedRecordsCount == int32(pageSize) {
+		page.Bookmark = metadata.GetBookmark()
+	}
+	return page, nil
 }
 
-func (s *SmartContract) GetAccountsByBankDesiredCurrencyAndBalance(ctx contractapi.TransactionContextInterface, bankId, currency, balanceThreshold string) ([]model.BankAccount, error) {
-	var currencyEnum model.Currency
-	switch strings.ToUpper(currency) {
-	case "EUR":
-		currencyEnum = model.EUR
-	case "RSD":
-		currencyEnum = model.RSD
-	}
+// accountQueryFields are the fields of bank accounts QueryAccounts may filter
+// and sort on, amounts are in minor units.
+var accountQueryFields = []string{"ID", "user_id", "bank_id", "currency", "balance", "held", "status", "product", "overdraft_limit"}
 
-	balanceThresh, err := strconv.ParseFloat(balanceThreshold, 64)
+// QueryAccounts searches bank accounts with a query.Request, e.g.
+// {"filters":[{"field":"currency","operator":"$in","value":["EUR","CHF"]}],"sort":[{"field":"balance","order":"desc"}]}.
+// Only accounts of the banks run by the organization of the caller are found.
+func (s *SmartContract) QueryAccounts(ctx contractapi.TransactionContextInterface, request string) ([]model.BankAccountDetails, error) {
+	banks, err := adminBanks(ctx)
 	if err != nil {
-		fmt.Println("Error:", err)
 		return nil, err
 	}
+	if len(banks) == 0 {
+		return []model.BankAccountDetails{}, nil
+	}
+	bankIDs := make([]string, 0, len(banks))
+	for bankID := range banks {
+		bankIDs = append(bankIDs, bankID)
+	}
+	// Sorted so that every peer builds the same query
+	sort.Strings(bankIDs)
 
-	queryString := fmt.Sprintf(`{
-			"selector":{
-			  "bank" : {
-					"ID" : "%s"
-					 },
-			  "currency":%d,
-			  "balance": {"$gte": %f}
-		   }
-		 }`, bankId, currencyEnum, balanceThresh)
+	q, err := query.Parse(request, accountQueryFields...)
+	if err != nil {
+		return nil, err
+	}
+	// Loans, cards, holds and standing orders share account fields, only accounts have a balance
+	queryString, err := q.Where("balance", query.Exists, true).Where("bank_id", query.In, bankIDs).Build()
+	if err != nil {
+		return nil, err
+	}
 
 	queryResults, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
@@ -427,6 +850,33 @@ func (s *SmartContract) GetAccountsByBankDesiredCurrencyAndBalance(ctx contracta
 	}
 	defer queryResults.Close()
 
+	bankAccounts, err := bankAccountsFromResults(queryResults)
+	if err != nil {
+		return nil, err
+	}
+
+	return joinBanks(ctx, bankAccounts)
+}
+
+func accountsByBankCurrencyAndBalanceQuery(ctx contractapi.TransactionContextInterface, bankId, currency, balanceThreshold string) (string, error) {
+	accountCurrency, err := readCurrency(ctx, normalizeCurrencyCode(currency))
+	if err != nil {
+		return "", err
+	}
+
+	balanceThresh, err := utils.ParseAmount(balanceThreshold, *accountCurrency)
+	if err != nil {
+		return "", err
+	}
+
+	return query.New().
+		Where("bank_id", query.Eq, bankId).
+		Where("currency", query.Eq, string(accountCurrency.Code)).
+		Where("balance", query.Gte, balanceThresh).
+		Build()
+}
+
+func bankAccountsFromResults(queryResults shim.StateQueryIteratorInterface) ([]model.BankAccount, error) {
 	var bankAccounts []model.BankAccount
 	for queryResults.HasNext() {
 		queryResult, err := queryResults.Next()
@@ -434,56 +884,65 @@ func (s *SmartContract) GetAccountsByBankDesiredCurrencyAndBalance(ctx contracta
 			return nil, fmt.Errorf("failed to iterate query results: %v", err)
 		}
 
-		var user model.BankAccount
-		if err := json.Unmarshal(queryResult.Value, &user); err != nil {
-			return nil, fmt.Errorf("failed to bank account user: %v", err)
+		var document struct {
+			model.BankAccount
+			DocType string `json:"docType"`
+			Balance *int64 `json:"balance"`
+		}
+		if err := json.Unmarshal(queryResult.Value, &document); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bank account: %v", err)
+		}
+		// Other assets matching the selector are not accounts
+		if document.DocType != "" || document.Balance == nil {
+			continue
 		}
 
-		bankAccounts = append(bankAccounts, user)
+		account := document.BankAccount
+		account.Balance = *document.Balance
+		bankAccounts = append(bankAccounts, account)
 	}
 
 	return bankAccounts, nil
 }
 
-func (s *SmartContract) GetAccountByBankDesiredCurrencyAndMaxBalance(ctx contractapi.TransactionContextInterface, bankId, currency string) (model.BankAccount, error) {
-	var currencyEnum model.Currency
-	switch strings.ToUpper(currency) {
-	case "EUR":
-		currencyEnum = model.EUR
-	case "RSD":
-		currencyEnum = model.RSD
-	}
-
-	queryString := fmt.Sprintf(`{
-       "selector": {
-          "bank" : {
-            "ID" : "%s"
-          },
-          "currency": %d
-       },
-       "sort": [{"balance": "desc"}],
-       "limit": 1
-     }`, bankId, currencyEnum)
+func (s *SmartContract) GetAccountByBankDesiredCurrencyAndMaxBalance(ctx contractapi.TransactionContextInterface, bankId, currency string) (model.BankAccountDetails, error) {
+	if err := assertBankAdmin(ctx, bankId); err != nil {
+		return model.BankAccountDetails{}, err
+	}
+
+	queryString, err := query.New().
+		Where("bank_id", query.Eq, bankId).
+		Where("currency", query.Eq, string(normalizeCurrencyCode(currency))).
+		Sort("balance", query.Desc).
+		Limit(1).
+		Build()
+	if err != nil {
+		return model.BankAccountDetails{}, err
+	}
 
 	queryResults, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
-		return model.BankAccount{}, fmt.Errorf("failed to execute query: %v", err)
+		return model.BankAccountDetails{}, fmt.Errorf("failed to execute query: %v", err)
 	}
 	defer queryResults.Close()
 
 	if !queryResults.HasNext() {
-		return model.BankAccount{}, fmt.Errorf("No accounts found")
+		return model.BankAccountDetails{}, fmt.Errorf("No accounts found")
 	}
 
 	queryResult, err := queryResults.Next()
 	if err != nil {
-		return model.BankAccount{}, fmt.Errorf("failed to get query result: %v", err)
+		return model.BankAccountDetails{}, fmt.Errorf("failed to get query result: %v", err)
 	}
 
 	var bankAccount model.BankAccount
 	if err := json.Unmarshal(queryResult.Value, &bankAccount); err != nil {
-		return model.BankAccount{}, fmt.Errorf("failed to unmarshal bank account: %v", err)
+		return model.BankAccountDetails{}, fmt.Errorf("failed to unmarshal bank account: %v", err)
 	}
 
-	return bankAccount, nil
+	details, err := joinBanks(ctx, []model.BankAccount{bankAccount})
+	if err != nil {
+		return model.BankAccountDetails{}, err
+	}
+	return details[0], nil
 }
@@ -4,14 +4,22 @@ import (
 	"chaincode/chaincode"
 	"chaincode/chaincode/mocks"
 	"chaincode/model"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // go run github.com/maxbrunsfeld/counterfeiter/v6 -o mocks/transaction.go -fake-name TransactionContext . transactionContext
@@ -29,13 +37,81 @@ type stateQueryIterator interface {
 	shim.StateQueryIteratorInterface
 }
 
+// go run github.com/maxbrunsfeld/counterfeiter/v6 -o mocks/historyqueryiterator.go -fake-name HistoryQueryIterator . historyQueryIterator
+type historyQueryIterator interface {
+	shim.HistoryQueryIteratorInterface
+}
+
+// go run github.com/maxbrunsfeld/counterfeiter/v6 -o mocks/clientidentity.go -fake-name ClientIdentity . clientIdentity
+type clientIdentity interface {
+	cid.ClientIdentity
+}
+
 //RUN ALL TESTS with go test -v ./chaincode from root dir
 
+var (
+	eurDefinition = []byte(`{"docType":"currency","code":"EUR","name":"Euro","minor_units":2,"rounding":"HALF_EVEN","enabled":true}`)
+	rsdDefinition = []byte(`{"docType":"currency","code":"RSD","name":"Serbian dinar","minor_units":2,"rounding":"HALF_UP","enabled":true}`)
+)
+
+// userIdentity mimics the enrollment certificate of a bank user issued by the Org1 CA
+func userIdentity(userID string) *mocks.ClientIdentity {
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetIDReturns("x509::CN="+userID, nil)
+	clientIdentity.GetMSPIDReturns("Org1MSP", nil)
+	clientIdentity.GetAttributeValueStub = func(name string) (string, bool, error) {
+		switch name {
+		case "hf.EnrollmentID":
+			return userID, true, nil
+		case "hf.Type":
+			return "client", true, nil
+		}
+		return "", false, nil
+	}
+	return clientIdentity
+}
+
+func adminIdentity() *mocks.ClientIdentity {
+	return organizationAdminIdentity("Org1MSP")
+}
+
+// organizationAdminIdentity is an administrator of the organization, the
+// seeded bank b1 is run by Org1MSP.
+func organizationAdminIdentity(mspID string) *mocks.ClientIdentity {
+	adminID := strings.ToLower(strings.TrimSuffix(mspID, "MSP")) + "admin"
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetIDReturns("x509::CN="+adminID, nil)
+	clientIdentity.GetMSPIDReturns(mspID, nil)
+	clientIdentity.GetAttributeValueStub = func(name string) (string, bool, error) {
+		switch name {
+		case "hf.EnrollmentID":
+			return adminID, true, nil
+		case "hf.Type":
+			return "admin", true, nil
+		}
+		return "", false, nil
+	}
+	return clientIdentity
+}
+
+// queryResults iterates over the given JSON documents.
+func queryResults(values ...string) *mocks.StateQueryIterator {
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextStub = func() bool {
+		return iterator.NextCallCount() < len(values)
+	}
+	iterator.NextStub = func() (*queryresult.KV, error) {
+		return &queryresult.KV{Value: []byte(values[iterator.NextCallCount()-1])}, nil
+	}
+	return iterator
+}
+
 func TestInitLedger(t *testing.T) {
 	//Arrange
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
 	contract := chaincode.SmartContract{}
 
 	//Testing happy path
@@ -52,14 +128,17 @@ func TestCreateBankAccount_BankAccountDoesNotExist(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
 	smartContract := chaincode.SmartContract{} // Correct instantiation
 
 	// Test Case: Bank account doesn't exist, user exists, and bank exists
-	chaincodeStub.GetStateReturns(nil, nil)                                                                                                           // Set state to indicate bank account doesn't exist
-	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"someUserData":"value"}`), nil)                                                                   // Set state to indicate user exists
-	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"ID":"b1","Name":"UniCredit","Headquarters":"Linz, Austria","Since":1969,"PIB":138429230}`), nil) // Set state to indicate bank exists
+	chaincodeStub.GetStateReturns(nil, nil)                                                                                                                            // Set state to indicate bank account doesn't exist
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"someUserData":"value"}`), nil)                                                                                    // Set state to indicate user exists
+	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"docType":"bank","ID":"b1","Name":"UniCredit","Headquarters":"Linz, Austria","Since":1969,"PIB":138429230}`), nil) // Set state to indicate bank exists
+	chaincodeStub.GetStateReturnsOnCall(3, eurDefinition, nil)                                                                                                         // Set state to indicate currency is registered
+	chaincodeStub.GetTransientReturns(map[string][]byte{"cards": []byte(`["4111111111111111"]`)}, nil)
 
-	err := smartContract.CreateBankAccount(transactionContext, "a1", "EUR", "Visa", "b1", "u1")
+	err := smartContract.CreateBankAccount(transactionContext, "a1", "EUR", "Visa", "b1", "u1", "")
 	require.NoError(t, err)
 }
 
@@ -68,11 +147,12 @@ func TestCreateBankAccount_BankAccountAlreadyExists(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
 	smartContract := chaincode.SmartContract{} // Correct instantiation
 
 	// Test Case: Bank account already exists
 	chaincodeStub.GetStateReturns([]byte(`{"ID":"a1","Currency":"EUR","Balance":0.0,"Cards":["Visa"],"Bank":{"ID":"b1","Name":"UniCredit","Headquarters":"Linz, Austria","Since":1969,"PIB":138429230},"UserID":"u1"}`), nil)
-	err := smartContract.CreateBankAccount(transactionContext, "a1", "EUR", "Visa", "b1", "u1")
+	err := smartContract.CreateBankAccount(transactionContext, "a1", "EUR", "Visa", "b1", "u1", "")
 	require.EqualError(t, err, "the bank account with id a1 already exists")
 }
 
@@ -81,13 +161,14 @@ func TestCreateBankAccount_UserDoesNotExist(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
 	smartContract := chaincode.SmartContract{} // Correct instantiation
 
 	// Test Case: User doesn't exist, Bank account doesn't exist
 	chaincodeStub.GetStateReturnsOnCall(0, nil, nil) // Set state to indicate user doesn't exist
 	chaincodeStub.GetStateReturnsOnCall(1, nil, nil) // Set state to indicate bank account doesn't exist
 
-	err := smartContract.CreateBankAccount(transactionContext, "a2", "RSD", "MasterCard", "b1", "u2")
+	err := smartContract.CreateBankAccount(transactionContext, "a2", "RSD", "MasterCard", "b1", "u2", "")
 	require.EqualError(t, err, "no registered user with id u2")
 }
 
@@ -96,6 +177,7 @@ func TestCreateBankAccount_BankDoesNotExist(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
 	smartContract := chaincode.SmartContract{} // Correct instantiation
 
 	// Test Case: No bank accounts, user exists, and no banks
@@ -103,7 +185,7 @@ func TestCreateBankAccount_BankDoesNotExist(t *testing.T) {
 	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"someUserData":"value"}`), nil)
 	chaincodeStub.GetStateReturnsOnCall(2, nil, nil)
 
-	err := smartContract.CreateBankAccount(transactionContext, "a3", "RSD", "American Express", "b2", "u3")
+	err := smartContract.CreateBankAccount(transactionContext, "a3", "RSD", "American Express", "b2", "u3", "")
 	require.EqualError(t, err, "the bank with id b2 does not exist")
 }
 
@@ -112,15 +194,18 @@ func TestTransferMoney_EnoughMoney(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+	chaincodeStub.GetTxIDReturns("tx1")
 	smartContract := chaincode.SmartContract{}
 
 	// Test Case: Enough money in the source account
-	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","Currency":0,"Balance":100}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"dstAccount","Currency":0,"Balance":0}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(2, nil, nil)
-	confirmation, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "75.0", "false")
-	require.True(t, confirmation)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","user_id":"usrID","currency":"EUR","Balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"dstAccount","currency":"EUR","Balance":0}`), nil)
+	transferred, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "75.0")
 	require.Nil(t, err)
+	require.Equal(t, int64(75_00), transferred.Amount)
 }
 
 func TestTransferMoney_NotEnoughMoney(t *testing.T) {
@@ -128,32 +213,34 @@ func TestTransferMoney_NotEnoughMoney(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
 	smartContract := chaincode.SmartContract{}
 
 	// Test Case: Not enough money in the source account
-	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","Currency":0,"Balance":50}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"dstAccount","Currency":0,"Balance":0}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(2, nil, nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","user_id":"usrID","currency":"EUR","Balance":5000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
 
-	_, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "100.0", "false")
+	_, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "100.0")
 	require.EqualError(t, err, "not enough money")
 }
 
-func TestTransferMoney_DifferentCurrenciesWithoutConfirmation(t *testing.T) {
+func TestTransferMoney_DifferentCurrenciesNeedQuote(t *testing.T) {
 	// Setup
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
-	smartContract := chaincode.SmartContract{} // Correct instantiation
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+	smartContract := chaincode.SmartContract{}
 
-	// Test Case: Different currencies without confirmation
-	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","Currency":0,"Balance":100}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"dstAccount","Currency":1,"Balance":0}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(2, []byte(`{"someUserData":"value"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","user_id":"usrID","currency":"EUR","Balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"dstAccount","currency":"RSD","Balance":0}`), nil)
 
-	confirmation, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "50.0", "false")
-	require.False(t, confirmation)
-	require.Nil(t, err)
+	_, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "50.0")
+	require.EqualError(t, err, "transfers from EUR to RSD need a quote")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
 }
 
 func TestReadBankAccount(t *testing.T) {
@@ -163,52 +250,59 @@ func TestReadBankAccount(t *testing.T) {
 	transactionContext.GetStubReturns(chaincodeStub)
 	smartContract := chaincode.SmartContract{} // Correct instantiation
 
-	// Test Case 1: Bank account exists
-	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"existingAccount","Currency":0,"Balance":100}`), nil) // Set state to indicate existing account with EUR currency and balance 100
+	state := map[string][]byte{
+		"existingAccount": []byte(`{"ID":"existingAccount","user_id":"usrID","currency":"EUR","Balance":10000,"bank_id":"b1"}`),
+		"usrID":           []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`),
+		"b1":              []byte(`{"docType":"bank","ID":"b1","name":"UniCredit","msp_id":"Org1MSP"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
 
+	// Test Case 1: The owner reads the bank account
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
 	_, err := smartContract.ReadBankAccount(transactionContext, "existingAccount")
 	require.NoError(t, err)
 
 	// Test Case 2: Bank account does not exist
-	chaincodeStub.GetStateReturnsOnCall(1, nil, nil)
-
 	_, nonExistingErr := smartContract.ReadBankAccount(transactionContext, "nonExistingAccount")
 	require.Error(t, nonExistingErr)
 	require.EqualError(t, nonExistingErr, "the bank account with id nonExistingAccount does not exist")
-}
 
-func TestTransferMoney_SameCurrency(t *testing.T) {
-	// Setup
-	chaincodeStub := &mocks.ChaincodeStub{}
-	transactionContext := &mocks.TransactionContext{}
-	transactionContext.GetStubReturns(chaincodeStub)
-	smartContract := chaincode.SmartContract{}
+	// Test Case 3: Other users do not see the bank account
+	transactionContext.GetClientIdentityReturns(userIdentity("otherID"))
+	_, err = smartContract.ReadBankAccount(transactionContext, "existingAccount")
+	require.EqualError(t, err, "bank account with ID existingAccount not found for user otherID")
 
-	// Test Case: Same currency with confirmation
-	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","Currency":0,"Balance":100}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"dstAccount","Currency":0,"Balance":50}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(2, nil, nil)
+	// Test Case 4: Administrators read the bank accounts of their banks
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	_, err = smartContract.ReadBankAccount(transactionContext, "existingAccount")
+	require.NoError(t, err)
 
-	confirmation, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "75.0", "true")
-	require.True(t, confirmation)
-	require.Nil(t, err)
+	// Test Case 5: Administrators of other organizations do not see the bank account
+	transactionContext.GetClientIdentityReturns(organizationAdminIdentity("Org2MSP"))
+	_, err = smartContract.ReadBankAccount(transactionContext, "existingAccount")
+	require.EqualError(t, err, "bank account with ID existingAccount not found for user org2admin")
 }
 
-func TestTransferMoney_DifferentCurrenciesWithConfirmation(t *testing.T) {
+func TestTransferMoney_SameCurrency(t *testing.T) {
 	// Setup
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
-	smartContract := chaincode.SmartContract{} // Correct instantiation
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+	chaincodeStub.GetTxIDReturns("tx1")
+	smartContract := chaincode.SmartContract{}
 
-	// Test Case: Different currencies with confirmation
-	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","Currency":0,"Balance":100}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"dstAccount","Currency":1,"Balance":50}`), nil)
-	chaincodeStub.GetStateReturnsOnCall(2, nil, nil)
+	// Test Case: Same currency
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"srcAccount","user_id":"usrID","currency":"EUR","Balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"dstAccount","currency":"EUR","Balance":5000}`), nil)
 
-	confirmation, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "75.0", "true")
-	require.True(t, confirmation)
+	transferred, err := smartContract.TransferMoney(transactionContext, "srcAccount", "dstAccount", "75.0")
 	require.Nil(t, err)
+	require.Equal(t, int64(75_00), transferred.Amount)
 }
 
 func TestAddUser(t *testing.T) {
@@ -216,15 +310,30 @@ func TestAddUser(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
 	contract := chaincode.SmartContract{}
 
 	//Happy path
-	err := contract.AddUser(transactionContext, "u1", "Aleksandar", "Stojanovic", "aleksandar@gmail.com")
+	piiJSON := `{"docType":"userPII","ID":"u1","name":"Aleksandar","surname":"Stojanovic","email":"aleksandar@gmail.com","salt":"s4lt"}`
+	chaincodeStub.GetTransientReturns(map[string][]byte{"user": []byte(`{"name":"Aleksandar","surname":"Stojanovic","email":"aleksandar@gmail.com","salt":"s4lt"}`)}, nil)
+	err := contract.AddUser(transactionContext, "u1")
 	require.NoError(t, err)
+	collection, key, privateJSON := chaincodeStub.PutPrivateDataArgsForCall(0)
+	require.Equal(t, "Org1MSPPIICollection", collection)
+	require.Equal(t, "u1", key)
+	require.JSONEq(t, piiJSON, string(privateJSON))
+	hash := sha256.Sum256(privateJSON)
+	_, userJSON := chaincodeStub.PutStateArgsForCall(0)
+	require.JSONEq(t, `{"ID":"u1","pii_hash":"`+hex.EncodeToString(hash[:])+`","pii_collection":"Org1MSPPIICollection","msp_id":"Org1MSP"}`, string(userJSON))
+
+	//Personal data outside of the transient map
+	chaincodeStub.GetTransientReturns(map[string][]byte{}, nil)
+	err = contract.AddUser(transactionContext, "u2")
+	require.EqualError(t, err, `the user details have to be passed in the transient map under "user"`)
 
 	//Already exists
 	chaincodeStub.GetStateReturns([]byte{}, nil)
-	err = contract.AddUser(transactionContext, "u1", "Aleksandar", "Stojanovic", "aleksandar@gmail.com")
+	err = contract.AddUser(transactionContext, "u1")
 	require.EqualError(t, err, "the user u1 already exists")
 }
 
@@ -233,28 +342,74 @@ func TestMoneyWithdrawal(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+	chaincodeStub.GetTxIDReturns("tx1")
 	smartContract := chaincode.SmartContract{}
 
 	// Test Case: Successful withdrawal
 	account := model.BankAccount{
-		ID:      "bankAccountID",
-		UserID:  "usrID",
-		Balance: 100,
+		ID:       "bankAccountID",
+		UserID:   "usrID",
+		Balance:  100_00,
+		Currency: model.EUR,
 	}
 	accountJSON, _ := json.Marshal(account)
 	chaincodeStub.GetStateReturns(accountJSON, nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
 
 	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		if key == "tx1" {
+			return nil
+		}
 		require.Equal(t, "bankAccountID", key)
 		var updatedAccount model.BankAccount
 		json.Unmarshal(value, &updatedAccount)
-		require.Equal(t, float64(50.0), updatedAccount.Balance)
+		require.Equal(t, int64(50_00), updatedAccount.Balance)
 		return nil
 	}
 
-	confirmation, err := smartContract.MoneyWithdrawal(transactionContext, "usrID", "bankAccountID", 50.0)
-	require.True(t, confirmation)
+	withdrawal, err := smartContract.MoneyWithdrawal(transactionContext, "bankAccountID", "50")
 	require.NoError(t, err)
+	require.Equal(t, int64(50_00), withdrawal.Amount)
+	require.Equal(t, "1.00000000", withdrawal.Rate)
+}
+
+func TestMoneyWithdrawalAndDeposit_StateWriteFails(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+	chaincodeStub.GetTxIDReturns("tx1")
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"bankAccountID": []byte(`{"ID":"bankAccountID","user_id":"usrID","currency":"EUR","balance":10000}`),
+		"usrID":         []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`),
+		"currency~EUR":  eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.PutStateReturns(fmt.Errorf("ledger unavailable"))
+
+	// Test Case: The account cannot be written
+	_, err := smartContract.MoneyWithdrawal(transactionContext, "bankAccountID", "50")
+	require.EqualError(t, err, "failed to put to world state. ledger unavailable")
+
+	confirmation, err := smartContract.MoneyDepositToAccount(transactionContext, "bankAccountID", "50")
+	require.False(t, confirmation)
+	require.EqualError(t, err, "failed to put to world state. ledger unavailable")
+
+	// Nothing is recorded for the failed movements
+	for i := 0; i < chaincodeStub.PutStateCallCount(); i++ {
+		key, _ := chaincodeStub.PutStateArgsForCall(i)
+		require.Equal(t, "bankAccountID", key)
+	}
 }
 
 func TestMoneyWithdrawal_InsufficientFunds(t *testing.T) {
@@ -262,19 +417,23 @@ func TestMoneyWithdrawal_InsufficientFunds(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
 	smartContract := chaincode.SmartContract{}
 
 	// Test Case: Insufficient funds
 	account := model.BankAccount{
-		ID:      "bankAccountID",
-		UserID:  "usrID",
-		Balance: 40,
+		ID:       "bankAccountID",
+		UserID:   "usrID",
+		Balance:  40_00,
+		Currency: model.EUR,
 	}
 	accountJSON, _ := json.Marshal(account)
 	chaincodeStub.GetStateReturns(accountJSON, nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
 
-	confirmation, err := smartContract.MoneyWithdrawal(transactionContext, "usrID", "bankAccountID", 50.0)
-	require.False(t, confirmation)
+	withdrawal, err := smartContract.MoneyWithdrawal(transactionContext, "bankAccountID", "50")
+	require.Nil(t, withdrawal)
 	require.EqualError(t, err, "Insufficient funds")
 }
 
@@ -283,13 +442,14 @@ func TestMoneyWithdrawal_AccountNotFound(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
 	smartContract := chaincode.SmartContract{}
 
-	// Test Case: Account not found
-	chaincodeStub.GetStateReturns([]byte(`{"ID":"bankAccountID","UserID":"usrID","Balance":100}`), nil)
+	// Test Case: Account of another user
+	chaincodeStub.GetStateReturns([]byte(`{"ID":"bankAccountID","user_id":"u2","balance":10000}`), nil)
 
-	confirmation, err := smartContract.MoneyWithdrawal(transactionContext, "usrID", "bankAccountID", 50.0)
-	require.False(t, confirmation)
+	withdrawal, err := smartContract.MoneyWithdrawal(transactionContext, "bankAccountID", "50")
+	require.Nil(t, withdrawal)
 	require.EqualError(t, err, "bank account with ID bankAccountID not found for user usrID")
 }
 
@@ -298,26 +458,38 @@ func TestMoneyDepositToAccount(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+	chaincodeStub.GetTxIDReturns("tx1")
 	smartContract := chaincode.SmartContract{}
 
 	// Test Case: Successful deposit
 	account := model.BankAccount{
-		ID:      "bankAccountID",
-		UserID:  "usrID",
-		Balance: 100,
+		ID:       "bankAccountID",
+		UserID:   "usrID",
+		Balance:  100_00,
+		Currency: model.EUR,
 	}
 	accountJSON, _ := json.Marshal(account)
 	chaincodeStub.GetStateReturns(accountJSON, nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 750_000_000, time.UTC)), nil)
 
 	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		if key == "tx1" {
+			// Recorded in whole seconds, the way GetTransactions compares them
+			require.Contains(t, string(value), `"timestamp":"2024-02-01T10:00:00Z"`)
+			return nil
+		}
 		require.Equal(t, "bankAccountID", key)
 		var updatedAccount model.BankAccount
 		json.Unmarshal(value, &updatedAccount)
-		require.Equal(t, float64(150.0), updatedAccount.Balance)
+		require.Equal(t, int64(150_00), updatedAccount.Balance)
 		return nil
 	}
 
-	confirmation, err := smartContract.MoneyDepositToAccount(transactionContext, "usrID", "bankAccountID", 50.0)
+	confirmation, err := smartContract.MoneyDepositToAccount(transactionContext, "bankAccountID", "50")
 	require.True(t, confirmation)
 	require.NoError(t, err)
 }
@@ -327,12 +499,231 @@ func TestMoneyDepositToAccount_AccountNotFound(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
 	smartContract := chaincode.SmartContract{}
 
-	// Test Case: Account not found
-	chaincodeStub.GetStateReturns([]byte(`{"ID":"bankAccountID","UserID":"usrID","Balance":100}`), nil)
+	// Test Case: Account of another user
+	chaincodeStub.GetStateReturns([]byte(`{"ID":"bankAccountID","user_id":"u2","balance":10000}`), nil)
 
-	confirmation, err := smartContract.MoneyDepositToAccount(transactionContext, "usrID", "bankAccountID", 50.0)
+	confirmation, err := smartContract.MoneyDepositToAccount(transactionContext, "bankAccountID", "50")
 	require.False(t, confirmation)
 	require.EqualError(t, err, "bank account with ID bankAccountID not found for user usrID")
 }
+
+func TestGetAccountHistory(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	created := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	historyIterator := &mocks.HistoryQueryIterator{}
+	historyIterator.HasNextReturnsOnCall(0, true)
+	historyIterator.HasNextReturnsOnCall(1, true)
+	historyIterator.HasNextReturnsOnCall(2, true)
+	historyIterator.HasNextReturnsOnCall(3, false)
+	// Returned newest first, the contract has to order them
+	historyIterator.NextReturnsOnCall(0, &queryresult.KeyModification{
+		TxId:      "tx3",
+		Value:     []byte(`{"ID":"a1","balance":120,"last_operation":"WITHDRAWAL"}`),
+		Timestamp: timestamppb.New(created.Add(2 * time.Hour)),
+	}, nil)
+	historyIterator.NextReturnsOnCall(1, &queryresult.KeyModification{
+		TxId:      "tx2",
+		Value:     []byte(`{"ID":"a1","balance":150,"last_operation":"DEPOSIT"}`),
+		Timestamp: timestamppb.New(created.Add(time.Hour)),
+	}, nil)
+	historyIterator.NextReturnsOnCall(2, &queryresult.KeyModification{
+		TxId:      "tx1",
+		Value:     []byte(`{"ID":"a1","balance":100}`),
+		Timestamp: timestamppb.New(created),
+	}, nil)
+
+	state := map[string][]byte{
+		"a1":    []byte(`{"ID":"a1","user_id":"usrID","balance":120}`),
+		"usrID": []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.GetHistoryForKeyReturns(historyIterator, nil)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+
+	history, err := smartContract.GetAccountHistory(transactionContext, "a1")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	require.Equal(t, "tx1", history[0].TxID)
+	require.Equal(t, model.OperationCreate, history[0].Operation)
+	require.Equal(t, int64(100), history[0].Delta)
+	require.Equal(t, created, history[0].Timestamp)
+
+	require.Equal(t, "tx2", history[1].TxID)
+	require.Equal(t, model.OperationDeposit, history[1].Operation)
+	require.Equal(t, int64(50), history[1].Delta)
+
+	require.Equal(t, "tx3", history[2].TxID)
+	require.Equal(t, model.OperationWithdrawal, history[2].Operation)
+	require.Equal(t, int64(-30), history[2].Delta)
+	require.Equal(t, int64(120), history[2].Balance)
+	require.True(t, historyIterator.CloseCallCount() > 0)
+
+	// Test Case: Other users do not see the history
+	transactionContext.GetClientIdentityReturns(userIdentity("otherID"))
+	_, err = smartContract.GetAccountHistory(transactionContext, "a1")
+	require.EqualError(t, err, "bank account with ID a1 not found for user otherID")
+	require.Equal(t, 1, chaincodeStub.GetHistoryForKeyCallCount())
+}
+
+func TestGetAccountHistory_AccountNotFound(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	// Test Case: Account not found
+	chaincodeStub.GetStateReturns(nil, nil)
+
+	_, err := smartContract.GetAccountHistory(transactionContext, "a1")
+	require.EqualError(t, err, "the bank account with id a1 does not exist")
+	require.Equal(t, 0, chaincodeStub.GetHistoryForKeyCallCount())
+}
+
+func TestMoneyDepositToAccount_InvalidAmount(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturns([]byte(`{"ID":"bankAccountID","user_id":"usrID","balance":10000,"currency":"EUR"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(5, eurDefinition, nil)
+
+	// Test Case: Negative amount
+	confirmation, err := smartContract.MoneyDepositToAccount(transactionContext, "bankAccountID", "-50")
+	require.False(t, confirmation)
+	require.EqualError(t, err, "amount must be positive")
+
+	// Test Case: Fraction of a cent
+	confirmation, err = smartContract.MoneyDepositToAccount(transactionContext, "bankAccountID", "0.001")
+	require.False(t, confirmation)
+	require.EqualError(t, err, "failed to parse amount: amount 0.001 has more than 2 decimal places")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
+
+func TestGetAccountsByBankDesiredCurrencyAndBalanceWithPagination(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"b1":           []byte(`{"docType":"bank","ID":"b1","Name":"UniCredit","Headquarters":"Linz, Austria","Since":1969,"PIB":138429230}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.GetQueryResultWithPaginationReturns(queryResults(
+		`{"ID":"a1","currency":"EUR","balance":50000,"bank_id":"b1"}`,
+		`{"ID":"a2","currency":"EUR","balance":20000,"bank_id":"b1"}`,
+	), &peer.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "g1AAAA"}, nil)
+
+	// Test Case: A full page returns the bookmark of the next one
+	page, err := smartContract.GetAccountsByBankDesiredCurrencyAndBalanceWithPagination(transactionContext, "b1", "eur", "100", 2, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	require.Equal(t, "UniCredit", page.Records[1].Bank.Name)
+	require.Equal(t, int32(2), page.FetchedRecordsCount)
+	require.Equal(t, "g1AAAA", page.Bookmark)
+	_, pageSize, bookmark := chaincodeStub.GetQueryResultWithPaginationArgsForCall(0)
+	require.Equal(t, int32(2), pageSize)
+	require.Empty(t, bookmark)
+
+	// Test Case: A short page is the last one
+	chaincodeStub.GetQueryResultWithPaginationReturns(queryResults(
+		`{"ID":"a3","currency":"EUR","balance":10000,"bank_id":"b1"}`,
+	), &peer.QueryResponseMetadata{FetchedRecordsCount: 1, Bookmark: "g1AAAB"}, nil)
+	page, err = smartContract.GetAccountsByBankDesiredCurrencyAndBalanceWithPagination(transactionContext, "b1", "eur", "100", 2, "g1AAAA")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.Empty(t, page.Bookmark)
+
+	// Test Case: Users cannot search the accounts of a bank
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+	_, err = smartContract.GetAccountsByBankDesiredCurrencyAndBalanceWithPagination(transactionContext, "b1", "eur", "100", 2, "")
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+	require.Equal(t, 2, chaincodeStub.GetQueryResultWithPaginationCallCount())
+}
+
+func TestQueryAccounts(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	state := map[string][]byte{
+		"b1": []byte(`{"docType":"bank","ID":"b1","Name":"UniCredit","msp_id":"Org1MSP"}`),
+		"b2": []byte(`{"docType":"bank","ID":"b2","Name":"Raiffeisen Bank","msp_id":"Org2MSP"}`),
+		"b5": []byte(`{"docType":"bank","ID":"b5","Name":"Banca Intesa","msp_id":"Org1MSP"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	var accountQueries []string
+	accountResults := []string{`{"ID":"a1","user_id":"u1","currency":"EUR","balance":50000,"bank_id":"b1"}`}
+	chaincodeStub.GetQueryResultStub = func(query string) (shim.StateQueryIteratorInterface, error) {
+		if query == `{"selector":{"docType":"bank"}}` {
+			return queryResults(string(state["b1"]), string(state["b2"]), string(state["b5"])), nil
+		}
+		accountQueries = append(accountQueries, query)
+		return queryResults(accountResults...), nil
+	}
+
+	accounts, err := smartContract.QueryAccounts(transactionContext, `{"filters":[{"field":"currency","operator":"$in","value":["EUR","CHF"]}],"sort":[{"field":"balance","order":"desc"}],"limit":5}`)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, int64(50000), accounts[0].Balance)
+	require.Equal(t, "UniCredit", accounts[0].Bank.Name)
+	// Only accounts of the banks run by the organization of the caller
+	require.JSONEq(t, `{"selector":{"currency":{"$in":["EUR","CHF"]},"balance":{"$exists":true},"bank_id":{"$in":["b1","b5"]}},"sort":[{"balance":"desc"}],"limit":5}`, accountQueries[0])
+
+	// Test Case: Loans and cards sharing the fields of accounts are not returned
+	accountResults = []string{
+		`{"docType":"loan","ID":"l1","user_id":"u1","account_id":"a1","bank_id":"b1","currency":"EUR","status":"ACTIVE","principal":100000}`,
+		`{"ID":"a1","user_id":"u1","currency":"EUR","balance":50000,"bank_id":"b1","status":"ACTIVE"}`,
+		`{"docType":"card","ID":"c1","user_id":"u1","account_id":"a1","currency":"EUR","status":"ACTIVE"}`,
+	}
+	accounts, err = smartContract.QueryAccounts(transactionContext, `{"filters":[{"field":"status","operator":"$eq","value":"ACTIVE"}]}`)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, "a1", accounts[0].ID)
+
+	// Test Case: Organizations running no bank find no accounts
+	transactionContext.GetClientIdentityReturns(organizationAdminIdentity("Org3MSP"))
+	accounts, err = smartContract.QueryAccounts(transactionContext, `{"filters":[]}`)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+	require.Len(t, accountQueries, 2)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+
+	// Test Case: Fields outside of the whitelist
+	_, err = smartContract.QueryAccounts(transactionContext, `{"filters":[{"field":"limits","operator":"$eq","value":"x"}]}`)
+	require.EqualError(t, err, `field "limits" cannot be queried`)
+
+	// Test Case: Users cannot search accounts
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	_, err = smartContract.QueryAccounts(transactionContext, `{"filters":[]}`)
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+}
@@ -0,0 +1,429 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Due orders beyond this are left to the next run, so a single transaction
+// stays small enough to be endorsed in time.
+const maxStandingOrdersPerRun = 50
+
+// Only the latest failures are kept on the order
+const maxStandingOrderFailures = 10
+
+// Orders failing this many runs in a row are stopped
+const maxStandingOrderFailedRuns = 3
+
+func (s *SmartContract) CreateStandingOrder(ctx contractapi.TransactionContextInterface, srcAccount, dstAccount, amountStr, schedule, firstRunStr, endDateStr string) (*model.StandingOrder, error) {
+	sourceAccount, err := readBankAccount(ctx, srcAccount)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := assertAccountOwner(ctx, sourceAccount)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(sourceAccount); err != nil {
+		return nil, err
+	}
+
+	currency, err := readCurrency(ctx, sourceAccount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(amountStr, *currency)
+	if err != nil {
+		return nil, err
+	}
+
+	destAccount, err := readBankAccount(ctx, dstAccount)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertAccountActive(destAccount); err != nil {
+		return nil, err
+	}
+	if destAccount.ID == sourceAccount.ID {
+		return nil, fmt.Errorf("money cannot be transferred to the same account")
+	}
+
+	orderSchedule, err := parseSchedule(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	firstRun := timestamp.AsTime()
+	if firstRunStr != "" {
+		firstRun, err = time.Parse(time.RFC3339, firstRunStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse first run: %v", err)
+		}
+		if firstRun.Before(timestamp.AsTime()) {
+			return nil, fmt.Errorf("first run cannot be in the past")
+		}
+	}
+	var endDate time.Time
+	if endDateStr != "" {
+		endDate, err = time.Parse(time.RFC3339, endDateStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse end date: %v", err)
+		}
+		if endDate.Before(firstRun) {
+			return nil, fmt.Errorf("end date cannot be before the first run")
+		}
+	}
+
+	// Times are kept in whole seconds of UTC, so queries can compare them as strings
+	firstRun = firstRun.UTC().Truncate(time.Second)
+	order := model.StandingOrder{
+		DocType:            model.StandingOrderDocType,
+		ID:                 ctx.GetStub().GetTxID(),
+		UserID:             identity.UserID,
+		SourceAccount:      sourceAccount.ID,
+		DestinationAccount: destAccount.ID,
+		Amount:             amount,
+		Currency:           sourceAccount.Currency,
+		Schedule:           orderSchedule,
+		FirstRun:           firstRun,
+		NextRun:            firstRun,
+		EndDate:            endDate.UTC(),
+		Status:             model.StandingOrderActive,
+	}
+	if err := utils.PutDataToState(ctx, order, order.ID); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (s *SmartContract) CancelStandingOrder(ctx contractapi.TransactionContextInterface, id string) (*model.StandingOrder, error) {
+	order, err := readOwnStandingOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != model.StandingOrderActive {
+		return nil, fmt.Errorf("standing order %s is not active", id)
+	}
+
+	order.Status = model.StandingOrderCancelled
+	if err := utils.PutDataToState(ctx, order, order.ID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *SmartContract) ListStandingOrders(ctx contractapi.TransactionContextInterface, accountID string) ([]model.StandingOrder, error) {
+	account, err := readBankAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertAccountOwner(ctx, account); err != nil {
+		return nil, err
+	}
+
+	return queryStandingOrders(ctx, map[string]interface{}{
+		"docType":        model.StandingOrderDocType,
+		"source_account": account.ID,
+	}, 0)
+}
+
+// ExecuteDueStandingOrders makes the transfers of all active orders whose
+// next run has come. An order that cannot be executed, e.g. for lack of money,
+// keeps the reason and moves on to its next run like an executed one; it
+// fails for good once one of its accounts is closed or after
+// maxStandingOrderFailedRuns failed runs in a row. Any administrator may run
+// them, the owners of the accounts ordered the transfers.
+func (s *SmartContract) ExecuteDueStandingOrders(ctx contractapi.TransactionContextInterface) ([]model.StandingOrderRun, error) {
+	if err := assertAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	now := timestamp.AsTime()
+
+	orders, err := queryStandingOrders(ctx, map[string]interface{}{
+		"docType":  model.StandingOrderDocType,
+		"status":   model.StandingOrderActive,
+		"next_run": map[string]interface{}{"$lte": now.UTC().Truncate(time.Second)},
+	}, maxStandingOrdersPerRun)
+	if err != nil {
+		return nil, err
+	}
+
+	// Writes of this transaction cannot be read back, orders sharing an
+	// account have to see each other's balance changes through this cache.
+	accounts := map[string]*model.BankAccount{}
+	released := map[string]bool{}
+	revenue := map[string]*model.RevenueAccount{}
+
+	runs := make([]model.StandingOrderRun, 0, len(orders))
+	for i := range orders {
+		order := &orders[i]
+		run := model.StandingOrderRun{OrderID: order.ID}
+
+		sourceAccount, destAccount, transaction, err := prepareStandingOrderTransfer(ctx, accounts, released, order, now)
+		if err != nil {
+			run.Error = err.Error()
+			order.Failures = append(order.Failures, model.StandingOrderFailure{RunAt: order.NextRun, Reason: run.Error})
+			if len(order.Failures) > maxStandingOrderFailures {
+				order.Failures = order.Failures[len(order.Failures)-maxStandingOrderFailures:]
+			}
+			order.FailedRuns++
+		} else {
+			transaction.ID = fmt.Sprintf("%s-%d", ctx.GetStub().GetTxID(), i)
+			recorded, err := executeTransfer(ctx, sourceAccount, destAccount, *transaction, now, revenue)
+			if err != nil {
+				return nil, err
+			}
+			run.TransactionID = recorded.ID
+			order.LastTransactionID = recorded.ID
+			order.FailedRuns = 0
+		}
+
+		advanceStandingOrder(order)
+		if order.FailedRuns >= maxStandingOrderFailedRuns || standingOrderAccountClosed(accounts, order) {
+			order.Status = model.StandingOrderFailed
+		}
+		if err := utils.PutDataToState(ctx, order, order.ID); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	// Accounts whose holds expired are written even when none of their
+	// orders could be executed, sorted so that every peer writes alike
+	releasedIDs := make([]string, 0, len(released))
+	for id := range released {
+		releasedIDs = append(releasedIDs, id)
+	}
+	sort.Strings(releasedIDs)
+	for _, id := range releasedIDs {
+		if err := utils.PutDataToState(ctx, accounts[id], id); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(runs) > 0 {
+		err = emitEvent(ctx, model.EventStandingOrdersRun, model.StandingOrdersRunEvent{
+			TxID:      ctx.GetStub().GetTxID(),
+			Runs:      runs,
+			Timestamp: now,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return runs, nil
+}
+
+// prepareStandingOrderTransfer checks everything a transfer of the order needs
+// and converts the amount at the rate in force. The errors it returns are the
+// reasons the order failed, nothing has been changed when it returns one.
+func prepareStandingOrderTransfer(ctx contractapi.TransactionContextInterface, accounts map[string]*model.BankAccount, released map[string]bool, order *model.StandingOrder, at time.Time) (*model.BankAccount, *model.BankAccount, *model.Transaction, error) {
+	sourceAccount, err := cachedBankAccount(ctx, accounts, released, order.SourceAccount, at)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := assertAccountActive(sourceAccount); err != nil {
+		return nil, nil, nil, err
+	}
+	destAccount, err := cachedBankAccount(ctx, accounts, released, order.DestinationAccount, at)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := assertAccountActive(destAccount); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if availableFunds(sourceAccount) < order.Amount {
+		return nil, nil, nil, fmt.Errorf("not enough money")
+	}
+
+	sourceCurrency, err := readCurrency(ctx, sourceAccount.Currency)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	destCurrency, err := readCurrency(ctx, destAccount.Currency)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	appliedRates, rate, err := effectiveExchangeRate(ctx, sourceAccount.Currency, destAccount.Currency, at)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	convertedAmount, err := utils.ConvertAmount(order.Amount, *sourceCurrency, *destCurrency, rate)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fees, err := transferFees(ctx, sourceAccount, destAccount, *sourceCurrency, order.Amount)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if availableFunds(sourceAccount) < order.Amount+totalFees(fees) {
+		return nil, nil, nil, fmt.Errorf("not enough money")
+	}
+
+	// Limits go last, they count the amount as spent once they pass
+	if err := chargeSpendingLimits(ctx, sourceAccount, model.TransactionTransfer, order.Amount, at); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return sourceAccount, destAccount, &model.Transaction{
+		Amount:            order.Amount,
+		Currency:          sourceAccount.Currency,
+		ConvertedAmount:   convertedAmount,
+		ConvertedCurrency: destAccount.Currency,
+		Rate:              rate.FloatString(utils.RateDecimals),
+		RateIDs:           exchangeRateIDs(appliedRates),
+		Fees:              fees,
+		StandingOrder:     order.ID,
+	}, nil
+}
+
+// cachedBankAccount reads an account once per run and releases its expired
+// holds then, marking it released when there were any.
+func cachedBankAccount(ctx contractapi.TransactionContextInterface, accounts map[string]*model.BankAccount, released map[string]bool, id string, at time.Time) (*model.BankAccount, error) {
+	if account, ok := accounts[id]; ok {
+		return account, nil
+	}
+	account, err := readBankAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	held := account.Held
+	if err := expireHolds(ctx, account, at); err != nil {
+		return nil, err
+	}
+	if account.Held != held {
+		account.LastOperation = model.OperationHoldRelease
+		released[id] = true
+	}
+	accounts[id] = account
+	return account, nil
+}
+
+// standingOrderAccountClosed tells whether an account of the order read in
+// this run is closed, the order can never run again then.
+func standingOrderAccountClosed(accounts map[string]*model.BankAccount, order *model.StandingOrder) bool {
+	for _, id := range []string{order.SourceAccount, order.DestinationAccount} {
+		if account, ok := accounts[id]; ok && accountStatus(account) == model.AccountClosed {
+			return true
+		}
+	}
+	return false
+}
+
+// advanceStandingOrder moves the order to its next run, or finishes it when
+// that would be after the end date.
+func advanceStandingOrder(order *model.StandingOrder) {
+	order.Runs++
+	order.NextRun = scheduledRun(order.FirstRun, order.Schedule, order.Runs)
+	if !order.EndDate.IsZero() && order.NextRun.After(order.EndDate) {
+		order.Status = model.StandingOrderFinished
+	}
+}
+
+// scheduledRun returns the time of the n-th run after the first one. Monthly
+// orders keep the day of the month of their first run, falling back to the
+// last day of shorter months.
+func scheduledRun(firstRun time.Time, schedule model.StandingOrderSchedule, n int) time.Time {
+	switch schedule {
+	case model.ScheduleDaily:
+		return firstRun.AddDate(0, 0, n)
+	case model.ScheduleWeekly:
+		return firstRun.AddDate(0, 0, 7*n)
+	default:
+		year, month, day := firstRun.Date()
+		monthStart := time.Date(year, month+time.Month(n), 1, firstRun.Hour(), firstRun.Minute(), firstRun.Second(), firstRun.Nanosecond(), firstRun.Location())
+		if lastDay := monthStart.AddDate(0, 1, -1).Day(); day > lastDay {
+			day = lastDay
+		}
+		return monthStart.AddDate(0, 0, day-1)
+	}
+}
+
+func parseSchedule(schedule string) (model.StandingOrderSchedule, error) {
+	switch parsed := model.StandingOrderSchedule(strings.ToUpper(strings.TrimSpace(schedule))); parsed {
+	case model.ScheduleDaily, model.ScheduleWeekly, model.ScheduleMonthly:
+		return parsed, nil
+	default:
+		return "", fmt.Errorf("invalid schedule: %s", schedule)
+	}
+}
+
+// readOwnStandingOrder reports orders of other users as missing, like their accounts.
+func readOwnStandingOrder(ctx contractapi.TransactionContextInterface, id string) (*model.StandingOrder, error) {
+	notFound := fmt.Errorf("the standing order with id %s does not exist", id)
+
+	orderJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if orderJSON == nil {
+		return nil, notFound
+	}
+
+	var order model.StandingOrder
+	if err := json.Unmarshal(orderJSON, &order); err != nil {
+		return nil, err
+	}
+	if order.DocType != model.StandingOrderDocType {
+		return nil, notFound
+	}
+
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != identity.UserID {
+		return nil, notFound
+	}
+
+	return &order, nil
+}
+
+// queryStandingOrders returns at most limit orders, a zero limit returns all.
+func queryStandingOrders(ctx contractapi.TransactionContextInterface, selector map[string]interface{}, limit int) ([]model.StandingOrder, error) {
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %v", err)
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	var orders []model.StandingOrder
+	for queryResults.HasNext() && (limit == 0 || len(orders) < limit) {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var order model.StandingOrder
+		if err := json.Unmarshal(queryResult.Value, &order); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal standing order: %v", err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
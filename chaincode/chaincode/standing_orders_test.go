@@ -0,0 +1,225 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCreateStandingOrder(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("so1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.GetStateReturnsOnCall(0, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(1, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(2, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(3, []byte(`{"ID":"a2","user_id":"u2","currency":"RSD","balance":0}`), nil)
+
+	_, err := smartContract.CreateStandingOrder(transactionContext, "a1", "a2", "450", "monthly", "2024-02-01T12:00:00+02:00", "2024-12-31T00:00:00Z")
+	require.NoError(t, err)
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "so1", key)
+	require.JSONEq(t, `{
+		"docType":"standingOrder",
+		"ID":"so1",
+		"user_id":"u1",
+		"source_account":"a1",
+		"destination_account":"a2",
+		"amount":45000,
+		"currency":"EUR",
+		"schedule":"MONTHLY",
+		"first_run":"2024-02-01T10:00:00Z",
+		"runs":0,
+		"next_run":"2024-02-01T10:00:00Z",
+		"end_date":"2024-12-31T00:00:00Z",
+		"status":"ACTIVE"
+	}`, string(value))
+
+	// Test Case: Unknown schedule
+	chaincodeStub.GetStateReturnsOnCall(4, []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(5, []byte(`{"ID":"u1"}`), nil)
+	chaincodeStub.GetStateReturnsOnCall(6, eurDefinition, nil)
+	chaincodeStub.GetStateReturnsOnCall(7, []byte(`{"ID":"a2","user_id":"u2","currency":"RSD","balance":0}`), nil)
+	_, err = smartContract.CreateStandingOrder(transactionContext, "a1", "a2", "450", "yearly", "", "")
+	require.EqualError(t, err, "invalid schedule: yearly")
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+}
+
+func TestExecuteDueStandingOrders(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 9, 0, 0, 500, time.UTC)), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"a1":           []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":15000}`),
+		"a2":           []byte(`{"ID":"a2","user_id":"u2","currency":"EUR","balance":0}`),
+		"a3":           []byte(`{"ID":"a3","user_id":"u3","currency":"EUR","balance":0}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	// Both orders draw on a1, which only covers the first one
+	orders := &mocks.StateQueryIterator{}
+	orders.HasNextReturnsOnCall(0, true)
+	orders.HasNextReturnsOnCall(1, true)
+	orders.NextReturnsOnCall(0, &queryresult.KV{Value: []byte(`{"docType":"standingOrder","ID":"so1","user_id":"u1","source_account":"a1","destination_account":"a2","amount":10000,"currency":"EUR","schedule":"MONTHLY","first_run":"2024-01-31T09:00:00Z","runs":0,"next_run":"2024-01-31T09:00:00Z","status":"ACTIVE"}`)}, nil)
+	orders.NextReturnsOnCall(1, &queryresult.KV{Value: []byte(`{"docType":"standingOrder","ID":"so2","user_id":"u1","source_account":"a1","destination_account":"a3","amount":10000,"currency":"EUR","schedule":"WEEKLY","first_run":"2024-01-25T09:00:00Z","runs":0,"next_run":"2024-01-25T09:00:00Z","end_date":"2024-01-31T00:00:00Z","status":"ACTIVE"}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(orders, nil)
+
+	runs, err := smartContract.ExecuteDueStandingOrders(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, []model.StandingOrderRun{
+		{OrderID: "so1", TransactionID: "tx1-0"},
+		{OrderID: "so2", Error: "not enough money"},
+	}, runs)
+	require.JSONEq(t, `{"selector":{"docType":"standingOrder","status":"ACTIVE","next_run":{"$lte":"2024-02-01T09:00:00Z"}}}`, chaincodeStub.GetQueryResultArgsForCall(0))
+
+	written := map[string][]byte{}
+	for i := 0; i < chaincodeStub.PutStateCallCount(); i++ {
+		key, value := chaincodeStub.PutStateArgsForCall(i)
+		written[key] = value
+	}
+	require.Len(t, written, 5)
+
+	var transaction model.Transaction
+	require.NoError(t, json.Unmarshal(written["tx1-0"], &transaction))
+	require.Equal(t, "so1", transaction.StandingOrder)
+	require.Equal(t, int64(100_00), transaction.ConvertedAmount)
+
+	// Monthly orders of the 31st fall on the last day of February
+	var executed model.StandingOrder
+	require.NoError(t, json.Unmarshal(written["so1"], &executed))
+	require.Equal(t, time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC), executed.NextRun)
+	require.Equal(t, "tx1-0", executed.LastTransactionID)
+	require.Equal(t, model.StandingOrderActive, executed.Status)
+
+	// The failed run is kept and the order ends after its end date
+	var failed model.StandingOrder
+	require.NoError(t, json.Unmarshal(written["so2"], &failed))
+	require.Equal(t, []model.StandingOrderFailure{{RunAt: time.Date(2024, 1, 25, 9, 0, 0, 0, time.UTC), Reason: "not enough money"}}, failed.Failures)
+	require.Equal(t, 1, failed.Runs)
+	require.Equal(t, model.StandingOrderFinished, failed.Status)
+
+	require.Equal(t, 1, chaincodeStub.SetEventCallCount())
+	name, _ := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "StandingOrdersRun", name)
+
+	// Test Case: Not an administrator
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	_, err = smartContract.ExecuteDueStandingOrders(transactionContext)
+	require.EqualError(t, err, "only administrators are allowed to perform this action")
+}
+
+func TestExecuteDueStandingOrders_HoldsAndFailures(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	state := map[string][]byte{
+		"a1":           []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":10000,"held":5000}`),
+		"a2":           []byte(`{"ID":"a2","user_id":"u2","currency":"EUR","balance":0}`),
+		"a3":           []byte(`{"ID":"a3","user_id":"u3","currency":"EUR","balance":0,"status":"CLOSED"}`),
+		"a4":           []byte(`{"ID":"a4","user_id":"u1","currency":"EUR","balance":10000,"status":"FROZEN"}`),
+		"currency~EUR": eurDefinition,
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	orders := &mocks.StateQueryIterator{}
+	orders.HasNextReturnsOnCall(0, true)
+	orders.HasNextReturnsOnCall(1, true)
+	orders.HasNextReturnsOnCall(2, true)
+	orders.NextReturnsOnCall(0, &queryresult.KV{Value: []byte(`{"docType":"standingOrder","ID":"so1","user_id":"u1","source_account":"a1","destination_account":"a2","amount":10000,"currency":"EUR","schedule":"DAILY","first_run":"2024-02-01T09:00:00Z","next_run":"2024-02-01T09:00:00Z","status":"ACTIVE"}`)}, nil)
+	orders.NextReturnsOnCall(1, &queryresult.KV{Value: []byte(`{"docType":"standingOrder","ID":"so2","user_id":"u1","source_account":"a1","destination_account":"a3","amount":100,"currency":"EUR","schedule":"DAILY","first_run":"2024-02-01T09:00:00Z","next_run":"2024-02-01T09:00:00Z","status":"ACTIVE"}`)}, nil)
+	orders.NextReturnsOnCall(2, &queryresult.KV{Value: []byte(`{"docType":"standingOrder","ID":"so3","user_id":"u1","source_account":"a4","destination_account":"a2","amount":100,"currency":"EUR","schedule":"DAILY","first_run":"2024-01-30T09:00:00Z","runs":2,"next_run":"2024-02-01T09:00:00Z","status":"ACTIVE","failed_runs":2}`)}, nil)
+	expired := &mocks.StateQueryIterator{}
+	expired.HasNextReturnsOnCall(0, true)
+	expired.NextReturnsOnCall(0, &queryresult.KV{Value: []byte(`{"docType":"hold","ID":"hold1","account_id":"a1","payee_account":"a2","amount":5000,"currency":"EUR","status":"ACTIVE","expires_at":"2024-02-01T08:00:00Z"}`)}, nil)
+	chaincodeStub.GetQueryResultStub = func(query string) (shim.StateQueryIteratorInterface, error) {
+		if strings.Contains(query, `"docType":"hold"`) {
+			return expired, nil
+		}
+		return orders, nil
+	}
+
+	// The expired hold no longer blocks the money of so1
+	runs, err := smartContract.ExecuteDueStandingOrders(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, []model.StandingOrderRun{
+		{OrderID: "so1", TransactionID: "tx1-0"},
+		{OrderID: "so2", Error: "bank account a3 is closed"},
+		{OrderID: "so3", Error: "bank account a4 is frozen"},
+	}, runs)
+
+	written := map[string][]byte{}
+	for i := 0; i < chaincodeStub.PutStateCallCount(); i++ {
+		key, value := chaincodeStub.PutStateArgsForCall(i)
+		written[key] = value
+	}
+
+	var account model.BankAccount
+	require.NoError(t, json.Unmarshal(written["a1"], &account))
+	require.Equal(t, int64(0), account.Balance)
+	require.Equal(t, int64(0), account.Held)
+	var hold model.Hold
+	require.NoError(t, json.Unmarshal(written["hold1"], &hold))
+	require.Equal(t, model.HoldExpired, hold.Status)
+
+	// Orders on closed accounts and orders failing too often stop
+	var order model.StandingOrder
+	require.NoError(t, json.Unmarshal(written["so2"], &order))
+	require.Equal(t, model.StandingOrderFailed, order.Status)
+	require.NoError(t, json.Unmarshal(written["so3"], &order))
+	require.Equal(t, model.StandingOrderFailed, order.Status)
+	require.Equal(t, 3, order.FailedRuns)
+}
+
+func TestCancelStandingOrder_OfAnotherUser(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(userIdentity("u2"))
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetStateReturns([]byte(`{"docType":"standingOrder","ID":"so1","user_id":"u1","status":"ACTIVE"}`), nil)
+
+	_, err := smartContract.CancelStandingOrder(transactionContext, "so1")
+	require.EqualError(t, err, "the standing order with id so1 does not exist")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+}
@@ -0,0 +1,169 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func recordTransaction(ctx contractapi.TransactionContextInterface, transaction model.Transaction) (*model.Transaction, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	initiator, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client identity: %v", err)
+	}
+
+	transaction.DocType = model.TransactionDocType
+	// Contract functions recording several transactions number them
+	if transaction.ID == "" {
+		transaction.ID = ctx.GetStub().GetTxID()
+	}
+	transaction.Timestamp = timestamp.AsTime().UTC().Truncate(time.Second)
+	transaction.Initiator = initiator
+
+	if err := utils.PutDataToState(ctx, transaction, transaction.ID); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (s *SmartContract) ReadTransaction(ctx contractapi.TransactionContextInterface, id string) (*model.Transaction, error) {
+	transactionJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if transactionJSON == nil {
+		return nil, fmt.Errorf("the transaction with id %s does not exist", id)
+	}
+
+	var transaction model.Transaction
+	if err := json.Unmarshal(transactionJSON, &transaction); err != nil {
+		return nil, err
+	}
+	if transaction.DocType != model.TransactionDocType {
+		return nil, fmt.Errorf("the transaction with id %s does not exist", id)
+	}
+	// Users only see transactions of their own accounts, administrators those
+	// of the accounts of their banks
+	if !canSeeTransaction(ctx, &transaction) {
+		return nil, fmt.Errorf("the transaction with id %s does not exist", id)
+	}
+
+	return &transaction, nil
+}
+
+func canSeeTransaction(ctx contractapi.TransactionContextInterface, transaction *model.Transaction) bool {
+	for _, accountID := range []string{transaction.SourceAccount, transaction.DestinationAccount} {
+		if accountID == "" {
+			continue
+		}
+		account, err := readBankAccount(ctx, accountID)
+		if err != nil {
+			continue
+		}
+		if assertBankAdmin(ctx, account.BankID) == nil {
+			return true
+		}
+		if _, err := assertAccountOwner(ctx, account); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTransactions filters payment records of an account, every other empty
+// argument is left out of the query. Dates are expected in RFC3339 format and
+// compared in whole seconds. Users name one of their accounts, administrators
+// an account of one of their banks.
+func (s *SmartContract) GetTransactions(ctx contractapi.TransactionContextInterface, accountId, transactionType, from, to string) ([]model.Transaction, error) {
+	if accountId == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	account, err := readBankAccount(ctx, accountId)
+	if err != nil {
+		return nil, err
+	}
+	if assertBankAdmin(ctx, account.BankID) != nil {
+		if _, err := assertAccountOwner(ctx, account); err != nil {
+			return nil, err
+		}
+	}
+
+	selector := map[string]interface{}{
+		"docType": model.TransactionDocType,
+		"$or": []map[string]interface{}{
+			{"source_account": accountId},
+			{"destination_account": accountId},
+		},
+	}
+
+	if transactionType != "" {
+		switch model.TransactionType(transactionType) {
+		case model.TransactionTransfer, model.TransactionDeposit, model.TransactionWithdrawal, model.TransactionPayout, model.TransactionOverdraftInterest, model.TransactionInterest,
+			model.TransactionLoanDisbursement, model.TransactionLoanRepayment, model.TransactionCrossChannel:
+			selector["type"] = transactionType
+		default:
+			return nil, fmt.Errorf("invalid transaction type: %s", transactionType)
+		}
+	}
+
+	timestampRange := map[string]interface{}{}
+	if from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse from date: %v", err)
+		}
+		timestampRange["$gte"] = fromTime.UTC().Truncate(time.Second)
+	}
+	if to != "" {
+		toTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse to date: %v", err)
+		}
+		timestampRange["$lte"] = toTime.UTC().Truncate(time.Second)
+	}
+	if len(timestampRange) > 0 {
+		selector["timestamp"] = timestampRange
+	}
+
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, err
+	}
+
+	queryResults, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	var transactions []model.Transaction
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var transaction model.Transaction
+		if err := json.Unmarshal(queryResult.Value, &transaction); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal transaction: %v", err)
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Timestamp.Before(transactions[j].Timestamp)
+	})
+
+	return transactions, nil
+}
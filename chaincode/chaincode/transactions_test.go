@@ -0,0 +1,133 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"chaincode/model"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTransaction(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	state := map[string][]byte{
+		"tx1":   []byte(`{"docType":"transaction","ID":"tx1","type":"DEPOSIT","amount":50,"destination_account":"a1"}`),
+		"a1":    []byte(`{"ID":"a1","user_id":"usrID","balance":100,"bank_id":"b1"}`),
+		"usrID": []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`),
+		"b1":    []byte(`{"docType":"bank","ID":"b1","name":"UniCredit","msp_id":"Org1MSP"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	// Test Case 1: Transaction exists
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	transaction, err := smartContract.ReadTransaction(transactionContext, "tx1")
+	require.NoError(t, err)
+	require.Equal(t, model.TransactionDeposit, transaction.Type)
+
+	// Test Case 2: Key holds another asset
+	_, err = smartContract.ReadTransaction(transactionContext, "a1")
+	require.EqualError(t, err, "the transaction with id a1 does not exist")
+
+	// Test Case 3: The owner of the account reads the transaction
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+	_, err = smartContract.ReadTransaction(transactionContext, "tx1")
+	require.NoError(t, err)
+
+	// Test Case 4: Other users do not see it
+	transactionContext.GetClientIdentityReturns(userIdentity("otherID"))
+	_, err = smartContract.ReadTransaction(transactionContext, "tx1")
+	require.EqualError(t, err, "the transaction with id tx1 does not exist")
+
+	// Test Case 5: Neither do administrators of other organizations
+	transactionContext.GetClientIdentityReturns(organizationAdminIdentity("Org2MSP"))
+	_, err = smartContract.ReadTransaction(transactionContext, "tx1")
+	require.EqualError(t, err, "the transaction with id tx1 does not exist")
+}
+
+func TestGetTransactions(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	state := map[string][]byte{
+		"a1":    []byte(`{"ID":"a1","user_id":"usrID","balance":100,"bank_id":"b1"}`),
+		"usrID": []byte(`{"ID":"usrID","msp_id":"Org1MSP"}`),
+		"b1":    []byte(`{"docType":"bank","ID":"b1","name":"UniCredit","msp_id":"Org1MSP"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	transactionContext.GetClientIdentityReturns(userIdentity("usrID"))
+
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.HasNextReturnsOnCall(1, true)
+	iterator.HasNextReturnsOnCall(2, false)
+	iterator.NextReturnsOnCall(0, &queryresult.KV{Value: []byte(`{"docType":"transaction","ID":"tx2","timestamp":"2024-02-02T10:00:00Z"}`)}, nil)
+	iterator.NextReturnsOnCall(1, &queryresult.KV{Value: []byte(`{"docType":"transaction","ID":"tx1","timestamp":"2024-02-01T10:00:00Z"}`)}, nil)
+	chaincodeStub.GetQueryResultReturns(iterator, nil)
+
+	transactions, err := smartContract.GetTransactions(transactionContext, "a1", "TRANSFER", "2024-02-01T00:00:00.25Z", "2024-02-03T00:00:00.999+01:00")
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	require.Equal(t, "tx1", transactions[0].ID)
+	require.Equal(t, "tx2", transactions[1].ID)
+
+	require.JSONEq(t, `{
+		"selector": {
+			"docType": "transaction",
+			"$or": [{"source_account": "a1"}, {"destination_account": "a1"}],
+			"type": "TRANSFER",
+			"timestamp": {"$gte": "2024-02-01T00:00:00Z", "$lte": "2024-02-02T23:00:00Z"}
+		}
+	}`, chaincodeStub.GetQueryResultArgsForCall(0))
+
+	// Test Case: Users only list the transactions of their own accounts
+	transactionContext.GetClientIdentityReturns(userIdentity("otherID"))
+	_, err = smartContract.GetTransactions(transactionContext, "a1", "", "", "")
+	require.EqualError(t, err, "bank account with ID a1 not found for user otherID")
+
+	// Test Case: Administrators only list the transactions of accounts of their banks
+	transactionContext.GetClientIdentityReturns(organizationAdminIdentity("Org2MSP"))
+	_, err = smartContract.GetTransactions(transactionContext, "a1", "", "", "")
+	require.EqualError(t, err, "bank account with ID a1 not found for user org2admin")
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	_, err = smartContract.GetTransactions(transactionContext, "", "", "", "")
+	require.EqualError(t, err, "account ID is required")
+	require.Equal(t, 1, chaincodeStub.GetQueryResultCallCount())
+}
+
+func TestGetTransactions_InvalidFilters(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	state := map[string][]byte{
+		"a1": []byte(`{"ID":"a1","user_id":"usrID","balance":100,"bank_id":"b1"}`),
+		"b1": []byte(`{"docType":"bank","ID":"b1","name":"UniCredit","msp_id":"Org1MSP"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	_, err := smartContract.GetTransactions(transactionContext, "a1", "REFUND", "", "")
+	require.EqualError(t, err, "invalid transaction type: REFUND")
+
+	_, err = smartContract.GetTransactions(transactionContext, "a1", "", "yesterday", "")
+	require.Error(t, err)
+	require.Equal(t, 0, chaincodeStub.GetQueryResultCallCount())
+}
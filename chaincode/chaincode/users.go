@@ -0,0 +1,199 @@
+package chaincode
+
+import (
+	"chaincode/chaincode/query"
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// userTransientKey is where AddUser expects the personal data of the user in
+// the transient map, so it is never written to the transaction itself.
+const userTransientKey = "user"
+
+// piiCollection is the private data collection of the organization, as
+// declared in collections_config.json.
+func piiCollection(mspID string) string {
+	return mspID + "PIICollection"
+}
+
+// putUser keeps the personal data of the user in the collection and the
+// public record with its hash in the world state.
+func putUser(ctx contractapi.TransactionContextInterface, details model.UserDetails, collection, salt string) error {
+	pii := model.UserPII{
+		DocType: model.UserPIIDocType,
+		ID:      details.ID,
+		Name:    details.Name,
+		Surname: details.Surname,
+		Email:   details.Email,
+		Salt:    salt,
+	}
+	piiJSON, err := json.Marshal(pii)
+	if err != nil {
+		return err
+	}
+
+	user := details.User
+	user.PIICollection = collection
+	user.PIIHash = piiHash(piiJSON)
+	if err := ctx.GetStub().PutPrivateData(user.PIICollection, user.ID, piiJSON); err != nil {
+		return fmt.Errorf("failed to put to private data collection %s: %v", user.PIICollection, err)
+	}
+	return utils.PutDataToState(ctx, user, user.ID)
+}
+
+func piiHash(piiJSON []byte) string {
+	hash := sha256.Sum256(piiJSON)
+	return hex.EncodeToString(hash[:])
+}
+
+// readUserPII returns the personal data of a user to administrators of the
+// organization keeping it and to the user. Users of other organizations are
+// reported as not shared, other users as missing.
+func readUserPII(ctx contractapi.TransactionContextInterface, id string) (*model.UserPII, error) {
+	userJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if userJSON == nil {
+		return nil, fmt.Errorf("the user %s does not exist", id)
+	}
+
+	var user model.User
+	if err := json.Unmarshal(userJSON, &user); err != nil {
+		return nil, err
+	}
+
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !identity.Admin && identity.UserID != user.ID {
+		return nil, fmt.Errorf("the user %s does not exist", id)
+	}
+	if user.PIICollection != piiCollection(identity.MSPID) {
+		return nil, fmt.Errorf("personal data of user %s is not shared with %s", id, identity.MSPID)
+	}
+
+	piiJSON, err := ctx.GetStub().GetPrivateData(user.PIICollection, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from private data collection %s: %v", user.PIICollection, err)
+	}
+	if piiJSON == nil {
+		return nil, fmt.Errorf("personal data of user %s is missing", id)
+	}
+	if piiHash(piiJSON) != user.PIIHash {
+		return nil, fmt.Errorf("personal data of user %s does not match its hash", id)
+	}
+
+	var pii model.UserPII
+	if err := json.Unmarshal(piiJSON, &pii); err != nil {
+		return nil, err
+	}
+	return &pii, nil
+}
+
+// userQueryFields are the fields of the personal data QueryUsers may filter
+// and sort on.
+var userQueryFields = []string{"ID", "name", "surname", "email"}
+
+// queryUserPII searches the personal data kept by the organization of the
+// caller, only administrators may search it.
+func queryUserPII(ctx contractapi.TransactionContextInterface, q *query.Builder) ([]model.UserPII, error) {
+	if err := assertAdmin(ctx); err != nil {
+		return nil, err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client identity: %v", err)
+	}
+
+	queryString, err := q.Where("docType", query.Eq, model.UserPIIDocType).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryResults, err := ctx.GetStub().GetPrivateDataQueryResult(piiCollection(mspID), queryString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	var users []model.UserPII
+	for queryResults.HasNext() {
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var user model.UserPII
+		if err := json.Unmarshal(queryResult.Value, &user); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal user: %v", err)
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// queryUserPIIWithPagination pages through the personal data kept by the
+// organization of the caller in the order of user IDs. Private data queries
+// do not support bookmarks, so the bookmark is the ID of the last user of the
+// page and the next page starts after it.
+func queryUserPIIWithPagination(ctx contractapi.TransactionContextInterface, q *query.Builder, pageSize int, bookmark string) (*model.UserPIIPage, error) {
+	if err := assertAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive")
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client identity: %v", err)
+	}
+
+	// The sorted field has to be in the selector for CouchDB to use an index
+	queryString, err := q.
+		Where("docType", query.Eq, model.UserPIIDocType).
+		Where("ID", query.Gt, bookmark).
+		Sort("ID", query.Asc).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryResults, err := ctx.GetStub().GetPrivateDataQueryResult(piiCollection(mspID), queryString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer queryResults.Close()
+
+	page := &model.UserPIIPage{Records: []model.UserPII{}}
+	for queryResults.HasNext() {
+		if len(page.Records) == pageSize {
+			page.Bookmark = page.Records[pageSize-1].ID
+			break
+		}
+
+		queryResult, err := queryResults.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var user model.UserPII
+		if err := json.Unmarshal(queryResult.Value, &user); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal user: %v", err)
+		}
+
+		page.Records = append(page.Records, user)
+	}
+	page.FetchedRecordsCount = int32(len(page.Records))
+
+	return page, nil
+}
@@ -0,0 +1,116 @@
+package chaincode_test
+
+import (
+	"chaincode/chaincode"
+	"chaincode/chaincode/mocks"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUserByBankAccountId_PrivateData(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	smartContract := chaincode.SmartContract{}
+
+	piiJSON := []byte(`{"docType":"userPII","ID":"u1","name":"Aleksandar","surname":"Stojanovic","email":"aleksandar@gmail.com","salt":"s4lt"}`)
+	hash := sha256.Sum256(piiJSON)
+	state := map[string][]byte{
+		"a1": []byte(`{"ID":"a1","user_id":"u1","currency":"EUR","balance":0,"bank_id":"b1"}`),
+		"u1": []byte(`{"ID":"u1","pii_hash":"` + hex.EncodeToString(hash[:]) + `","pii_collection":"Org1MSPPIICollection","msp_id":"Org1MSP"}`),
+	}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.GetPrivateDataReturns(piiJSON, nil)
+
+	// Test Case: The user reads their own personal data
+	transactionContext.GetClientIdentityReturns(userIdentity("u1"))
+	user, err := smartContract.GetUserByBankAccountId(transactionContext, "a1")
+	require.NoError(t, err)
+	require.Equal(t, "Aleksandar", user.Name)
+	collection, key := chaincodeStub.GetPrivateDataArgsForCall(0)
+	require.Equal(t, "Org1MSPPIICollection", collection)
+	require.Equal(t, "u1", key)
+
+	// Test Case: Other users do not see it
+	transactionContext.GetClientIdentityReturns(userIdentity("u2"))
+	_, err = smartContract.GetUserByBankAccountId(transactionContext, "a1")
+	require.EqualError(t, err, "the user u1 does not exist")
+
+	// Test Case: Administrators of other organizations do not see it
+	otherAdmin := adminIdentity()
+	otherAdmin.GetMSPIDReturns("Org2MSP", nil)
+	transactionContext.GetClientIdentityReturns(otherAdmin)
+	_, err = smartContract.GetUserByBankAccountId(transactionContext, "a1")
+	require.EqualError(t, err, "personal data of user u1 is not shared with Org2MSP")
+
+	// Test Case: Tampered personal data is rejected
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	chaincodeStub.GetPrivateDataReturns([]byte(`{"docType":"userPII","ID":"u1","name":"Someone"}`), nil)
+	_, err = smartContract.GetUserByBankAccountId(transactionContext, "a1")
+	require.EqualError(t, err, "personal data of user u1 does not match its hash")
+}
+
+func TestGetUsersByNameWithPagination(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetPrivateDataQueryResultReturns(queryResults(
+		`{"docType":"userPII","ID":"u4","name":"Marko"}`,
+		`{"docType":"userPII","ID":"u7","name":"Marko"}`,
+		`{"docType":"userPII","ID":"u9","name":"Marko"}`,
+	), nil)
+
+	// Test Case: A full page continues after its last user
+	page, err := smartContract.GetUsersByNameWithPagination(transactionContext, "Marko", 2, "u1")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	require.Equal(t, int32(2), page.FetchedRecordsCount)
+	require.Equal(t, "u7", page.Bookmark)
+	collection, query := chaincodeStub.GetPrivateDataQueryResultArgsForCall(0)
+	require.Equal(t, "Org1MSPPIICollection", collection)
+	require.JSONEq(t, `{"selector":{"docType":"userPII","name":"Marko","ID":{"$gt":"u1"}},"sort":[{"ID":"asc"}]}`, query)
+
+	// Test Case: The last page has no bookmark
+	chaincodeStub.GetPrivateDataQueryResultReturns(queryResults(`{"docType":"userPII","ID":"u9","name":"Marko"}`), nil)
+	page, err = smartContract.GetUsersByNameWithPagination(transactionContext, "Marko", 2, "u7")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.Equal(t, "u9", page.Records[0].ID)
+	require.Empty(t, page.Bookmark)
+
+	// Test Case: Invalid page size
+	_, err = smartContract.GetUsersByNameWithPagination(transactionContext, "Marko", 0, "")
+	require.EqualError(t, err, "page size must be positive")
+}
+
+func TestQueryUsers(t *testing.T) {
+	// Setup
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(adminIdentity())
+	smartContract := chaincode.SmartContract{}
+
+	chaincodeStub.GetPrivateDataQueryResultReturns(queryResults(`{"docType":"userPII","ID":"u1","surname":"Stojanovic"}`), nil)
+
+	users, err := smartContract.QueryUsers(transactionContext, `{"filters":[{"field":"surname","operator":"$regex","value":"^Stojan"}]}`)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	collection, query := chaincodeStub.GetPrivateDataQueryResultArgsForCall(0)
+	require.Equal(t, "Org1MSPPIICollection", collection)
+	require.JSONEq(t, `{"selector":{"docType":"userPII","surname":{"$regex":"^Stojan"}}}`, query)
+
+	// Test Case: The document type cannot be queried
+	_, err = smartContract.QueryUsers(transactionContext, `{"filters":[{"field":"docType","operator":"$eq","value":"bank"}]}`)
+	require.EqualError(t, err, `field "docType" cannot be queried`)
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"chaincode/model"
+	"fmt"
+	"strings"
+)
+
+// Issuer identification numbers the generated card numbers start with
+var cardIINs = map[model.CardNetwork]string{
+	model.CardVisa:       "4",
+	model.CardMasterCard: "51",
+	model.CardAmex:       "37",
+	model.CardDina:       "9891",
+}
+
+var cardNumberLengths = map[model.CardNetwork]int{
+	model.CardVisa:       16,
+	model.CardMasterCard: 16,
+	model.CardAmex:       15,
+	model.CardDina:       16,
+}
+
+// ParseCardNetwork accepts network codes as well as the names cards used to be
+// stored with, such as "American Express".
+func ParseCardNetwork(network string) (model.CardNetwork, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(network), " ", ""))
+	if normalized == "AMERICANEXPRESS" {
+		normalized = string(model.CardAmex)
+	}
+	if _, ok := cardIINs[model.CardNetwork(normalized)]; !ok {
+		return "", fmt.Errorf("unsupported card network: %s", network)
+	}
+	return model.CardNetwork(normalized), nil
+}
+
+// ValidatePAN checks that the card number belongs to the network and carries a
+// valid Luhn check digit.
+func ValidatePAN(network model.CardNetwork, pan string) error {
+	if len(pan) != cardNumberLengths[network] || !strings.HasPrefix(pan, cardIINs[network]) {
+		return fmt.Errorf("invalid %s card number", network)
+	}
+	for _, digit := range pan {
+		if digit < '0' || digit > '9' {
+			return fmt.Errorf("invalid %s card number", network)
+		}
+	}
+	digits := []byte(pan[:len(pan)-1])
+	if pan[len(pan)-1] != '0'+luhnCheckDigit(digits) {
+		return fmt.Errorf("invalid %s card number", network)
+	}
+	return nil
+}
+
+func luhnCheckDigit(digits []byte) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		// Doubling starts with the rightmost digit since the check digit follows it
+		if (len(digits)-i)%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return byte((10 - sum%10) % 10)
+}
+
+// MaskPAN keeps only the last four digits.
+func MaskPAN(pan string) string {
+	return strings.Repeat("*", len(pan)-4) + pan[len(pan)-4:]
+}
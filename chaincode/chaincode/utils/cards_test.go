@@ -0,0 +1,40 @@
+package utils_test
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePAN(t *testing.T) {
+	require.NoError(t, utils.ValidatePAN(model.CardVisa, "4111111111111111"))
+	require.NoError(t, utils.ValidatePAN(model.CardAmex, "378282246310005"))
+	require.NoError(t, utils.ValidatePAN(model.CardMasterCard, "5105105105105100"))
+
+	// Test Case: Wrong check digit
+	require.EqualError(t, utils.ValidatePAN(model.CardVisa, "4111111111111112"), "invalid VISA card number")
+	// Test Case: Number of another network
+	require.EqualError(t, utils.ValidatePAN(model.CardAmex, "4111111111111111"), "invalid AMEX card number")
+	// Test Case: Not only digits
+	require.EqualError(t, utils.ValidatePAN(model.CardVisa, "4111-11111111111"), "invalid VISA card number")
+}
+
+func TestMaskPAN(t *testing.T) {
+	require.Equal(t, "************1111", utils.MaskPAN("4111111111111111"))
+	require.Equal(t, "***********0005", utils.MaskPAN("378282246310005"))
+}
+
+func TestParseCardNetwork(t *testing.T) {
+	network, err := utils.ParseCardNetwork("American Express")
+	require.NoError(t, err)
+	require.Equal(t, model.CardAmex, network)
+
+	network, err = utils.ParseCardNetwork("MasterCard")
+	require.NoError(t, err)
+	require.Equal(t, model.CardMasterCard, network)
+
+	_, err = utils.ParseCardNetwork("Diners")
+	require.EqualError(t, err, "unsupported card network: Diners")
+}
@@ -1,13 +1,29 @@
 package utils
 
-func EurToDin(eurAmount float64) float64 {
-	exchangeRate := 117.0
-	dinAmount := eurAmount * exchangeRate
-	return dinAmount
-}
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RateDecimals is the precision exchange rates are recorded with.
+const RateDecimals = 8
+
+// SameCurrencyRate is recorded on movements that do not change the currency.
+var SameCurrencyRate = big.NewRat(1, 1).FloatString(RateDecimals)
+
+func ParseRate(rateStr string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(rateStr))
+	if !ok {
+		return nil, fmt.Errorf("invalid exchange rate: %s", rateStr)
+	}
+	if rate.Sign() <= 0 {
+		return nil, fmt.Errorf("exchange rate must be positive")
+	}
 
-func DinToEur(dinAmount float64) float64 {
-	exchangeRate := 117.0
-	eurAmount := dinAmount / exchangeRate
-	return eurAmount
+	precision := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(RateDecimals), nil))
+	if !new(big.Rat).Mul(rate, precision).IsInt() {
+		return nil, fmt.Errorf("exchange rate %s has more than %d decimal places", rateStr, RateDecimals)
+	}
+	return rate, nil
 }
@@ -5,54 +5,115 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"time"
 )
 
-func InitializeData() ([]model.Bank, []model.User, []model.BankAccount) {
+func InitializeData() ([]model.Bank, []model.UserDetails, []model.BankAccount) {
 	banks := []model.Bank{
-		{ID: "b1", Name: "UniCredit", Headquarters: "Linz, Austria", Since: 1969, PIB: 138429230},
-		{ID: "b2", Name: "Raiffeisen Bank", Headquarters: "Vienna, Austria", Since: 1927, PIB: 537891234},
-		{ID: "b3", Name: "Erste Group", Headquarters: "Vienna, Austria", Since: 1819, PIB: 987654321},
-		{ID: "b4", Name: "OTP Bank", Headquarters: "Budapest, Hungary", Since: 1949, PIB: 654321789},
+		{ID: "b1", Name: "UniCredit", Headquarters: "Linz, Austria", Since: 1969, PIB: 138429230, MSPID: "Org1MSP"},
+		{ID: "b2", Name: "Raiffeisen Bank", Headquarters: "Vienna, Austria", Since: 1927, PIB: 537891234, MSPID: "Org2MSP"},
+		{ID: "b3", Name: "Erste Group", Headquarters: "Vienna, Austria", Since: 1819, PIB: 987654321, MSPID: "Org3MSP"},
+		{ID: "b4", Name: "OTP Bank", Headquarters: "Budapest, Hungary", Since: 1949, PIB: 654321789, MSPID: "Org4MSP"},
 	}
 
-	users := []model.User{
-		{ID: "u1", Name: "John", Surname: "Doe", Email: "john.doe@gmail.com"},
-		{ID: "u2", Name: "Alice", Surname: "Smith", Email: "alice.smith@gmail.com"},
-		{ID: "u3", Name: "Bob", Surname: "Johnson", Email: "bob.johnson@gmail.com"},
-		{ID: "u4", Name: "Eva", Surname: "Williams", Email: "eva.williams@gmail.com"},
-		{ID: "u5", Name: "Daniel", Surname: "Miller", Email: "daniel.miller@gmail.com"},
-		{ID: "u6", Name: "Sophia", Surname: "Brown", Email: "sophia.brown@gmail.com"},
-		{ID: "u7", Name: "John", Surname: "Davis", Email: "matthew.davis@gmail.com"},
-		{ID: "u8", Name: "Olivia", Surname: "Jones", Email: "olivia.jones@gmail.com"},
-		{ID: "u9", Name: "Michael", Surname: "Smith", Email: "michael.clark@gmail.com"},
-		{ID: "u10", Name: "Emma", Surname: "Garcia", Email: "emma.garcia@gmail.com"},
-		{ID: "u11", Name: "William", Surname: "Hill", Email: "william.hill@gmail.com"},
-		{ID: "u12", Name: "Ava", Surname: "Martinez", Email: "ava.martinez@gmail.com"},
+	users := []model.UserDetails{
+		{User: model.User{ID: "u1", MSPID: "Org1MSP"}, Name: "John", Surname: "Doe", Email: "john.doe@gmail.com"},
+		{User: model.User{ID: "u2", MSPID: "Org2MSP"}, Name: "Alice", Surname: "Smith", Email: "alice.smith@gmail.com"},
+		{User: model.User{ID: "u3", MSPID: "Org3MSP"}, Name: "Bob", Surname: "Johnson", Email: "bob.johnson@gmail.com"},
+		{User: model.User{ID: "u4", MSPID: "Org4MSP"}, Name: "Eva", Surname: "Williams", Email: "eva.williams@gmail.com"},
+		{User: model.User{ID: "u5", MSPID: "Org1MSP"}, Name: "Daniel", Surname: "Miller", Email: "daniel.miller@gmail.com"},
+		{User: model.User{ID: "u6", MSPID: "Org2MSP"}, Name: "Sophia", Surname: "Brown", Email: "sophia.brown@gmail.com"},
+		{User: model.User{ID: "u7", MSPID: "Org3MSP"}, Name: "John", Surname: "Davis", Email: "matthew.davis@gmail.com"},
+		{User: model.User{ID: "u8", MSPID: "Org4MSP"}, Name: "Olivia", Surname: "Jones", Email: "olivia.jones@gmail.com"},
+		{User: model.User{ID: "u9", MSPID: "Org1MSP"}, Name: "Michael", Surname: "Smith", Email: "michael.clark@gmail.com"},
+		{User: model.User{ID: "u10", MSPID: "Org2MSP"}, Name: "Emma", Surname: "Garcia", Email: "emma.garcia@gmail.com"},
+		{User: model.User{ID: "u11", MSPID: "Org3MSP"}, Name: "William", Surname: "Hill", Email: "william.hill@gmail.com"},
+		{User: model.User{ID: "u12", MSPID: "Org4MSP"}, Name: "Ava", Surname: "Martinez", Email: "ava.martinez@gmail.com"},
 	}
 
 	bankAccounts := []model.BankAccount{
-		{ID: "a1", Balance: 1500, Currency: model.RSD, Cards: []string{"Visa"}, Bank: banks[0], UserID: users[0].ID},
-		{ID: "a2", Balance: 80000, Currency: model.EUR, Cards: []string{"MasterCard", "American Express"}, Bank: banks[1], UserID: users[1].ID},
-		{ID: "a3", Balance: 300, Currency: model.RSD, Cards: []string{"Dina"}, Bank: banks[2], UserID: users[2].ID},
-		{ID: "a4", Balance: 4500, Currency: model.EUR, Cards: []string{"Visa"}, Bank: banks[3], UserID: users[3].ID},
-		{ID: "a5", Balance: 1200, Currency: model.EUR, Cards: []string{"MasterCard"}, Bank: banks[0], UserID: users[4].ID},
-		{ID: "a6", Balance: 60000, Currency: model.RSD, Cards: []string{"Dina", "Visa"}, Bank: banks[1], UserID: users[5].ID},
-		{ID: "a7", Balance: 900, Currency: model.RSD, Cards: []string{"American Express"}, Bank: banks[2], UserID: users[6].ID},
-		{ID: "a8", Balance: 20000, Currency: model.EUR, Cards: []string{"Visa", "MasterCard"}, Bank: banks[3], UserID: users[7].ID},
-		{ID: "a9", Balance: 700, Currency: model.RSD, Cards: []string{"Dina"}, Bank: banks[0], UserID: users[8].ID},
-		{ID: "a10", Balance: 3500, Currency: model.EUR, Cards: []string{"MasterCard"}, Bank: banks[1], UserID: users[9].ID},
-		{ID: "a11", Balance: 800, Currency: model.EUR, Cards: []string{"Visa"}, Bank: banks[2], UserID: users[10].ID},
-		{ID: "a12", Balance: 40000, Currency: model.RSD, Cards: []string{"Dina"}, Bank: banks[3], UserID: users[11].ID},
-		{ID: "a13", Balance: 1100, Currency: model.RSD, Cards: []string{"American Express"}, Bank: banks[0], UserID: users[0].ID},
-		{ID: "a14", Balance: 55000, Currency: model.EUR, Cards: []string{"MasterCard"}, Bank: banks[1], UserID: users[1].ID},
-		{ID: "a15", Balance: 750, Currency: model.RSD, Cards: []string{"Dina", "Visa"}, Bank: banks[2], UserID: users[2].ID},
-		{ID: "a16", Balance: 6000, Currency: model.EUR, Cards: []string{"American Express", "MasterCard"}, Bank: banks[3], UserID: users[3].ID},
-		{ID: "a17", Balance: 950, Currency: model.EUR, Cards: []string{"Visa"}, Bank: banks[0], UserID: users[4].ID},
-		{ID: "a18", Balance: 30000, Currency: model.RSD, Cards: []string{"Dina", "MasterCard"}, Bank: banks[1], UserID: users[5].ID},
+		{ID: "a1", Balance: 1500_00, Currency: model.RSD, BankID: banks[0].ID, UserID: users[0].ID},
+		{ID: "a2", Balance: 80000_00, Currency: model.EUR, BankID: banks[1].ID, UserID: users[1].ID},
+		{ID: "a3", Balance: 300_00, Currency: model.RSD, BankID: banks[2].ID, UserID: users[2].ID},
+		{ID: "a4", Balance: 4500_00, Currency: model.EUR, BankID: banks[3].ID, UserID: users[3].ID},
+		{ID: "a5", Balance: 1200_00, Currency: model.EUR, BankID: banks[0].ID, UserID: users[4].ID},
+		{ID: "a6", Balance: 60000_00, Currency: model.RSD, BankID: banks[1].ID, UserID: users[5].ID},
+		{ID: "a7", Balance: 900_00, Currency: model.RSD, BankID: banks[2].ID, UserID: users[6].ID},
+		{ID: "a8", Balance: 20000_00, Currency: model.EUR, BankID: banks[3].ID, UserID: users[7].ID},
+		{ID: "a9", Balance: 700_00, Currency: model.RSD, BankID: banks[0].ID, UserID: users[8].ID},
+		{ID: "a10", Balance: 3500_00, Currency: model.EUR, BankID: banks[1].ID, UserID: users[9].ID},
+		{ID: "a11", Balance: 800_00, Currency: model.EUR, BankID: banks[2].ID, UserID: users[10].ID},
+		{ID: "a12", Balance: 40000_00, Currency: model.RSD, BankID: banks[3].ID, UserID: users[11].ID},
+		{ID: "a13", Balance: 1100_00, Currency: model.RSD, BankID: banks[0].ID, UserID: users[0].ID},
+		{ID: "a14", Balance: 55000_00, Currency: model.EUR, BankID: banks[1].ID, UserID: users[1].ID},
+		{ID: "a15", Balance: 750_00, Currency: model.RSD, BankID: banks[2].ID, UserID: users[2].ID},
+		{ID: "a16", Balance: 6000_00, Currency: model.EUR, BankID: banks[3].ID, UserID: users[3].ID},
+		{ID: "a17", Balance: 950_00, Currency: model.EUR, BankID: banks[0].ID, UserID: users[4].ID},
+		{ID: "a18", Balance: 30000_00, Currency: model.RSD, BankID: banks[1].ID, UserID: users[5].ID},
 	}
 	return banks, users, bankAccounts
 }
 
+// InitializeCards lists the card networks of the cards issued with each seeded account.
+func InitializeCards() map[string][]model.CardNetwork {
+	return map[string][]model.CardNetwork{
+		"a1":  {model.CardVisa},
+		"a2":  {model.CardMasterCard, model.CardAmex},
+		"a3":  {model.CardDina},
+		"a4":  {model.CardVisa},
+		"a5":  {model.CardMasterCard},
+		"a6":  {model.CardDina, model.CardVisa},
+		"a7":  {model.CardAmex},
+		"a8":  {model.CardVisa, model.CardMasterCard},
+		"a9":  {model.CardDina},
+		"a10": {model.CardMasterCard},
+		"a11": {model.CardVisa},
+		"a12": {model.CardDina},
+		"a13": {model.CardAmex},
+		"a14": {model.CardMasterCard},
+		"a15": {model.CardDina, model.CardVisa},
+		"a16": {model.CardAmex, model.CardMasterCard},
+		"a17": {model.CardVisa},
+		"a18": {model.CardDina, model.CardMasterCard},
+	}
+}
+
+// InitializeFeeSchedules lists what the seeded banks charge in EUR and RSD.
+func InitializeFeeSchedules() []model.FeeSchedule {
+	return []model.FeeSchedule{
+		{BankID: "b1", Currency: model.EUR, Withdrawal: &model.Fee{Type: model.FeeFlat, Amount: 1_00}, InterbankTransfer: &model.Fee{Type: model.FeePercentage, Rate: "0.00500000"}, Conversion: &model.Fee{Type: model.FeePercentage, Rate: "0.01000000"}},
+		{BankID: "b1", Currency: model.RSD, Withdrawal: &model.Fee{Type: model.FeeFlat, Amount: 100_00}, InterbankTransfer: &model.Fee{Type: model.FeePercentage, Rate: "0.00500000"}, Conversion: &model.Fee{Type: model.FeePercentage, Rate: "0.01000000"}},
+		{BankID: "b2", Currency: model.EUR, Withdrawal: &model.Fee{Type: model.FeePercentage, Rate: "0.00200000"}, InterbankTransfer: &model.Fee{Type: model.FeeFlat, Amount: 2_00}, Conversion: &model.Fee{Type: model.FeePercentage, Rate: "0.00750000"}},
+		{BankID: "b2", Currency: model.RSD, Withdrawal: &model.Fee{Type: model.FeePercentage, Rate: "0.00200000"}, InterbankTransfer: &model.Fee{Type: model.FeeFlat, Amount: 200_00}, Conversion: &model.Fee{Type: model.FeePercentage, Rate: "0.00750000"}},
+		{BankID: "b3", Currency: model.EUR, InterbankTransfer: &model.Fee{Type: model.FeeFlat, Amount: 1_50}, Conversion: &model.Fee{Type: model.FeePercentage, Rate: "0.01000000"}},
+		{BankID: "b3", Currency: model.RSD, InterbankTransfer: &model.Fee{Type: model.FeeFlat, Amount: 150_00}, Conversion: &model.Fee{Type: model.FeePercentage, Rate: "0.01000000"}},
+		{BankID: "b4", Currency: model.EUR, Withdrawal: &model.Fee{Type: model.FeeFlat, Amount: 50}, InterbankTransfer: &model.Fee{Type: model.FeePercentage, Rate: "0.00250000"}},
+		{BankID: "b4", Currency: model.RSD, Withdrawal: &model.Fee{Type: model.FeeFlat, Amount: 50_00}, InterbankTransfer: &model.Fee{Type: model.FeePercentage, Rate: "0.00250000"}},
+	}
+}
+
+func InitializeCurrencies() ([]model.CurrencyDefinition, model.CurrencySettings) {
+	currencies := []model.CurrencyDefinition{
+		{Code: model.EUR, Name: "Euro", MinorUnits: 2, Rounding: model.RoundHalfEven, Enabled: true},
+		{Code: model.RSD, Name: "Serbian dinar", MinorUnits: 2, Rounding: model.RoundHalfUp, Enabled: true},
+		{Code: "USD", Name: "US dollar", MinorUnits: 2, Rounding: model.RoundHalfEven, Enabled: true},
+		{Code: "CHF", Name: "Swiss franc", MinorUnits: 2, Rounding: model.RoundHalfEven, Enabled: true},
+		{Code: "HUF", Name: "Hungarian forint", MinorUnits: 2, Rounding: model.RoundHalfUp, Enabled: true},
+	}
+	return currencies, model.CurrencySettings{BaseCurrency: model.EUR}
+}
+
+func InitializeExchangeRates() []model.ExchangeRate {
+	// Effective since the beginning of time so transfers work on a fresh ledger
+	since := time.Unix(0, 0).UTC()
+	return []model.ExchangeRate{
+		{From: model.EUR, To: model.RSD, Rate: "117.00000000", EffectiveFrom: since, PublishedBy: "InitLedger"},
+		{From: model.EUR, To: "USD", Rate: "1.08000000", EffectiveFrom: since, PublishedBy: "InitLedger"},
+		{From: model.EUR, To: "CHF", Rate: "0.95000000", EffectiveFrom: since, PublishedBy: "InitLedger"},
+		{From: model.EUR, To: "HUF", Rate: "390.00000000", EffectiveFrom: since, PublishedBy: "InitLedger"},
+	}
+}
+
 func PutDataToState(ctx contractapi.TransactionContextInterface, data interface{}, id string) error {
 	dataJSON, err := json.Marshal(data)
 	if err != nil {
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// InterestDecimals is the precision, in minor units, interest that has been
+// accrued but not charged or paid yet is kept with.
+const InterestDecimals = 8
+
+// Interest is computed on an actual/365 day count basis
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// ParseInterestRate reads a yearly interest rate given as a fraction, such as
+// "0.125" for 12.5%.
+func ParseInterestRate(rateStr string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(rateStr))
+	if !ok {
+		return nil, fmt.Errorf("invalid interest rate: %s", rateStr)
+	}
+	if rate.Sign() < 0 {
+		return nil, fmt.Errorf("interest rate cannot be negative")
+	}
+
+	precision := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(RateDecimals), nil))
+	if !new(big.Rat).Mul(rate, precision).IsInt() {
+		return nil, fmt.Errorf("interest rate %s has more than %d decimal places", rateStr, RateDecimals)
+	}
+	return rate, nil
+}
+
+// ParseAccruedInterest reads interest kept with InterestDecimals precision, an
+// empty string is no interest.
+func ParseAccruedInterest(interestStr string) (*big.Rat, error) {
+	if interestStr == "" {
+		return new(big.Rat), nil
+	}
+	interest, ok := new(big.Rat).SetString(interestStr)
+	if !ok {
+		return nil, fmt.Errorf("invalid accrued interest: %s", interestStr)
+	}
+	return interest, nil
+}
+
+// AccrueInterest returns the simple interest, in minor units, on a principal
+// held from one time to another at a yearly rate.
+func AccrueInterest(principal int64, yearlyRate *big.Rat, from, to time.Time) *big.Rat {
+	if !to.After(from) {
+		return new(big.Rat)
+	}
+
+	interest := new(big.Rat).SetInt64(principal)
+	interest.Mul(interest, yearlyRate)
+	return interest.Mul(interest, big.NewRat(int64(to.Sub(from)/time.Second), secondsPerYear))
+}
+
+// WholeMinorUnits splits interest into the whole minor units that can be
+// booked and the fraction that is carried over.
+func WholeMinorUnits(interest *big.Rat) (int64, *big.Rat) {
+	whole := new(big.Int).Quo(interest.Num(), interest.Denom())
+	return whole.Int64(), new(big.Rat).Sub(interest, new(big.Rat).SetInt(whole))
+}
@@ -0,0 +1,38 @@
+package utils_test
+
+import (
+	"chaincode/chaincode/utils"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrueInterest(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// 1000.00 at 12.5% for 73 days, a fifth of a year
+	interest := utils.AccrueInterest(1000_00, big.NewRat(1, 8), from, from.AddDate(0, 0, 73))
+	require.Equal(t, "2500.00000000", interest.FloatString(utils.InterestDecimals))
+
+	require.Zero(t, utils.AccrueInterest(1000_00, big.NewRat(1, 8), from, from).Sign())
+}
+
+func TestParseInterestRate(t *testing.T) {
+	rate, err := utils.ParseInterestRate("0.125")
+	require.NoError(t, err)
+	require.Equal(t, big.NewRat(1, 8), rate)
+
+	_, err = utils.ParseInterestRate("-0.1")
+	require.EqualError(t, err, "interest rate cannot be negative")
+
+	_, err = utils.ParseInterestRate("twelve")
+	require.EqualError(t, err, "invalid interest rate: twelve")
+}
+
+func TestWholeMinorUnits(t *testing.T) {
+	whole, remainder := utils.WholeMinorUnits(big.NewRat(1234567, 1000))
+	require.Equal(t, int64(1234), whole)
+	require.Equal(t, "0.567", remainder.FloatString(3))
+}
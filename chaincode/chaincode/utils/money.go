@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"chaincode/model"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Amounts are kept in minor units (cents, para), the currency definition says
+// how many of them make a major unit and how fractions of a minor unit are rounded.
+
+// ParseAmount converts a decimal string such as "75.50" to minor units of the
+// currency. Amounts more precise than the currency allows are rejected.
+func ParseAmount(amountStr string, currency model.CurrencyDefinition) (int64, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amountStr))
+	if !ok {
+		return 0, fmt.Errorf("invalid amount: %s", amountStr)
+	}
+
+	minorValue := new(big.Rat).Mul(value, new(big.Rat).SetInt(minorUnitsFactor(currency.MinorUnits)))
+	if !minorValue.IsInt() {
+		return 0, fmt.Errorf("amount %s has more than %d decimal places", amountStr, currency.MinorUnits)
+	}
+	if !minorValue.Num().IsInt64() {
+		return 0, fmt.Errorf("amount %s is out of range", amountStr)
+	}
+
+	return minorValue.Num().Int64(), nil
+}
+
+// ToMinorUnits rounds an arbitrary precision major unit value using the currency rules.
+func ToMinorUnits(value *big.Rat, currency model.CurrencyDefinition) (int64, error) {
+	return Round(new(big.Rat).Mul(value, new(big.Rat).SetInt(minorUnitsFactor(currency.MinorUnits))), currency.Rounding)
+}
+
+func FormatAmount(amount int64, currency model.CurrencyDefinition) string {
+	return new(big.Rat).SetFrac(big.NewInt(amount), minorUnitsFactor(currency.MinorUnits)).FloatString(currency.MinorUnits)
+}
+
+// ConvertAmount converts minor units of one currency to minor units of another,
+// rate is the number of major target units per major source unit.
+func ConvertAmount(amount int64, from, to model.CurrencyDefinition, rate *big.Rat) (int64, error) {
+	major := new(big.Rat).SetFrac(big.NewInt(amount), minorUnitsFactor(from.MinorUnits))
+	return ToMinorUnits(major.Mul(major, rate), to)
+}
+
+func Round(value *big.Rat, mode model.RoundingMode) (int64, error) {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+
+	if remainder.Sign() != 0 {
+		doubledRemainder := new(big.Int).Abs(remainder)
+		doubledRemainder.Lsh(doubledRemainder, 1)
+		comparison := doubledRemainder.Cmp(value.Denom())
+
+		awayFromZero := false
+		switch mode {
+		case model.RoundHalfUp:
+			awayFromZero = comparison >= 0
+		case model.RoundHalfEven:
+			awayFromZero = comparison > 0 || (comparison == 0 && quotient.Bit(0) == 1)
+		default:
+			return 0, fmt.Errorf("unknown rounding mode %s", mode)
+		}
+
+		if awayFromZero {
+			quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+		}
+	}
+
+	if !quotient.IsInt64() {
+		return 0, fmt.Errorf("amount %s is out of range", value.FloatString(2))
+	}
+	return quotient.Int64(), nil
+}
+
+func minorUnitsFactor(minorUnits int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(minorUnits)), nil)
+}
@@ -0,0 +1,108 @@
+package utils_test
+
+import (
+	"chaincode/chaincode/utils"
+	"chaincode/model"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	eur = model.CurrencyDefinition{Code: model.EUR, MinorUnits: 2, Rounding: model.RoundHalfEven}
+	rsd = model.CurrencyDefinition{Code: model.RSD, MinorUnits: 2, Rounding: model.RoundHalfUp}
+	jpy = model.CurrencyDefinition{Code: "JPY", MinorUnits: 0, Rounding: model.RoundHalfEven}
+	bhd = model.CurrencyDefinition{Code: "BHD", MinorUnits: 3, Rounding: model.RoundHalfUp}
+)
+
+func TestParseAmount(t *testing.T) {
+	amount, err := utils.ParseAmount("75.5", eur)
+	require.NoError(t, err)
+	require.Equal(t, int64(75_50), amount)
+
+	amount, err = utils.ParseAmount("1500", rsd)
+	require.NoError(t, err)
+	require.Equal(t, int64(1500_00), amount)
+
+	_, err = utils.ParseAmount("1.005", eur)
+	require.EqualError(t, err, "amount 1.005 has more than 2 decimal places")
+
+	amount, err = utils.ParseAmount("1500", jpy)
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), amount)
+
+	_, err = utils.ParseAmount("1500.5", jpy)
+	require.EqualError(t, err, "amount 1500.5 has more than 0 decimal places")
+
+	amount, err = utils.ParseAmount("1.005", bhd)
+	require.NoError(t, err)
+	require.Equal(t, int64(1005), amount)
+
+	_, err = utils.ParseAmount("ten", eur)
+	require.EqualError(t, err, "invalid amount: ten")
+
+	_, err = utils.ParseAmount("1e30", eur)
+	require.EqualError(t, err, "amount 1e30 is out of range")
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "75.05", utils.FormatAmount(75_05, eur))
+	require.Equal(t, "-0.03", utils.FormatAmount(-3, rsd))
+	require.Equal(t, "1500", utils.FormatAmount(1500, jpy))
+	require.Equal(t, "1.005", utils.FormatAmount(1005, bhd))
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		value    *big.Rat
+		mode     model.RoundingMode
+		expected int64
+	}{
+		{big.NewRat(5, 2), model.RoundHalfUp, 3},
+		{big.NewRat(5, 2), model.RoundHalfEven, 2},
+		{big.NewRat(7, 2), model.RoundHalfEven, 4},
+		{big.NewRat(-5, 2), model.RoundHalfUp, -3},
+		{big.NewRat(-5, 2), model.RoundHalfEven, -2},
+		{big.NewRat(24, 10), model.RoundHalfUp, 2},
+		{big.NewRat(26, 10), model.RoundHalfEven, 3},
+	}
+
+	for _, c := range cases {
+		rounded, err := utils.Round(c.value, c.mode)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, rounded, c.value.String())
+	}
+}
+
+func TestConvertAmount(t *testing.T) {
+	converted, err := utils.ConvertAmount(10_01, eur, rsd, big.NewRat(117, 1))
+	require.NoError(t, err)
+	require.Equal(t, int64(1171_17), converted)
+
+	// 100 RSD is 0.854700... EUR
+	converted, err = utils.ConvertAmount(100_00, rsd, eur, big.NewRat(1, 117))
+	require.NoError(t, err)
+	require.Equal(t, int64(85), converted)
+
+	// Between currencies with different minor units
+	converted, err = utils.ConvertAmount(10_00, eur, jpy, big.NewRat(16250, 100))
+	require.NoError(t, err)
+	require.Equal(t, int64(1625), converted)
+
+	converted, err = utils.ConvertAmount(1625, jpy, bhd, big.NewRat(25, 10000))
+	require.NoError(t, err)
+	require.Equal(t, int64(4063), converted)
+}
+
+func TestParseRate(t *testing.T) {
+	rate, err := utils.ParseRate("117.25")
+	require.NoError(t, err)
+	require.Equal(t, "117.25000000", rate.FloatString(utils.RateDecimals))
+
+	_, err = utils.ParseRate("0")
+	require.EqualError(t, err, "exchange rate must be positive")
+
+	_, err = utils.ParseRate("0.000000001")
+	require.EqualError(t, err, "exchange rate 0.000000001 has more than 8 decimal places")
+}
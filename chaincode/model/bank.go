@@ -1,9 +1,13 @@
 package model
 
+const BankDocType = "bank"
+
 type Bank struct {
+	DocType      string `json:"docType"`
 	ID           string `json:"ID"`
 	Name         string `json:"name"`
 	Headquarters string `json:"headquarters"`
 	Since        int    `json:"since"`
 	PIB          int    `json:"pib"`
+	MSPID        string `json:"msp_id"` // organization running the bank
 }
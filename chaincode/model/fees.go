@@ -0,0 +1,59 @@
+package model
+
+const (
+	FeeScheduleDocType    = "feeSchedule"
+	RevenueAccountDocType = "revenueAccount"
+)
+
+type FeeType string
+
+const (
+	FeeFlat       FeeType = "FLAT"
+	FeePercentage FeeType = "PERCENTAGE"
+)
+
+// FeeOperation is what a fee is charged for
+type FeeOperation string
+
+const (
+	FeeOnWithdrawal        FeeOperation = "WITHDRAWAL"
+	FeeOnInterbankTransfer FeeOperation = "INTERBANK_TRANSFER"
+	FeeOnConversion        FeeOperation = "CONVERSION"
+)
+
+// Fee is either a flat amount or a percentage of the amount of the operation
+type Fee struct {
+	Type   FeeType `json:"type"`
+	Amount int64   `json:"amount,omitempty"` // flat fees, in minor units of the schedule currency
+	Rate   string  `json:"rate,omitempty"`   // percentage fees as a fraction, e.g. "0.005"
+}
+
+// FeeSchedule is what a bank charges on operations of its accounts in one
+// currency. Operations without a fee are free.
+type FeeSchedule struct {
+	DocType           string   `json:"docType"`
+	ID                string   `json:"ID"`
+	BankID            string   `json:"bank_id"`
+	Currency          Currency `json:"currency"`
+	Withdrawal        *Fee     `json:"withdrawal,omitempty"`
+	InterbankTransfer *Fee     `json:"interbank_transfer,omitempty"`
+	Conversion        *Fee     `json:"conversion,omitempty"`
+}
+
+// AppliedFee is a fee charged on top of the amount of a transaction
+type AppliedFee struct {
+	Operation FeeOperation `json:"operation"`
+	BankID    string       `json:"bank_id"`
+	Amount    int64        `json:"amount"` // in minor units of Currency
+	Currency  Currency     `json:"currency"`
+}
+
+// RevenueAccount collects the fees a bank charged in one currency. It is kept
+// apart from customer accounts so queries over them do not pick it up.
+type RevenueAccount struct {
+	DocType   string   `json:"docType"`
+	ID        string   `json:"ID"`
+	BankID    string   `json:"bank_id"`
+	Currency  Currency `json:"currency"`
+	Collected int64    `json:"collected"`
+}
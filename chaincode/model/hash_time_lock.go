@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+const (
+	HashTimeLockDocType    = "hashTimeLock"
+	ClearingAccountDocType = "clearingAccount"
+)
+
+// LockDirection tells which side of a cross-channel transfer a lock is on
+type LockDirection string
+
+const (
+	LockOutgoing LockDirection = "OUTGOING" // money leaves an account of this channel
+	LockIncoming LockDirection = "INCOMING" // money arrives to an account of this channel
+)
+
+type LockStatus string
+
+const (
+	LockLocked   LockStatus = "LOCKED"
+	LockClaimed  LockStatus = "CLAIMED"
+	LockRefunded LockStatus = "REFUNDED"
+)
+
+// HashTimeLock is one side of a transfer between channels. Both sides share
+// the hash of a secret: revealing the secret claims the money on either side
+// before the lock times out, afterwards it is refunded. Amounts are in minor
+// units of Currency.
+type HashTimeLock struct {
+	DocType   string        `json:"docType"`
+	ID        string        `json:"ID"`
+	HashLock  string        `json:"hash_lock"` // hex encoded SHA-256 of the secret
+	Direction LockDirection `json:"direction"`
+	AccountID string        `json:"account_id"`
+	Amount    int64         `json:"amount"`
+	Currency  Currency      `json:"currency"`
+	// Channel and account on the other side, for reference only
+	CounterpartyChannel string     `json:"counterparty_channel"`
+	CounterpartyAccount string     `json:"counterparty_account"`
+	Status              LockStatus `json:"status"`
+	LockedAt            time.Time  `json:"locked_at"`
+	Timeout             time.Time  `json:"timeout"`
+	// Revealed when the lock is claimed, so the other side can be claimed too
+	Secret        string    `json:"secret,omitempty"`
+	ClosedAt      time.Time `json:"closed_at"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+}
+
+// ClearingAccount keeps what moved between this channel and the others in one
+// currency: claimed outgoing locks add to its position, claimed incoming ones
+// take from it. Positions of all channels add up to zero.
+type ClearingAccount struct {
+	DocType  string   `json:"docType"`
+	ID       string   `json:"ID"`
+	Currency Currency `json:"currency"`
+	Position int64    `json:"position"`
+}
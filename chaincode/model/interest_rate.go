@@ -0,0 +1,13 @@
+package model
+
+const InterestRateDocType = "interestRate"
+
+// InterestRate is the yearly interest a bank pays on positive balances of
+// accounts of one product.
+type InterestRate struct {
+	DocType string         `json:"docType"`
+	ID      string         `json:"ID"`
+	BankID  string         `json:"bank_id"`
+	Product AccountProduct `json:"product"`
+	Rate    string         `json:"rate"` // e.g. "0.025"
+}
@@ -0,0 +1,16 @@
+package model
+
+// Pages of query results. Bookmark is passed with the next request to continue
+// after the last record and is empty once there are no more records.
+
+type UserPIIPage struct {
+	Records             []UserPII `json:"records"`
+	FetchedRecordsCount int32     `json:"fetched_records_count"`
+	Bookmark            string    `json:"bookmark"`
+}
+
+type BankAccountDetailsPage struct {
+	Records             []BankAccountDetails `json:"records"`
+	FetchedRecordsCount int32                `json:"fetched_records_count"`
+	Bookmark            string               `json:"bookmark"`
+}
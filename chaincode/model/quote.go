@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const TransferQuoteDocType = "transferQuote"
+
+type QuoteStatus string
+
+const (
+	QuoteOpen     QuoteStatus = "OPEN"
+	QuoteExecuted QuoteStatus = "EXECUTED"
+)
+
+// TransferQuote fixes the terms of a transfer until it expires, amounts are in
+// minor units of their currencies.
+type TransferQuote struct {
+	DocType            string       `json:"docType"`
+	ID                 string       `json:"ID"`
+	SourceAccount      string       `json:"source_account"`
+	DestinationAccount string       `json:"destination_account"`
+	Amount             int64        `json:"amount"`
+	Currency           Currency     `json:"currency"`
+	ConvertedAmount    int64        `json:"converted_amount"`
+	ConvertedCurrency  Currency     `json:"converted_currency"`
+	Rate               string       `json:"rate"`
+	RateIDs            []string     `json:"rate_ids,omitempty"`
+	Fee                int64        `json:"fee"` // total of Fees, on top of Amount
+	Fees               []AppliedFee `json:"fees,omitempty"`
+	CreatedAt          time.Time    `json:"created_at"`
+	ExpiresAt          time.Time    `json:"expires_at"`
+	Status             QuoteStatus  `json:"status"`
+	TransactionID      string       `json:"transaction_id,omitempty"`
+}
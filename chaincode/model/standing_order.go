@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+const StandingOrderDocType = "standingOrder"
+
+type StandingOrderSchedule string
+
+const (
+	ScheduleDaily   StandingOrderSchedule = "DAILY"
+	ScheduleWeekly  StandingOrderSchedule = "WEEKLY"
+	ScheduleMonthly StandingOrderSchedule = "MONTHLY"
+)
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderActive    StandingOrderStatus = "ACTIVE"
+	StandingOrderCancelled StandingOrderStatus = "CANCELLED"
+	StandingOrderFinished  StandingOrderStatus = "FINISHED"
+	// Stopped because an account was closed or too many runs in a row failed
+	StandingOrderFailed StandingOrderStatus = "FAILED"
+)
+
+// StandingOrder transfers the same amount, in minor units of the source
+// account currency, on every run of its schedule until the end date.
+type StandingOrder struct {
+	DocType            string                `json:"docType"`
+	ID                 string                `json:"ID"`
+	UserID             string                `json:"user_id"`
+	SourceAccount      string                `json:"source_account"`
+	DestinationAccount string                `json:"destination_account"`
+	Amount             int64                 `json:"amount"`
+	Currency           Currency              `json:"currency"`
+	Schedule           StandingOrderSchedule `json:"schedule"`
+	FirstRun           time.Time             `json:"first_run"`
+	// Runs counts the past runs, failed ones included
+	Runs    int       `json:"runs"`
+	NextRun time.Time `json:"next_run"`
+	// Without an end date the order runs until it is cancelled
+	EndDate           time.Time              `json:"end_date"`
+	Status            StandingOrderStatus    `json:"status"`
+	LastTransactionID string                 `json:"last_transaction_id,omitempty"`
+	Failures          []StandingOrderFailure `json:"failures,omitempty"`
+	// FailedRuns counts the failed runs since the last executed one
+	FailedRuns int `json:"failed_runs,omitempty"`
+}
+
+type StandingOrderFailure struct {
+	RunAt  time.Time `json:"run_at"`
+	Reason string    `json:"reason"`
+}
+
+// StandingOrderRun is the outcome of one due order, it is only returned and
+// never stored.
+type StandingOrderRun struct {
+	OrderID       string `json:"order_id"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
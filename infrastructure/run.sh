@@ -3,8 +3,8 @@
 ./network.sh down
 ./network.sh up
 ./network.sh createChannel
-./network.sh deployCC -ccp ../chaincode/ -ccn bankchaincode1 -c channel1
-./network.sh deployCC -ccp ../chaincode/ -ccn bankchaincode2 -c channel2
+./network.sh deployCC -ccp ../chaincode/ -ccn bankchaincode1 -c channel1 -cccg ../chaincode/collections_config.json
+./network.sh deployCC -ccp ../chaincode/ -ccn bankchaincode2 -c channel2 -cccg ../chaincode/collections_config.json
 
 # Init ledger
 cd utils